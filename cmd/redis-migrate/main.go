@@ -0,0 +1,82 @@
+// Command redis-migrate copies keys between two redis servers with DUMP and RESTORE.
+//
+//	redis-migrate -source 10.0.0.1:6379 -target 10.0.0.2:6379 -match 'user:*' -rate 5000
+//
+// On interrupt it prints the cursor to pass to -cursor to resume the migration.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+	"github.com/5112100070/publib/storage/redis/migrate"
+	"github.com/5112100070/publib/storage/redis/redigo"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run migrates the keys and returns the exit code, so the deferred closes run
+func run() int {
+	var (
+		source  = flag.String("source", "", "source endpoint, host:port")
+		target  = flag.String("target", "", "target endpoint, host:port")
+		match   = flag.String("match", "", "glob pattern of the keys copied, every key when empty")
+		keyType = flag.String("type", "", "only copy the keys of this type, e.g. hash")
+		count   = flag.Int("count", 100, "SCAN page size hint")
+		cursor  = flag.Int("cursor", 0, "cursor to resume from")
+		rate    = flag.Int("rate", 0, "keys copied per second, zero is unlimited")
+		replace = flag.Bool("replace", false, "overwrite the keys existing on the target")
+		dryRun  = flag.Bool("dry-run", false, "scan without copying")
+		every   = flag.Duration("progress", 5*time.Second, "interval of the progress logs")
+	)
+	flag.Parse()
+
+	if *source == "" || *target == "" {
+		flag.Usage()
+		return 2
+	}
+
+	src := redigo.New(redigo.Config{Endpoint: *source})
+	defer src.Close()
+	dst := redigo.New(redigo.Config{Endpoint: *target})
+	defer dst.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var logged time.Time
+	progress, err := migrate.Migrate(ctx, src, dst, migrate.Config{
+		Scan:    redis.ScanOptions{Match: *match, Count: *count, Type: *keyType},
+		Cursor:  *cursor,
+		Rate:    *rate,
+		Replace: *replace,
+		DryRun:  *dryRun,
+		OnProgress: func(p migrate.Progress) {
+			if time.Since(logged) >= *every {
+				logged = time.Now()
+				logProgress(p)
+			}
+		},
+	})
+	logProgress(progress)
+
+	if err != nil {
+		log.Printf("stopped: %v, resume with -cursor %d", err, progress.Cursor)
+		return 1
+	}
+
+	return 0
+}
+
+func logProgress(p migrate.Progress) {
+	log.Printf("cursor %d: scanned %d, copied %d, existing %d, expired %d in %v",
+		p.Cursor, p.Scanned, p.Copied, p.Existing, p.Expired, p.Elapsed.Round(time.Millisecond))
+}
@@ -9,7 +9,16 @@ import (
 
 const jakartaTZ = "Asia/Jakarta"
 
-var jakartaLocation, _ = time.LoadLocation(jakartaTZ)
+// JakartaLocation is the WIB (UTC+7) time zone, a fixed zone when the tz database is not available
+var JakartaLocation = loadJakartaLocation()
+
+func loadJakartaLocation() *time.Location {
+	location, err := time.LoadLocation(jakartaTZ)
+	if err != nil {
+		return time.FixedZone("WIB", 7*60*60)
+	}
+	return location
+}
 
 // ToFloat64 Convert any value to float64
 func ToFloat64(v interface{}) (result float64) {
@@ -24,6 +33,8 @@ func ToFloat64(v interface{}) (result float64) {
 		result = v
 	case uint8:
 		result, _ = strconv.ParseFloat(string(v), 64)
+	case []uint8:
+		result, _ = strconv.ParseFloat(string(v), 64)
 	default:
 	}
 
@@ -149,5 +160,5 @@ func ToString(v interface{}) (result string) {
 // input: t = 2018-10-30 00:00:00 +0000 UTC, offset = 28800(SG). output: 2018-10-29 23:00:00 +0700 WIB
 // input: t = 2018-10-30 00:00:00 +0000 UTC, offset = -14400(EDT DST). output: 2018-10-30 11:00:00 +0700 WIB
 func FixMySQLTime(t time.Time, offset int) time.Time {
-	return t.In(jakartaLocation).Add(time.Duration(-offset) * time.Second)
+	return t.In(JakartaLocation).Add(time.Duration(-offset) * time.Second)
 }
@@ -0,0 +1,39 @@
+package encoding
+
+import (
+	"math/big"
+)
+
+// ToBitmap converts a bitmask into a redis bitmap, so that bit n of the bitmask
+// is the bit at offset n read by GETBIT.
+// Like Has, it cannot handle bitmask below 0
+func ToBitmap(bitmask string) []byte {
+	bigIntBitmask := big.NewInt(0)
+	bigIntBitmask.SetString(bitmask, 10)
+
+	length := bigIntBitmask.BitLen()
+	bitmap := make([]byte, (length+7)/8)
+	for i := 0; i < length; i++ {
+		if bigIntBitmask.Bit(i) == 1 {
+			//redis counts the offset from the most significant bit of the first byte
+			bitmap[i/8] |= 0x80 >> uint(i%8)
+		}
+	}
+
+	return bitmap
+}
+
+// FromBitmap converts a redis bitmap back into a bitmask
+func FromBitmap(bitmap []byte) string {
+	bitmask := big.NewInt(0)
+
+	for i, b := range bitmap {
+		for j := 0; j < 8; j++ {
+			if b&(0x80>>uint(j)) != 0 {
+				bitmask.SetBit(bitmask, i*8+j, 1)
+			}
+		}
+	}
+
+	return bitmask.String()
+}
@@ -15,18 +15,27 @@ type HMACAuthData struct {
 }
 
 func (this HMACAuthData) GenerateHMACHash(secret string) string {
-	key := []byte(secret)
-	h := hmac.New(sha1.New, key)
-
 	message := fmt.Sprintf(
 		"%s\n%s\n%d",
 		this.Method,
 		this.Path,
 		this.Date,
 	)
-	h.Write([]byte(message))
 
-	hash := base64.StdEncoding.EncodeToString(h.Sum(nil))
+	hash := HMACSign(secret, []byte(message))
 
 	return strings.Replace(hash, " ", "-", -1)
 }
+
+// HMACSign returns the base64 encoded HMAC-SHA1 of message
+func HMACSign(secret string, message []byte) string {
+	h := hmac.New(sha1.New, []byte(secret))
+	h.Write(message)
+
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// HMACVerify reports whether signature is the HMACSign of message, in constant time
+func HMACVerify(secret string, message []byte, signature string) bool {
+	return hmac.Equal([]byte(HMACSign(secret, message)), []byte(signature))
+}
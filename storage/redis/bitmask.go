@@ -0,0 +1,67 @@
+package redis
+
+import "github.com/5112100070/publib/encoding"
+
+// SetBitmask stores an encoding bitmask as a redis bitmap, so each bit can be
+// checked server side with HasBit instead of fetching the whole value
+func SetBitmask(rds Redis, key string, bitmask string) error {
+	return rds.Set(key, encoding.ToBitmap(bitmask)).Error
+}
+
+// GetBitmask reads a redis bitmap back as an encoding bitmask, "0" when the key does not exist
+func GetBitmask(rds Redis, key string) (string, error) {
+	res := rds.Get(key)
+	if res.Error != nil {
+		return "", res.Error
+	}
+
+	return encoding.FromBitmap(res.Bytes()), nil
+}
+
+// HasBit is the server side equivalent of encoding.Has on a bitmap stored by SetBitmask
+func HasBit(rds Redis, key string, bit int64) (bool, error) {
+	if bit < 0 {
+		return false, nil
+	}
+
+	res := rds.GetBit(key, bit)
+	return res.Int() == 1, res.Error
+}
+
+// ToggleBits flips bits of a bitmap stored by SetBitmask, like encoding.Toggle
+func ToggleBits(rds Redis, key string, bits []int64) error {
+	// A single bit incremented with the default WRAP overflow is flipped
+	ops := make([]BitFieldOp, len(bits))
+	for i, bit := range bits {
+		ops[i] = BitFieldIncrBy("u1", bit, 1)
+	}
+
+	if len(ops) == 0 {
+		return nil
+	}
+
+	return rds.BitField(key, ops...).Error
+}
+
+// SetBits sets bits of a bitmap stored by SetBitmask
+func SetBits(rds Redis, key string, bits []int64) error {
+	return setBits(rds, key, bits, 1)
+}
+
+// ClearBits clears bits of a bitmap stored by SetBitmask, like encoding.Clear
+func ClearBits(rds Redis, key string, bits []int64) error {
+	return setBits(rds, key, bits, 0)
+}
+
+func setBits(rds Redis, key string, bits []int64, value int64) error {
+	ops := make([]BitFieldOp, len(bits))
+	for i, bit := range bits {
+		ops[i] = BitFieldSet("u1", bit, value)
+	}
+
+	if len(ops) == 0 {
+		return nil
+	}
+
+	return rds.BitField(key, ops...).Error
+}
@@ -0,0 +1,73 @@
+package redis_test
+
+import (
+	"testing"
+
+	"github.com/5112100070/publib/encoding"
+	. "github.com/5112100070/publib/storage/redis"
+	"github.com/5112100070/publib/storage/redis/dummyrds"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBitmaskRoundTrip(t *testing.T) {
+	for _, bits := range [][]int64{
+		{},
+		{0},
+		{1, 3, 7},
+		{8},
+		{2, 9, 15, 16, 70, 200},
+	} {
+		bitmask := encoding.Generate(bits)
+		bitmap := encoding.ToBitmap(bitmask)
+		assert.Equal(t, bitmask, encoding.FromBitmap(bitmap), "bits %v", bits)
+	}
+
+	// bit n is the redis offset n
+	assert.Equal(t, []byte{0x80}, encoding.ToBitmap(encoding.Generate([]int64{0})))
+	assert.Equal(t, []byte{0x41, 0x80}, encoding.ToBitmap(encoding.Generate([]int64{1, 7, 8})))
+	assert.Equal(t, []byte{}, encoding.ToBitmap("0"))
+	assert.Equal(t, "0", encoding.FromBitmap(nil))
+}
+
+func TestBitmask(t *testing.T) {
+	bitmask := encoding.Generate([]int64{1, 7, 8})
+
+	m := dummyrds.Mocker{}
+	m.AddMock("SET perm "+string([]byte{0x41, 0x80})+" []", "OK", false)
+	m.AddMock("GET perm", []byte{0x41, 0x80}, false)
+	m.AddMock("GET none", nil, false)
+	m.AddMock("GETBIT perm 7", int64(1), false)
+	m.AddMock("GETBIT perm 2", int64(0), false)
+	m.AddMock("BITFIELD perm INCRBY u1 2 1 INCRBY u1 7 1", []interface{}{int64(1), int64(0)}, false)
+	m.AddMock("BITFIELD perm SET u1 3 1", []interface{}{int64(0)}, false)
+	m.AddMock("BITFIELD perm SET u1 8 0", []interface{}{int64(1)}, false)
+
+	rds := dummyrds.New(dummyrds.Config{MockingMap: m})
+
+	assert.NoError(t, SetBitmask(rds, "perm", bitmask))
+
+	got, err := GetBitmask(rds, "perm")
+	assert.NoError(t, err)
+	assert.Equal(t, bitmask, got)
+
+	got, err = GetBitmask(rds, "none")
+	assert.NoError(t, err)
+	assert.Equal(t, "0", got)
+
+	ok, err := HasBit(rds, "perm", 7)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = HasBit(rds, "perm", 2)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = HasBit(rds, "perm", -1)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, ToggleBits(rds, "perm", []int64{2, 7}))
+	assert.NoError(t, SetBits(rds, "perm", []int64{3}))
+	assert.NoError(t, ClearBits(rds, "perm", []int64{8}))
+	assert.NoError(t, ClearBits(rds, "perm", nil))
+}
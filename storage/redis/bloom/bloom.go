@@ -0,0 +1,269 @@
+// Package bloom implements a Bloom filter on a redis bitmap, without the RedisBloom module.
+//
+// The filter is sized from the expected number of items and the false positive
+// rate. The bit positions of an item are computed client-side with double
+// hashing, and the bits of a batch of items are set or read in a single pipeline
+// when the client supports it, such as redigo, or one command per bit otherwise.
+// A Bloom filter never misses an added item, but may report an item that was
+// not added with the configured probability. Items cannot be removed.
+//
+//	f, err := bloom.New(rds, bloom.Config{Key: "voucher:seen", Capacity: 10000000, FalsePositiveRate: 0.001})
+//	added, err := f.Add(userID + ":" + voucherID)
+package bloom
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// maxBits is the size limit of a redis string, 512 MB
+const maxBits = 1 << 32
+
+// multiBitter sets or reads many bits of a key in a single pipeline
+type multiBitter interface {
+	SetBitMulti(key string, offsets []int64, value int) *redis.Result
+	GetBitMulti(key string, offsets []int64) *redis.Result
+}
+
+// Error list
+var (
+	ErrInvalidConfig = errors.New("bloom: invalid config")
+)
+
+// Config of a Bloom filter
+type Config struct {
+	// Key of the bitmap
+	Key string
+	// Capacity is the number of items expected, more items raise the false positive rate
+	Capacity uint64
+	// FalsePositiveRate is the probability to report an item that was not added at
+	// capacity, between 0 and 1 exclusive. Default is 0.01
+	FalsePositiveRate float64
+}
+
+// Filter is a Bloom filter stored in a redis bitmap
+type Filter struct {
+	rds    redis.Redis
+	key    string
+	bits   uint64
+	hashes int
+}
+
+// New Bloom filter, the bitmap is allocated by redis as bits are set
+func New(rds redis.Redis, config Config) (*Filter, error) {
+	if config.Key == "" || config.Capacity == 0 {
+		return nil, fmt.Errorf("%w: key and capacity are required", ErrInvalidConfig)
+	}
+
+	// Set default 1% false positive rate
+	if config.FalsePositiveRate == 0 {
+		config.FalsePositiveRate = 0.01
+	}
+
+	if config.FalsePositiveRate <= 0 || config.FalsePositiveRate >= 1 {
+		return nil, fmt.Errorf("%w: false positive rate must be between 0 and 1", ErrInvalidConfig)
+	}
+
+	bits, hashes := Size(config.Capacity, config.FalsePositiveRate)
+	if bits > maxBits {
+		return nil, fmt.Errorf("%w: %d bits exceed the 512 MB bitmap limit", ErrInvalidConfig, bits)
+	}
+
+	return &Filter{
+		rds:    rds,
+		key:    config.Key,
+		bits:   bits,
+		hashes: hashes,
+	}, nil
+}
+
+// Size returns the optimal number of bits and hashes of a filter holding capacity
+// items with the false positive rate
+func Size(capacity uint64, falsePositiveRate float64) (bits uint64, hashes int) {
+	n := float64(capacity)
+	m := math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	k := math.Round(m / n * math.Ln2)
+	if k < 1 {
+		k = 1
+	}
+
+	return uint64(m), int(k)
+}
+
+// Bits returns the size of the bitmap
+func (f *Filter) Bits() uint64 {
+	return f.bits
+}
+
+// Hashes returns the number of bits set per item
+func (f *Filter) Hashes() int {
+	return f.hashes
+}
+
+// Add adds item, it reports whether the item was not in the filter before
+func (f *Filter) Add(item string) (bool, error) {
+	added, err := f.AddMulti(item)
+	if err != nil {
+		return false, err
+	}
+
+	return added[0], nil
+}
+
+// AddMulti adds items in a single batch, it reports for each item whether it
+// was not in the filter before, including an item repeated earlier in items
+func (f *Filter) AddMulti(items ...string) ([]bool, error) {
+	res := f.setBits(f.offsets(items))
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	// An item was new when one of its bits was not set
+	previous := res.BoolSlice()
+	if len(previous) != len(items)*f.hashes {
+		return nil, fmt.Errorf("bloom: unexpected reply of %d bits", len(previous))
+	}
+	added := make([]bool, len(items))
+	for i := range items {
+		for _, set := range previous[i*f.hashes : (i+1)*f.hashes] {
+			if !set {
+				added[i] = true
+				break
+			}
+		}
+	}
+
+	return added, nil
+}
+
+// Has reports whether item may have been added, false means it certainly was not
+func (f *Filter) Has(item string) (bool, error) {
+	has, err := f.HasMulti(item)
+	if err != nil {
+		return false, err
+	}
+
+	return has[0], nil
+}
+
+// HasMulti reports for each item whether it may have been added, in a single batch
+func (f *Filter) HasMulti(items ...string) ([]bool, error) {
+	res := f.getBits(f.offsets(items))
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	bits := res.BoolSlice()
+	if len(bits) != len(items)*f.hashes {
+		return nil, fmt.Errorf("bloom: unexpected reply of %d bits", len(bits))
+	}
+
+	has := make([]bool, len(items))
+	for i := range items {
+		has[i] = true
+		for _, set := range bits[i*f.hashes : (i+1)*f.hashes] {
+			if !set {
+				has[i] = false
+				break
+			}
+		}
+	}
+
+	return has, nil
+}
+
+// setBits sets the bits at offsets, it replies the previous bits
+func (f *Filter) setBits(offsets []int64) *redis.Result {
+	if m, ok := f.rds.(multiBitter); ok {
+		return m.SetBitMulti(f.key, offsets, 1)
+	}
+
+	return f.eachBit(offsets, func(offset int64) *redis.Result {
+		return f.rds.SetBit(f.key, offset, 1)
+	})
+}
+
+// getBits replies the bits at offsets
+func (f *Filter) getBits(offsets []int64) *redis.Result {
+	if m, ok := f.rds.(multiBitter); ok {
+		return m.GetBitMulti(f.key, offsets)
+	}
+
+	return f.eachBit(offsets, func(offset int64) *redis.Result {
+		return f.rds.GetBit(f.key, offset)
+	})
+}
+
+// eachBit runs cmd once per offset, it returns the first error
+func (f *Filter) eachBit(offsets []int64, cmd func(offset int64) *redis.Result) *redis.Result {
+	replies := make([]interface{}, len(offsets))
+	for i, offset := range offsets {
+		res := cmd(offset)
+		if res.Error != nil {
+			return res
+		}
+		replies[i] = res.Value
+	}
+
+	return &redis.Result{Value: replies}
+}
+
+// Count estimates the number of distinct items added from the number of bits set
+func (f *Filter) Count() (uint64, error) {
+	res := f.rds.BitCount(f.key)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	m, k, x := float64(f.bits), float64(f.hashes), float64(res.Int64())
+	if x >= m {
+		return math.MaxUint64, nil
+	}
+
+	return uint64(math.Round(-m / k * math.Log(1-x/m))), nil
+}
+
+// Reset removes every item
+func (f *Filter) Reset() error {
+	return f.rds.Del(f.key)
+}
+
+// offsets returns the bit positions of items, Hashes per item. The positions are
+// derived from the mixed halves of a 128-bit FNV-1a hash, h1 + i*h2 mod bits
+func (f *Filter) offsets(items []string) []int64 {
+	offsets := make([]int64, 0, len(items)*f.hashes)
+	for _, item := range items {
+		h := fnv.New128a()
+		h.Write([]byte(item))
+		sum := h.Sum(nil)
+
+		h1, h2 := mix(uint64From(sum[:8])), mix(uint64From(sum[8:]))|1
+		for i := 0; i < f.hashes; i++ {
+			offsets = append(offsets, int64((h1+uint64(i)*h2)%f.bits))
+		}
+	}
+
+	return offsets
+}
+
+func uint64From(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+// mix is the splitmix64 finalizer, it spreads the FNV bits of similar items
+func mix(v uint64) uint64 {
+	v ^= v >> 30
+	v *= 0xbf58476d1ce4e5b9
+	v ^= v >> 27
+	v *= 0x94d049bb133111eb
+	v ^= v >> 31
+	return v
+}
@@ -0,0 +1,153 @@
+package bloom_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/5112100070/publib/storage/redis"
+	. "github.com/5112100070/publib/storage/redis/bloom"
+	"github.com/5112100070/publib/storage/redis/dummyrds"
+	"github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/5112100070/publib/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSize(t *testing.T) {
+	bits, hashes := Size(1000000, 0.01)
+	assert.Equal(t, uint64(9585059), bits)
+	assert.Equal(t, 7, hashes)
+
+	bits, hashes = Size(1000, 0.001)
+	assert.Equal(t, uint64(14378), bits)
+	assert.Equal(t, 10, hashes)
+
+	_, hashes = Size(100, 0.9)
+	assert.Equal(t, 1, hashes)
+}
+
+func TestFilter(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	f, err := New(rds, Config{Key: redistest.Key(t, "bloom"), Capacity: 1000})
+	require.NoError(t, err)
+
+	added, err := f.Add("user:1:voucher:1")
+	require.NoError(t, err)
+	assert.True(t, added)
+
+	added, err = f.Add("user:1:voucher:1")
+	require.NoError(t, err)
+	assert.False(t, added)
+
+	has, err := f.Has("user:1:voucher:1")
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	has, err = f.Has("user:2:voucher:1")
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	// a repeated item is only new the first time
+	addedMulti, err := f.AddMulti("a", "b", "a")
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, true, false}, addedMulti)
+
+	hasMulti, err := f.HasMulti("a", "b", "c")
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, true, false}, hasMulti)
+
+	require.NoError(t, f.Reset())
+	has, err = f.Has("a")
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestFilter_NoPipeline(t *testing.T) {
+	// the embedding hides the pipelined bit commands of redigo
+	rds := struct{ redis.Redis }{redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})}
+	f, err := New(rds, Config{Key: redistest.Key(t, "bloom"), Capacity: 1000})
+	require.NoError(t, err)
+
+	added, err := f.AddMulti("a", "b", "a")
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, true, false}, added)
+
+	has, err := f.HasMulti("a", "b", "c")
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, true, false}, has)
+}
+
+func TestFilter_FalsePositiveRate(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	f, err := New(rds, Config{Key: redistest.Key(t, "bloom"), Capacity: 2000, FalsePositiveRate: 0.01})
+	require.NoError(t, err)
+
+	items := make([]string, 2000)
+	for i := range items {
+		items[i] = fmt.Sprintf("added:%d", i)
+	}
+	_, err = f.AddMulti(items...)
+	require.NoError(t, err)
+
+	// no false negative
+	has, err := f.HasMulti(items...)
+	require.NoError(t, err)
+	for i, h := range has {
+		require.True(t, h, items[i])
+	}
+
+	others := make([]string, 20000)
+	for i := range others {
+		others[i] = fmt.Sprintf("other:%d", i)
+	}
+	has, err = f.HasMulti(others...)
+	require.NoError(t, err)
+
+	positives := 0
+	for _, h := range has {
+		if h {
+			positives++
+		}
+	}
+	assert.Less(t, float64(positives)/float64(len(others)), 0.02)
+
+	count, err := f.Count()
+	require.NoError(t, err)
+	assert.InDelta(t, 2000, count, 100)
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	rds := dummyrds.New(dummyrds.Config{})
+
+	for _, config := range []Config{
+		{Capacity: 10},
+		{Key: "foo"},
+		{Key: "foo", Capacity: 10, FalsePositiveRate: 1},
+		{Key: "foo", Capacity: 10, FalsePositiveRate: -0.1},
+		{Key: "foo", Capacity: 1 << 40, FalsePositiveRate: 0.0001},
+	} {
+		_, err := New(rds, config)
+		assert.True(t, errors.Is(err, ErrInvalidConfig), "%+v", config)
+	}
+
+	f, err := New(rds, Config{Key: "foo", Capacity: 10})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(96), f.Bits())
+	assert.Equal(t, 7, f.Hashes())
+}
+
+func TestFilter_Error(t *testing.T) {
+	m := dummyrds.Mocker{}
+	m.AddMock("BITCOUNT foo", "failed", true)
+
+	f, err := New(dummyrds.New(dummyrds.Config{MockingMap: m}), Config{Key: "foo", Capacity: 10})
+	require.NoError(t, err)
+
+	_, err = f.Add("a")
+	assert.Error(t, err)
+	_, err = f.Has("a")
+	assert.Error(t, err)
+	_, err = f.Count()
+	assert.EqualError(t, err, "failed")
+}
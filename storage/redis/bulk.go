@@ -0,0 +1,104 @@
+package redis
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// DefaultBulkCount is the SCAN page size used by the bulk helpers when count is not positive
+const DefaultBulkCount = 1000
+
+// expireScript sets the same time to live on every key of a page, returns the number of keys that exist
+const expireScript = `
+local n = 0
+for _, key in ipairs(KEYS) do
+	n = n + redis.call('PEXPIRE', key, ARGV[1])
+end
+return n`
+
+// DeleteByPattern unlinks every key matching match, a SCAN page at a time so
+// the server is never blocked by a single large command. A pattern matching
+// every key, such as "" or "*", returns ErrMatchAll, use DeleteAll instead.
+// Returns the number of deleted keys, including on error or cancellation
+func DeleteByPattern(ctx context.Context, rds Redis, match string, count int) (int64, error) {
+	if matchesAll(match) {
+		return 0, ErrMatchAll
+	}
+
+	return deleteKeys(ctx, rds, match, count)
+}
+
+// DeleteAll unlinks every key of the database, a SCAN page at a time
+func DeleteAll(ctx context.Context, rds Redis, count int) (int64, error) {
+	return deleteKeys(ctx, rds, "", count)
+}
+
+func deleteKeys(ctx context.Context, rds Redis, match string, count int) (int64, error) {
+	var deleted int64
+	err := scanPages(ctx, rds, bulkOptions(match, count), func(keys []string) error {
+		res := rds.Unlink(keys...)
+		if res.Error != nil {
+			return res.Error
+		}
+
+		deleted += res.Int64()
+		return nil
+	})
+
+	return deleted, err
+}
+
+// ExpireByPattern sets ttl on every key matching match, with a single round trip
+// per SCAN page. A pattern matching every key returns ErrMatchAll.
+// Returns the number of expired keys, including on error or cancellation
+func ExpireByPattern(ctx context.Context, rds Redis, match string, ttl time.Duration, count int) (int64, error) {
+	if matchesAll(match) {
+		return 0, ErrMatchAll
+	}
+	if ttl <= 0 {
+		return 0, ErrInvalidTTL
+	}
+
+	var expired int64
+	err := scanPages(ctx, rds, bulkOptions(match, count), func(keys []string) error {
+		res := rds.Eval(expireScript, keys, Milliseconds(ttl))
+		if res.Error != nil {
+			return res.Error
+		}
+
+		expired += res.Int64()
+		return nil
+	})
+
+	return expired, err
+}
+
+// CountByPattern returns the number of keys matching match without holding them in memory.
+// As with SCAN, keys created or deleted during the walk may or may not be counted,
+// and a key may be counted twice when the keyspace is resized in the meantime
+func CountByPattern(ctx context.Context, rds Redis, match string, count int) (int64, error) {
+	var counted int64
+	err := scanPages(ctx, rds, bulkOptions(match, count), func(keys []string) error {
+		counted += int64(len(keys))
+		return nil
+	})
+
+	return counted, err
+}
+
+// matchesAll reports whether the glob pattern match selects every key
+func matchesAll(match string) bool {
+	return strings.Trim(match, "*") == ""
+}
+
+func bulkOptions(match string, count int) ScanOptions {
+	if count <= 0 {
+		count = DefaultBulkCount
+	}
+
+	return ScanOptions{
+		Match: match,
+		Count: count,
+	}
+}
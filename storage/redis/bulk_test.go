@@ -0,0 +1,77 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/5112100070/publib/storage/redis"
+	"github.com/5112100070/publib/storage/redis/dummyrds"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteByPattern(t *testing.T) {
+	m := dummyrds.Mocker{}
+	m.AddMock("SCAN 0 user:* 1000", page("3", "user:1", "user:2"), false)
+	m.AddMock("SCAN 3 user:* 1000", page("0", "user:3"), false)
+	m.AddMock("UNLINK user:1 user:2", 2, false)
+	m.AddMock("UNLINK user:3", 1, false)
+
+	m.AddMock("SCAN 0 err:* 2", page("3", "err:1"), false)
+	m.AddMock("UNLINK err:1", "failed", true)
+
+	rds := dummyrds.New(dummyrds.Config{MockingMap: m})
+
+	n, err := DeleteByPattern(context.Background(), rds, "user:*", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+
+	n, err = DeleteByPattern(context.Background(), rds, "err:*", 2)
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, int64(0), n)
+
+	for _, match := range []string{"", "*", "**"} {
+		_, err = DeleteByPattern(context.Background(), rds, match, 0)
+		assert.Equal(t, ErrMatchAll, err, match)
+	}
+}
+
+func TestDeleteAll(t *testing.T) {
+	m := dummyrds.Mocker{}
+	m.AddMock("SCAN 0  1000", page("0", "user:1", "other"), false)
+	m.AddMock("UNLINK user:1 other", 2, false)
+
+	rds := dummyrds.New(dummyrds.Config{MockingMap: m})
+
+	n, err := DeleteAll(context.Background(), rds, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+}
+
+func TestExpireByPattern(t *testing.T) {
+	m := dummyrds.Mocker{}
+	m.AddMock("SCAN 0 user:* 10", page("0", "user:1", "user:2"), false)
+
+	rds := dummyrds.New(dummyrds.Config{MockingMap: m})
+
+	n, err := ExpireByPattern(context.Background(), rds, "user:*", time.Minute, 10)
+	assert.Error(t, err, "the page is expired by an unmocked EVAL")
+	assert.Equal(t, int64(0), n)
+
+	_, err = ExpireByPattern(context.Background(), rds, "*", time.Minute, 10)
+	assert.Equal(t, ErrMatchAll, err)
+	_, err = ExpireByPattern(context.Background(), rds, "user:*", 0, 10)
+	assert.Equal(t, ErrInvalidTTL, err)
+}
+
+func TestCountByPattern(t *testing.T) {
+	m := dummyrds.Mocker{}
+	m.AddMock("SCAN 0 user:* 1000", page("3", "user:1", "user:2"), false)
+	m.AddMock("SCAN 3 user:* 1000", page("0", "user:3"), false)
+
+	rds := dummyrds.New(dummyrds.Config{MockingMap: m})
+
+	n, err := CountByPattern(context.Background(), rds, "user:*", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+}
@@ -0,0 +1,122 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Gzip codec from the standard library, a zero Level is gzip.DefaultCompression
+type Gzip struct {
+	Level int
+}
+
+// ID of gzip
+func (g Gzip) ID() byte {
+	return GzipID
+}
+
+// Compress data with gzip
+func (g Gzip) Compress(data []byte) ([]byte, error) {
+	level := g.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decompress gzip data
+func (g Gzip) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return ioutil.ReadAll(r)
+}
+
+// Snappy codec, fast with a lower ratio than gzip
+type Snappy struct{}
+
+// ID of snappy
+func (Snappy) ID() byte {
+	return SnappyID
+}
+
+// Compress data with snappy
+func (Snappy) Compress(data []byte) ([]byte, error) {
+	return snappy.Encode(nil, data), nil
+}
+
+// Decompress snappy data
+func (Snappy) Decompress(data []byte) ([]byte, error) {
+	return snappy.Decode(nil, data)
+}
+
+// Zstd codec, a zero Level is zstd.SpeedDefault
+type Zstd struct {
+	Level zstd.EncoderLevel
+}
+
+var (
+	// zstd encoders and decoder are safe for concurrent use and costly to create
+	zstdEncoders sync.Map
+	zstdDecoder  *zstd.Decoder
+	zstdOnce     sync.Once
+	zstdErr      error
+)
+
+// ID of zstd
+func (z Zstd) ID() byte {
+	return ZstdID
+}
+
+// Compress data with zstd
+func (z Zstd) Compress(data []byte) ([]byte, error) {
+	level := z.Level
+	if level == 0 {
+		level = zstd.SpeedDefault
+	}
+
+	encoder, ok := zstdEncoders.Load(level)
+	if !ok {
+		e, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(level))
+		if err != nil {
+			return nil, err
+		}
+		encoder, _ = zstdEncoders.LoadOrStore(level, e)
+	}
+
+	return encoder.(*zstd.Encoder).EncodeAll(data, nil), nil
+}
+
+// Decompress zstd data
+func (z Zstd) Decompress(data []byte) ([]byte, error) {
+	zstdOnce.Do(func() {
+		zstdDecoder, zstdErr = zstd.NewReader(nil)
+	})
+	if zstdErr != nil {
+		return nil, zstdErr
+	}
+
+	return zstdDecoder.DecodeAll(data, nil)
+}
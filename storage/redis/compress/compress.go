@@ -0,0 +1,360 @@
+// Package compress offers a redis.Redis decorator compressing large values.
+//
+// Values of at least Config.Threshold bytes are stored behind a three bytes header,
+// the magic 0xC0 0xDE followed by the codec ID. 0xC0 never starts valid UTF-8, so
+// text and JSON values written before the decorator are still read as is. The
+// values written as is that start with the magic get the RawID header instead.
+//
+// Gzip, Snappy and Zstd are built in, other codecs plug in through the Codec interface.
+//
+// Values are compressed by Set, Setex, PSetEx, SetNX, SetWithOptions, GetSet, MSet,
+// MSetNX, HSet, HMSet and HSetNX, and decompressed by Get, GetDel, GetEx, GetSet,
+// Set with GET, SetWithOptions, MGet, HGet, HMGet, HVals and HGetAll. Commands working
+// on the stored bytes such as Append, StrLen or GetRange see the compressed value.
+package compress
+
+import (
+	"bytes"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+var magic = []byte{0xC0, 0xDE}
+
+const defaultThreshold = 1024
+
+var _ redis.Redis = (*Client)(nil)
+
+// New compressing decorator of rds
+func New(rds redis.Redis, config Config) *Client {
+
+	if config.Codec == nil {
+		config.Codec = Gzip{}
+	}
+
+	// Set default 1 KiB threshold
+	if config.Threshold <= 0 {
+		config.Threshold = defaultThreshold
+	}
+
+	codecs := map[byte]Codec{GzipID: Gzip{}, SnappyID: Snappy{}, ZstdID: Zstd{}}
+	for _, codec := range config.Decoders {
+		codecs[codec.ID()] = codec
+	}
+	codecs[config.Codec.ID()] = config.Codec
+
+	return &Client{
+		Redis:     rds,
+		codec:     config.Codec,
+		codecs:    codecs,
+		threshold: config.Threshold,
+	}
+}
+
+// Stats returns the compression counters since the client was created
+func (c *Client) Stats() Stats {
+	return c.stats.snapshot()
+}
+
+// Ratio is the stored size of the compressed values over their raw size,
+// 0.25 means they take a quarter of their size. It is 1 before any compression
+func (s Stats) Ratio() float64 {
+	if s.RawBytes == 0 {
+		return 1
+	}
+	return float64(s.StoredBytes) / float64(s.RawBytes)
+}
+
+func (c *Client) Get(key string) *redis.Result {
+	return c.decodeResult(c.Redis.Get(key))
+}
+
+func (c *Client) GetDel(key string) (string, error) {
+	return c.decodeString(c.Redis.GetDel(key))
+}
+
+func (c *Client) GetEx(key string, expiration time.Duration) (string, error) {
+	return c.decodeString(c.Redis.GetEx(key, expiration))
+}
+
+func (c *Client) MGet(keys ...string) *redis.Result {
+	return c.decodeValues(c.Redis.MGet(keys...), 1)
+}
+
+func (c *Client) HGet(key, field string) *redis.Result {
+	return c.decodeResult(c.Redis.HGet(key, field))
+}
+
+func (c *Client) HMGet(key string, fields ...string) *redis.Result {
+	return c.decodeValues(c.Redis.HMGet(key, fields...), 1)
+}
+
+func (c *Client) HVals(key string) *redis.Result {
+	return c.decodeValues(c.Redis.HVals(key), 1)
+}
+
+// HGetAll decompresses the values of the field value pairs
+func (c *Client) HGetAll(key string) *redis.Result {
+	return c.decodeValues(c.Redis.HGetAll(key), 2)
+}
+
+func (c *Client) Set(key, value interface{}, args ...interface{}) *redis.Result {
+	value, err := c.encode(value)
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+	// The previous value replied with GET is decompressed, OK and nil are read as is
+	return c.decodeResult(c.Redis.Set(key, value, args...))
+}
+
+func (c *Client) Setex(key string, expireTime int, value interface{}) error {
+	value, err := c.encode(value)
+	if err != nil {
+		return err
+	}
+	return c.Redis.Setex(key, expireTime, value)
+}
+
+func (c *Client) PSetEx(key string, ttl time.Duration, value interface{}) error {
+	value, err := c.encode(value)
+	if err != nil {
+		return err
+	}
+	return c.Redis.PSetEx(key, ttl, value)
+}
+
+func (c *Client) SetNX(key string, value interface{}) (bool, error) {
+	value, err := c.encode(value)
+	if err != nil {
+		return false, err
+	}
+	return c.Redis.SetNX(key, value)
+}
+
+func (c *Client) SetWithOptions(key string, value interface{}, opts redis.SetOptions) (bool, string, error) {
+	value, err := c.encode(value)
+	if err != nil {
+		return false, "", err
+	}
+
+	written, previous, err := c.Redis.SetWithOptions(key, value, opts)
+	if err != nil {
+		return written, previous, err
+	}
+
+	previous, err = c.decodeString(previous, nil)
+	return written, previous, err
+}
+
+func (c *Client) GetSet(key string, value interface{}) (string, error) {
+	value, err := c.encode(value)
+	if err != nil {
+		return "", err
+	}
+	return c.decodeString(c.Redis.GetSet(key, value))
+}
+
+func (c *Client) MSet(values map[string]interface{}) error {
+	values, err := c.encodeMap(values)
+	if err != nil {
+		return err
+	}
+	return c.Redis.MSet(values)
+}
+
+func (c *Client) MSetNX(values map[string]interface{}) (bool, error) {
+	values, err := c.encodeMap(values)
+	if err != nil {
+		return false, err
+	}
+	return c.Redis.MSetNX(values)
+}
+
+func (c *Client) HSet(key, field string, value interface{}) error {
+	value, err := c.encode(value)
+	if err != nil {
+		return err
+	}
+	return c.Redis.HSet(key, field, value)
+}
+
+func (c *Client) HMSet(key string, values map[string]interface{}) error {
+	values, err := c.encodeMap(values)
+	if err != nil {
+		return err
+	}
+	return c.Redis.HMSet(key, values)
+}
+
+func (c *Client) HSetNX(key, field string, value interface{}) *redis.Result {
+	value, err := c.encode(value)
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+	return c.Redis.HSetNX(key, field, value)
+}
+
+// encode compresses string and []byte values of at least the threshold,
+// as long as they shrink. Other values are written as is
+func (c *Client) encode(value interface{}) (interface{}, error) {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return value, nil
+	}
+
+	if len(data) < c.threshold {
+		atomic.AddInt64(&c.stats.skipped, 1)
+		return c.raw(value, data), nil
+	}
+
+	compressed, err := c.codec.Compress(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(compressed)+len(magic)+1 >= len(data) {
+		atomic.AddInt64(&c.stats.skipped, 1)
+		return c.raw(value, data), nil
+	}
+
+	stored := header(c.codec.ID(), compressed)
+
+	atomic.AddInt64(&c.stats.compressed, 1)
+	atomic.AddInt64(&c.stats.rawBytes, int64(len(data)))
+	atomic.AddInt64(&c.stats.storedBytes, int64(len(stored)))
+	return stored, nil
+}
+
+// raw returns a value written as is, behind the RawID header when it starts with
+// the magic so that it is not read as compressed
+func (c *Client) raw(value interface{}, data []byte) interface{} {
+	if !bytes.HasPrefix(data, magic) {
+		return value
+	}
+	return header(RawID, data)
+}
+
+// header prefixes data with the magic and id
+func header(id byte, data []byte) []byte {
+	stored := make([]byte, 0, len(magic)+1+len(data))
+	stored = append(stored, magic...)
+	stored = append(stored, id)
+	return append(stored, data...)
+}
+
+func (c *Client) encodeMap(values map[string]interface{}) (map[string]interface{}, error) {
+	encoded := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		v, err := c.encode(v)
+		if err != nil {
+			return nil, err
+		}
+		encoded[k] = v
+	}
+	return encoded, nil
+}
+
+// decode decompresses a value read with the header, other values are returned as is
+func (c *Client) decode(value interface{}) (interface{}, error) {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return value, nil
+	}
+
+	if len(data) <= len(magic) || !bytes.HasPrefix(data, magic) {
+		return value, nil
+	}
+
+	if data[len(magic)] == RawID {
+		return data[len(magic)+1:], nil
+	}
+
+	codec, ok := c.codecs[data[len(magic)]]
+	if !ok {
+		return nil, fmt.Errorf("%w: id %d", ErrUnknownCodec, data[len(magic)])
+	}
+
+	raw, err := codec.Decompress(data[len(magic)+1:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupted, err)
+	}
+
+	atomic.AddInt64(&c.stats.decompressed, 1)
+	return raw, nil
+}
+
+func (c *Client) decodeResult(res *redis.Result) *redis.Result {
+	if res.Error != nil {
+		return res
+	}
+
+	value, err := c.decode(res.Value)
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+	return &redis.Result{Value: value}
+}
+
+func (c *Client) decodeString(value string, err error) (string, error) {
+	if err != nil || value == "" {
+		return value, err
+	}
+
+	raw, err := c.decode(value)
+	if err != nil {
+		return "", err
+	}
+
+	switch v := raw.(type) {
+	case []byte:
+		return string(v), nil
+	case string:
+		return v, nil
+	}
+	return value, nil
+}
+
+// decodeValues decompresses every stride-th element of an array reply, from the last
+// element of each stride, so 2 decodes the values of field value pairs
+func (c *Client) decodeValues(res *redis.Result, stride int) *redis.Result {
+	if res.Error != nil {
+		return res
+	}
+
+	var values []interface{}
+	switch v := res.Value.(type) {
+	case []interface{}:
+		values = make([]interface{}, len(v))
+		copy(values, v)
+	case []string:
+		values = make([]interface{}, len(v))
+		for i := range v {
+			values[i] = v[i]
+		}
+	default:
+		return res
+	}
+
+	for i := stride - 1; i < len(values); i += stride {
+		value, err := c.decode(values[i])
+		if err != nil {
+			return &redis.Result{Error: err}
+		}
+		values[i] = value
+	}
+
+	return &redis.Result{Value: values}
+}
@@ -0,0 +1,186 @@
+package compress_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/5112100070/publib/storage/redis"
+	. "github.com/5112100070/publib/storage/redis/compress"
+	"github.com/5112100070/publib/storage/redis/dummyrds"
+	"github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/5112100070/publib/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConformance(t *testing.T) {
+	addr := redistest.StartServer(t)
+
+	redistest.RunSuite(t, func(t *testing.T) redis.Redis {
+		return New(redigo.New(redigo.Config{Endpoint: addr}), Config{})
+	})
+}
+
+var payload = `{"items":[` + strings.Repeat(`{"id":1,"name":"product","price":10000},`, 100) + `{}]}`
+
+func TestCompress(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	c := New(rds, Config{})
+	foo, bar, old := redistest.Key(t, "foo"), redistest.Key(t, "bar"), redistest.Key(t, "old")
+
+	require.NoError(t, c.Set(foo, payload).Error)
+	assert.Equal(t, payload, c.Get(foo).String())
+
+	// stored compressed behind the header
+	stored := rds.Get(foo).Bytes()
+	assert.True(t, bytes.HasPrefix(stored, []byte{0xC0, 0xDE, GzipID}))
+	assert.True(t, len(stored) < len(payload)/10, "stored %d bytes", len(stored))
+
+	// small and old values are read as is
+	require.NoError(t, c.Set(bar, "small").Error)
+	assert.Equal(t, "small", rds.Get(bar).String())
+	require.NoError(t, rds.Set(old, payload).Error)
+	assert.Equal(t, payload, c.Get(old).String())
+
+	assert.Equal(t, []string{payload, "small", payload, ""}, c.MGet(foo, bar, old, redistest.Key(t, "none")).StringSlice())
+
+	stats := c.Stats()
+	assert.Equal(t, int64(1), stats.Compressed)
+	assert.Equal(t, int64(1), stats.Skipped)
+	assert.Equal(t, int64(len(payload)), stats.RawBytes)
+	assert.Equal(t, int64(len(stored)), stats.StoredBytes)
+	assert.True(t, stats.Ratio() < 0.1, "ratio %f", stats.Ratio())
+}
+
+func TestCompress_Hash(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	c := New(rds, Config{Threshold: 100})
+	hash := redistest.Key(t, "hash")
+
+	require.NoError(t, c.HSet(hash, "large", payload))
+	require.NoError(t, c.HMSet(hash, map[string]interface{}{"small": "x", "other": payload}))
+
+	assert.Equal(t, payload, c.HGet(hash, "large").String())
+	assert.Equal(t, []string{payload, "x"}, c.HMGet(hash, "other", "small").StringSlice())
+	assert.Equal(t, map[string]string{"large": payload, "small": "x", "other": payload}, toMap(c.HGetAll(hash).StringSlice()))
+	assert.Len(t, c.HVals(hash).StringSlice(), 3)
+	assert.True(t, rds.HStrLen(hash, "large").Int() < len(payload))
+}
+
+func TestCompress_Strings(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	c := New(rds, Config{})
+	foo := redistest.Key(t, "foo")
+
+	ok, err := c.SetNX(foo, payload)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	previous, err := c.GetSet(foo, payload+"2")
+	require.NoError(t, err)
+	assert.Equal(t, payload, previous)
+
+	_, previous, err = c.SetWithOptions(foo, payload+"3", redis.SetOptions{Get: true})
+	require.NoError(t, err)
+	assert.Equal(t, payload+"2", previous)
+
+	value, err := c.GetDel(foo)
+	require.NoError(t, err)
+	assert.Equal(t, payload+"3", value)
+}
+
+func TestCompress_Errors(t *testing.T) {
+	m := dummyrds.Mocker{}
+	m.AddMock("GET corrupted", []byte{0xC0, 0xDE, GzipID, 'x'}, false)
+	m.AddMock("GET unknown", []byte{0xC0, 0xDE, 42, 'x'}, false)
+	m.AddMock("MGET corrupted", []interface{}{[]byte{0xC0, 0xDE, GzipID, 'x'}}, false)
+
+	c := New(dummyrds.New(dummyrds.Config{MockingMap: m}), Config{})
+
+	err := c.Get("corrupted").Error
+	assert.True(t, errors.Is(err, ErrCorrupted), "%v", err)
+	assert.Empty(t, c.Get("corrupted").String())
+
+	err = c.Get("unknown").Error
+	assert.True(t, errors.Is(err, ErrUnknownCodec), "%v", err)
+
+	err = c.MGet("corrupted").Error
+	assert.True(t, errors.Is(err, ErrCorrupted), "%v", err)
+}
+
+// halves is a custom codec for values made of two equal halves
+type halves struct{}
+
+func (halves) ID() byte { return 100 }
+
+func (halves) Compress(data []byte) ([]byte, error) {
+	return data[:len(data)/2], nil
+}
+
+func (halves) Decompress(data []byte) ([]byte, error) {
+	return bytes.Repeat(data, 2), nil
+}
+
+func TestCompress_Codec(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	foo := redistest.Key(t, "foo")
+	value := strings.Repeat("ab", 1000)
+
+	require.NoError(t, New(rds, Config{Codec: halves{}}).Set(foo, value).Error)
+	assert.Equal(t, byte(100), rds.Get(foo).Bytes()[2])
+
+	assert.True(t, errors.Is(New(rds, Config{}).Get(foo).Error, ErrUnknownCodec))
+	assert.Equal(t, value, New(rds, Config{Decoders: []Codec{halves{}}}).Get(foo).String())
+}
+
+func TestCompress_BuiltinCodecs(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	gzip := New(rds, Config{})
+
+	for _, codec := range []Codec{Gzip{Level: 9}, Snappy{}, Zstd{}} {
+		foo := redistest.Key(t, fmt.Sprint(codec.ID()))
+		require.NoError(t, New(rds, Config{Codec: codec}).Set(foo, payload).Error)
+
+		stored := rds.Get(foo).Bytes()
+		assert.Equal(t, codec.ID(), stored[2])
+		assert.True(t, len(stored) < len(payload)/5, "%T stored %d bytes", codec, len(stored))
+
+		// every built-in codec is known without Decoders
+		assert.Equal(t, payload, gzip.Get(foo).String(), "%T", codec)
+	}
+}
+
+func TestCompress_Magic(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	c := New(rds, Config{Threshold: 4})
+	foo, bar := redistest.Key(t, "foo"), redistest.Key(t, "bar")
+
+	// raw values looking like a header, below the threshold or not shrinking
+	for _, value := range []string{"\xC0\xDE", "\xC0\xDE\x01x", "\xC0\xDE\x03" + strings.Repeat("\x00", 2)} {
+		require.NoError(t, c.Set(foo, value).Error)
+		assert.Equal(t, value, c.Get(foo).String(), "%q", value)
+		assert.True(t, bytes.HasPrefix(rds.Get(foo).Bytes(), []byte{0xC0, 0xDE, RawID}))
+	}
+
+	// other raw values are stored as is
+	require.NoError(t, c.Set(foo, "abc").Error)
+	assert.Equal(t, "abc", rds.Get(foo).String())
+
+	// the previous value replied by SET GET is decompressed
+	require.NoError(t, c.Set(bar, payload).Error)
+	res := c.Set(bar, "new", "GET")
+	require.NoError(t, res.Error)
+	assert.Equal(t, payload, res.String())
+	assert.Equal(t, "OK", c.Set(bar, "new").String())
+}
+
+func toMap(pairs []string) map[string]string {
+	m := make(map[string]string, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		m[pairs[i]] = pairs[i+1]
+	}
+	return m
+}
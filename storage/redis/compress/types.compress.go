@@ -0,0 +1,87 @@
+package compress
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Error list
+var (
+	// ErrCorrupted is returned when a compressed value can not be decompressed
+	ErrCorrupted = errors.New("compress: corrupted value")
+	// ErrUnknownCodec is returned when a value was compressed by a codec that is not configured
+	ErrUnknownCodec = errors.New("compress: unknown codec")
+)
+
+// Codec IDs written in the header, custom codecs must use other IDs
+const (
+	// RawID marks a value stored as is because it starts with the magic bytes
+	RawID    byte = 0
+	GzipID   byte = 1
+	SnappyID byte = 2
+	ZstdID   byte = 3
+)
+
+// Codec compresses values, see Gzip
+type Codec interface {
+	// ID is written in the header of every value, it must never change
+	ID() byte
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// Config of the compressing decorator
+type Config struct {
+	// Codec compresses the written values, default is Gzip
+	Codec Codec
+	// Decoders are the custom codecs of the stored values, e.g. the previous codec
+	// while migrating. Gzip, Snappy and Zstd are always known
+	Decoders []Codec
+	// Threshold is the smallest value compressed in bytes, default is 1 KiB
+	Threshold int
+}
+
+// Client compresses the values written through the embedded redis.Redis.
+// The commands that are not overridden are passed through as is
+type Client struct {
+	redis.Redis
+
+	codec     Codec
+	codecs    map[byte]Codec
+	threshold int
+	stats     stats
+}
+
+// Stats of the values written and read by a client
+type Stats struct {
+	// Compressed is the number of values written compressed
+	Compressed int64 `json:"compressed"`
+	// Skipped is the number of values written as is, below the threshold or not shrinking
+	Skipped int64 `json:"skipped"`
+	// RawBytes is the size of the compressed values before compression
+	RawBytes int64 `json:"raw_bytes"`
+	// StoredBytes is the size of the compressed values as stored, header included
+	StoredBytes int64 `json:"stored_bytes"`
+	// Decompressed is the number of values read compressed
+	Decompressed int64 `json:"decompressed"`
+}
+
+type stats struct {
+	compressed   int64
+	skipped      int64
+	rawBytes     int64
+	storedBytes  int64
+	decompressed int64
+}
+
+func (s *stats) snapshot() Stats {
+	return Stats{
+		Compressed:   atomic.LoadInt64(&s.compressed),
+		Skipped:      atomic.LoadInt64(&s.skipped),
+		RawBytes:     atomic.LoadInt64(&s.rawBytes),
+		StoredBytes:  atomic.LoadInt64(&s.storedBytes),
+		Decompressed: atomic.LoadInt64(&s.decompressed),
+	}
+}
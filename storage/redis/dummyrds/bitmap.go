@@ -0,0 +1,45 @@
+package dummyrds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+func (c *dummydis) SetBit(key string, offset int64, value int) *redis.Result {
+	return c.mock(fmt.Sprintf("SETBIT %s %d %d", key, offset, value))
+}
+
+func (c *dummydis) GetBit(key string, offset int64) *redis.Result {
+	return c.mock(fmt.Sprintf("GETBIT %s %d", key, offset))
+}
+
+func (c *dummydis) BitCount(key string) *redis.Result {
+	return c.mock("BITCOUNT " + key)
+}
+
+func (c *dummydis) BitCountRange(key string, start, end int64) *redis.Result {
+	return c.mock(fmt.Sprintf("BITCOUNT %s %d %d", key, start, end))
+}
+
+func (c *dummydis) BitPos(key string, bit int) *redis.Result {
+	return c.mock(fmt.Sprintf("BITPOS %s %d", key, bit))
+}
+
+func (c *dummydis) BitPosRange(key string, bit int, start, end int64) *redis.Result {
+	return c.mock(fmt.Sprintf("BITPOS %s %d %d %d", key, bit, start, end))
+}
+
+func (c *dummydis) BitOp(op, destination string, keys ...string) *redis.Result {
+	return c.mock(fmt.Sprintf("BITOP %s %s %s", op, destination, strings.Join(keys, " ")))
+}
+
+// BitField mock key lists the subcommands in order, e.g. "BITFIELD foo GET u8 0 INCRBY u8 8 1"
+func (c *dummydis) BitField(key string, ops ...redis.BitFieldOp) *redis.Result {
+	req := "BITFIELD " + key
+	for _, op := range ops {
+		req += " " + join(op)
+	}
+	return c.mock(req)
+}
@@ -0,0 +1,59 @@
+package dummyrds_test
+
+import (
+	"testing"
+
+	"github.com/5112100070/publib/storage/redis"
+	. "github.com/5112100070/publib/storage/redis/dummyrds"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetBitGetBit(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("SETBIT foo 7 1", 0, false)
+	m.AddMock("GETBIT foo 7", 1, false)
+	m.AddMock("SETBIT err 7 1", "failed", true)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, 0, rds.SetBit("foo", 7, 1).Int())
+	assert.Equal(t, 1, rds.GetBit("foo", 7).Int())
+	assert.EqualError(t, rds.SetBit("err", 7, 1).Error, "failed")
+}
+
+func TestBitCountPos(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("BITCOUNT foo", 3, false)
+	m.AddMock("BITCOUNT foo 0 1", 2, false)
+	m.AddMock("BITPOS foo 1", 7, false)
+	m.AddMock("BITPOS foo 0 1 -1", 8, false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, 3, rds.BitCount("foo").Int())
+	assert.Equal(t, 2, rds.BitCountRange("foo", 0, 1).Int())
+	assert.Equal(t, 7, rds.BitPos("foo", 1).Int())
+	assert.Equal(t, 8, rds.BitPosRange("foo", 0, 1, -1).Int())
+}
+
+func TestBitOpField(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("BITOP OR dest foo bar", 2, false)
+	m.AddMock("BITFIELD foo OVERFLOW SAT INCRBY u8 0 300 GET u8 8 SET i16 16 -1", []int64{255, 0, 0}, false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, 2, rds.BitOp(redis.BitOr, "dest", "foo", "bar").Int())
+	assert.Equal(t, []int64{255, 0, 0}, rds.BitField("foo",
+		redis.BitFieldOverflow("SAT"),
+		redis.BitFieldIncrBy("u8", 0, 300),
+		redis.BitFieldGet("u8", 8),
+		redis.BitFieldSet("i16", 16, -1),
+	).Int64Slice())
+}
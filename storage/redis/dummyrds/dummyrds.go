@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync/atomic"
 
 	"github.com/5112100070/publib/storage/redis"
 )
@@ -23,6 +24,10 @@ func (m Mocker) AddMock(command string, result interface{}, isError bool) {
 	}
 }
 
+func (c *dummydis) Ping() *redis.Result {
+	return c.mock("PING")
+}
+
 func (c *dummydis) Get(key string) *redis.Result {
 	return c.mock("GET " + key)
 }
@@ -46,8 +51,7 @@ func (c *dummydis) Expire(key string, seconds int) error {
 }
 
 func (c *dummydis) Incr(keys ...string) error {
-	k := strings.Join(keys, " ")
-	return c.mock("INCR " + k).Error
+	return c.pipe("INCR", keys)
 }
 
 func (c *dummydis) IncrSingle(key string) (int, error) {
@@ -55,8 +59,7 @@ func (c *dummydis) IncrSingle(key string) (int, error) {
 }
 
 func (c *dummydis) Decr(keys ...string) error {
-	k := strings.Join(keys, " ")
-	return c.mock("DECR " + k).Error
+	return c.pipe("DECR", keys)
 }
 
 func (c *dummydis) HDel(key string, fields ...string) error {
@@ -129,8 +132,22 @@ func (c *dummydis) Rename(key, newKey string) *redis.Result {
 	return c.mock(fmt.Sprintf("RENAME %s %s", key, newKey))
 }
 
+// Close makes every following command fail with redis.ErrClosed
+func (c *dummydis) Close() error {
+	if !atomic.CompareAndSwapInt32(&c.closed, 0, 1) {
+		return redis.ErrClosed
+	}
+	return nil
+}
+
 func (c *dummydis) mock(command string) *redis.Result {
 
+	if atomic.LoadInt32(&c.closed) == 1 {
+		return &redis.Result{
+			Error: redis.ErrClosed,
+		}
+	}
+
 	res, ok := c.config.MockingMap[command]
 
 	// Mock not found
@@ -154,16 +171,28 @@ func (c *dummydis) mock(command string) *redis.Result {
 
 }
 
+// pipe mocks command once per key, returns the first error
+func (c *dummydis) pipe(command string, keys []string) error {
+	var firstErr error
+	for _, key := range keys {
+		if err := c.mock(command + " " + key).Error; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
 func (c *dummydis) Set(key, value interface{}, args ...interface{}) *redis.Result {
 	return c.mock(fmt.Sprintf("SET %s %s %s", key, value, args))
 }
 
-func (c *dummydis) LPush(key string, value interface{}) error {
-	return c.mock(fmt.Sprintf("LPUSH %s %s", key, value)).Error
+func (c *dummydis) LPush(key string, values ...interface{}) error {
+	return c.mock(fmt.Sprintf("LPUSH %s %s", key, join(values))).Error
 }
 
-func (c *dummydis) RPush(key string, value interface{}) error {
-	return c.mock(fmt.Sprintf("RPUSH %s %s", key, value)).Error
+func (c *dummydis) RPush(key string, values ...interface{}) error {
+	return c.mock(fmt.Sprintf("RPUSH %s %s", key, join(values))).Error
 }
 
 func (c *dummydis) LPop(key string) *redis.Result {
@@ -177,3 +206,11 @@ func (c *dummydis) LLen(key string) *redis.Result {
 func (c *dummydis) Scan(cursor int, match string, count int) *redis.Result {
 	return c.mock(fmt.Sprintf("SCAN %d %s %d", cursor, match, count))
 }
+
+func (c *dummydis) ScanType(cursor int, match string, count int, keyType string) *redis.Result {
+	return c.mock(fmt.Sprintf("SCAN %d %s %d %s", cursor, match, count, keyType))
+}
+
+func (c *dummydis) Unlink(keys ...string) *redis.Result {
+	return c.mock("UNLINK " + strings.Join(keys, " "))
+}
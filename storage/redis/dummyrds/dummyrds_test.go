@@ -8,6 +8,18 @@ import (
 	. "github.com/5112100070/publib/storage/redis/dummyrds"
 )
 
+func TestPing(t *testing.T) {
+
+	m := Mocker{}
+	m.AddMock("PING", "PONG", false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, "PONG", rds.Ping().String())
+}
+
 func TestGet(t *testing.T) {
 
 	m := Mocker{}
@@ -370,3 +382,46 @@ func TestLLen(t *testing.T) {
 	assert.Equal(t, 1, rds.LLen("mylist").Int())
 	assert.Equal(t, 0, rds.LLen("none").Int())
 }
+
+func TestScanType(t *testing.T) {
+
+	m := Mocker{}
+	m.AddMock("SCAN 0 foo* 10 hash", []interface{}{[]byte("0"), []interface{}{[]byte("foo")}}, false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	cursor, keys, err := rds.ScanType(0, "foo*", 10, "hash").ScanResult()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, cursor)
+	assert.Equal(t, []string{"foo"}, keys)
+}
+
+func TestUnlink(t *testing.T) {
+
+	m := Mocker{}
+	m.AddMock("UNLINK foo bar", 2, false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, 2, rds.Unlink("foo", "bar").Int())
+}
+
+func TestClose(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("GET foo", "bar", false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, "bar", rds.Get("foo").String())
+	assert.Nil(t, rds.Close())
+	assert.Equal(t, redis.ErrClosed, rds.Get("foo").Error)
+	assert.Equal(t, redis.ErrClosed, rds.Incr("foo"))
+	assert.False(t, rds.Status().Connected)
+	assert.Equal(t, redis.ErrClosed, rds.Close())
+}
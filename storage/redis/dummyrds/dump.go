@@ -0,0 +1,28 @@
+package dummyrds
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+func (c *dummydis) Dump(key string) *redis.Result {
+	return c.mock("DUMP " + key)
+}
+
+// Restore mock key holds the ttl, the value and REPLACE when replace is true,
+// a BUSYKEY error mock is returned as redis.ErrKeyExists
+func (c *dummydis) Restore(key string, ttl time.Duration, value []byte, replace bool) error {
+	command := fmt.Sprintf("RESTORE %s %v %s", key, ttl, value)
+	if replace {
+		command += " REPLACE"
+	}
+
+	err := c.mock(command).Error
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYKEY") {
+		return redis.ErrKeyExists
+	}
+	return err
+}
@@ -0,0 +1,29 @@
+package dummyrds_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+	. "github.com/5112100070/publib/storage/redis/dummyrds"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDump(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("DUMP foo", []byte("serialized"), false)
+	m.AddMock("RESTORE bar 1s serialized", nil, false)
+	m.AddMock("RESTORE baz 0s serialized REPLACE", nil, false)
+	m.AddMock("RESTORE busy 0s serialized", "BUSYKEY Target key name already exists.", true)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	value := rds.Dump("foo").Bytes()
+	assert.Equal(t, []byte("serialized"), value)
+	assert.NoError(t, rds.Restore("bar", time.Second, value, false))
+	assert.NoError(t, rds.Restore("baz", 0, value, true))
+	assert.Equal(t, redis.ErrKeyExists, rds.Restore("busy", 0, value, false))
+	assert.Error(t, rds.Restore("missing", 0, value, false))
+}
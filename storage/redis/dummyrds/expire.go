@@ -0,0 +1,57 @@
+package dummyrds
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+func (c *dummydis) PSetEx(key string, ttl time.Duration, value interface{}) error {
+	if ttl <= 0 {
+		return redis.ErrInvalidTTL
+	}
+	return c.mock(fmt.Sprintf("PSETEX %s %v %v", key, ttl, value)).Error
+}
+
+func (c *dummydis) PExpire(key string, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		return false, redis.ErrInvalidTTL
+	}
+	res := c.mock(fmt.Sprintf("PEXPIRE %s %v", key, ttl))
+	return res.Bool(), res.Error
+}
+
+// ExpireAt mock key holds the unix time in seconds
+func (c *dummydis) ExpireAt(key string, at time.Time) (bool, error) {
+	res := c.mock(fmt.Sprintf("EXPIREAT %s %d", key, at.Unix()))
+	return res.Bool(), res.Error
+}
+
+// PExpireAt mock key holds the unix time in milliseconds
+func (c *dummydis) PExpireAt(key string, at time.Time) (bool, error) {
+	res := c.mock(fmt.Sprintf("PEXPIREAT %s %d", key, at.UnixNano()/int64(time.Millisecond)))
+	return res.Bool(), res.Error
+}
+
+func (c *dummydis) Persist(key string) (bool, error) {
+	res := c.mock("PERSIST " + key)
+	return res.Bool(), res.Error
+}
+
+// PTTL mocks are replied in milliseconds, -1 and -2 map to the typed errors
+func (c *dummydis) PTTL(key string) (time.Duration, error) {
+	res := c.mock("PTTL " + key)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	switch ms := res.Int64(); ms {
+	case -1:
+		return 0, redis.ErrNoExpire
+	case -2:
+		return 0, redis.ErrKeyNotExist
+	default:
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+}
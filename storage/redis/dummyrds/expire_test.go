@@ -0,0 +1,69 @@
+package dummyrds_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+	. "github.com/5112100070/publib/storage/redis/dummyrds"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDurationExpire(t *testing.T) {
+	at := time.Unix(1700000000, 500*int64(time.Millisecond))
+
+	m := Mocker{}
+	m.AddMock("PSETEX foo 1.5s bar", "OK", false)
+	m.AddMock("PEXPIRE foo 250ms", 1, false)
+	m.AddMock("EXPIREAT foo 1700000000", 1, false)
+	m.AddMock("PEXPIREAT foo 1700000000500", 0, false)
+	m.AddMock("PERSIST foo", 1, false)
+	m.AddMock("PERSIST err", "failed", true)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.NoError(t, rds.PSetEx("foo", 1500*time.Millisecond, "bar"))
+
+	ok, err := rds.PExpire("foo", 250*time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, _ = rds.ExpireAt("foo", at)
+	assert.True(t, ok)
+
+	ok, _ = rds.PExpireAt("foo", at)
+	assert.False(t, ok)
+
+	ok, _ = rds.Persist("foo")
+	assert.True(t, ok)
+
+	_, err = rds.Persist("err")
+	assert.EqualError(t, err, "failed")
+}
+
+func TestPTTL(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("PTTL foo", 1500, false)
+	m.AddMock("PTTL persistent", -1, false)
+	m.AddMock("PTTL none", -2, false)
+	m.AddMock("PTTL err", "failed", true)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	ttl, err := rds.PTTL("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, 1500*time.Millisecond, ttl)
+
+	_, err = rds.PTTL("persistent")
+	assert.Equal(t, redis.ErrNoExpire, err)
+
+	_, err = rds.PTTL("none")
+	assert.Equal(t, redis.ErrKeyNotExist, err)
+
+	_, err = rds.PTTL("err")
+	assert.EqualError(t, err, "failed")
+}
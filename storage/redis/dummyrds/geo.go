@@ -0,0 +1,31 @@
+package dummyrds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+func (c *dummydis) GeoAdd(key string, locations ...redis.GeoLocation) *redis.Result {
+	args := make([]interface{}, 0, 3*len(locations))
+	for _, loc := range locations {
+		args = append(args, loc.Longitude, loc.Latitude, loc.Name)
+	}
+	return c.mock(fmt.Sprintf("GEOADD %s %s", key, join(args)))
+}
+
+func (c *dummydis) GeoPos(key string, members ...string) *redis.Result {
+	return c.mock(fmt.Sprintf("GEOPOS %s %s", key, strings.Join(members, " ")))
+}
+
+func (c *dummydis) GeoDist(key, member1, member2, unit string) *redis.Result {
+	if unit == "" {
+		unit = redis.GeoMeters
+	}
+	return c.mock(fmt.Sprintf("GEODIST %s %s %s %s", key, member1, member2, unit))
+}
+
+func (c *dummydis) GeoSearch(key string, query redis.GeoSearchQuery) *redis.Result {
+	return c.mock(fmt.Sprintf("GEOSEARCH %s %s", key, join(query.Args())))
+}
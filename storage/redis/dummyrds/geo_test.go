@@ -0,0 +1,45 @@
+package dummyrds_test
+
+import (
+	"testing"
+
+	"github.com/5112100070/publib/storage/redis"
+	. "github.com/5112100070/publib/storage/redis/dummyrds"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeo(t *testing.T) {
+	warehouses := []redis.GeoLocation{
+		{Name: "cakung", Longitude: 106.95, Latitude: -6.18},
+		{Name: "cikarang", Longitude: 107.15, Latitude: -6.3},
+	}
+
+	m := Mocker{}
+	m.AddMock("GEOADD wh 106.95 -6.18 cakung 107.15 -6.3 cikarang", 2, false)
+	m.AddMock("GEOPOS wh cakung cikarang", warehouses, false)
+	m.AddMock("GEODIST wh cakung cikarang km", "25.4", false)
+	m.AddMock("GEOSEARCH wh FROMLONLAT 106.8 -6.2 BYRADIUS 50 km ASC COUNT 1 WITHDIST", []redis.GeoLocation{
+		{Name: "cakung", Dist: 16.7},
+	}, false)
+	m.AddMock("GEODIST err a b m", "failed", true)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, 2, rds.GeoAdd("wh", warehouses...).Int())
+	assert.Equal(t, warehouses, rds.GeoPos("wh", "cakung", "cikarang").GeoLocations())
+	assert.Equal(t, 25.4, rds.GeoDist("wh", "cakung", "cikarang", redis.GeoKilometers).Float64())
+	assert.EqualError(t, rds.GeoDist("err", "a", "b", "").Error, "failed")
+
+	nearest := rds.GeoSearch("wh", redis.GeoSearchQuery{
+		Longitude: 106.8,
+		Latitude:  -6.2,
+		Radius:    50,
+		Unit:      redis.GeoKilometers,
+		Sort:      "ASC",
+		Count:     1,
+		WithDist:  true,
+	}).GeoLocations()
+	assert.Equal(t, []redis.GeoLocation{{Name: "cakung", Dist: 16.7}}, nearest)
+}
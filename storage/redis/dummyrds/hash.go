@@ -0,0 +1,36 @@
+package dummyrds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+func (c *dummydis) HIncrBy(key, field string, increment int64) *redis.Result {
+	return c.mock(fmt.Sprintf("HINCRBY %s %s %d", key, field, increment))
+}
+
+func (c *dummydis) HIncrByFloat(key, field string, increment float64) *redis.Result {
+	return c.mock(fmt.Sprintf("HINCRBYFLOAT %s %s %v", key, field, increment))
+}
+
+func (c *dummydis) HSetNX(key, field string, value interface{}) *redis.Result {
+	return c.mock(fmt.Sprintf("HSETNX %s %s %v", key, field, value))
+}
+
+func (c *dummydis) HLen(key string) *redis.Result {
+	return c.mock("HLEN " + key)
+}
+
+func (c *dummydis) HMGet(key string, fields ...string) *redis.Result {
+	return c.mock(fmt.Sprintf("HMGET %s %s", key, strings.Join(fields, " ")))
+}
+
+func (c *dummydis) HStrLen(key, field string) *redis.Result {
+	return c.mock(fmt.Sprintf("HSTRLEN %s %s", key, field))
+}
+
+func (c *dummydis) HScan(key string, cursor int, match string, count int) *redis.Result {
+	return c.mock(fmt.Sprintf("HSCAN %s %d %s %d", key, cursor, match, count))
+}
@@ -0,0 +1,74 @@
+package dummyrds_test
+
+import (
+	"testing"
+
+	. "github.com/5112100070/publib/storage/redis/dummyrds"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHIncrBy(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("HINCRBY foo bar 2", 3, false)
+	m.AddMock("HINCRBYFLOAT foo bar 0.5", "3.5", false)
+	m.AddMock("HINCRBY err bar 1", "failed", true)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, 3, rds.HIncrBy("foo", "bar", 2).Int())
+	assert.Equal(t, 3.5, rds.HIncrByFloat("foo", "bar", 0.5).Float64())
+	assert.EqualError(t, rds.HIncrBy("err", "bar", 1).Error, "failed")
+}
+
+func TestHSetNX(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("HSETNX foo bar 1", 1, false)
+	m.AddMock("HSETNX foo baz 1", 0, false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.True(t, rds.HSetNX("foo", "bar", 1).Bool())
+	assert.False(t, rds.HSetNX("foo", "baz", 1).Bool())
+}
+
+func TestHLen(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("HLEN foo", 2, false)
+	m.AddMock("HSTRLEN foo bar", 5, false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, 2, rds.HLen("foo").Int())
+	assert.Equal(t, 5, rds.HStrLen("foo", "bar").Int())
+}
+
+func TestHMGet(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("HMGET foo a b", []string{"1", ""}, false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, []string{"1", ""}, rds.HMGet("foo", "a", "b").StringSlice())
+}
+
+func TestHScan(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("HSCAN foo 0 a* 10", []interface{}{[]byte("0"), []interface{}{[]byte("a"), []byte("1")}}, false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	cursor, fields, err := rds.HScan("foo", 0, "a*", 10).ScanResult()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, cursor)
+	assert.Equal(t, []string{"a", "1"}, fields)
+}
@@ -0,0 +1,20 @@
+package dummyrds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+func (c *dummydis) PFAdd(key string, elements ...interface{}) *redis.Result {
+	return c.mock(fmt.Sprintf("PFADD %s %s", key, join(elements)))
+}
+
+func (c *dummydis) PFCount(keys ...string) *redis.Result {
+	return c.mock("PFCOUNT " + strings.Join(keys, " "))
+}
+
+func (c *dummydis) PFMerge(destination string, keys ...string) error {
+	return c.mock(fmt.Sprintf("PFMERGE %s %s", destination, strings.Join(keys, " "))).Error
+}
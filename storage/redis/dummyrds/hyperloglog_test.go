@@ -0,0 +1,25 @@
+package dummyrds_test
+
+import (
+	"testing"
+
+	. "github.com/5112100070/publib/storage/redis/dummyrds"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHyperLogLog(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("PFADD foo a b", 1, false)
+	m.AddMock("PFCOUNT foo bar", 3, false)
+	m.AddMock("PFMERGE dest foo bar", "OK", false)
+	m.AddMock("PFMERGE err foo", "failed", true)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.True(t, rds.PFAdd("foo", "a", "b").Bool())
+	assert.Equal(t, int64(3), rds.PFCount("foo", "bar").Int64())
+	assert.NoError(t, rds.PFMerge("dest", "foo", "bar"))
+	assert.EqualError(t, rds.PFMerge("err", "foo"), "failed")
+}
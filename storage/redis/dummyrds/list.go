@@ -0,0 +1,41 @@
+package dummyrds
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+func (c *dummydis) RPop(key string) *redis.Result {
+	return c.mock("RPOP " + key)
+}
+
+func (c *dummydis) LRange(key string, start, stop int) *redis.Result {
+	return c.mock(fmt.Sprintf("LRANGE %s %d %d", key, start, stop))
+}
+
+func (c *dummydis) LTrim(key string, start, stop int) error {
+	return c.mock(fmt.Sprintf("LTRIM %s %d %d", key, start, stop)).Error
+}
+
+func (c *dummydis) LRem(key string, count int, value interface{}) *redis.Result {
+	return c.mock(fmt.Sprintf("LREM %s %d %v", key, count, value))
+}
+
+func (c *dummydis) LIndex(key string, index int) *redis.Result {
+	return c.mock(fmt.Sprintf("LINDEX %s %d", key, index))
+}
+
+func (c *dummydis) BLPop(timeout time.Duration, keys ...string) *redis.Result {
+	return c.mock(fmt.Sprintf("BLPOP %s %v", strings.Join(keys, " "), timeout.Seconds()))
+}
+
+func (c *dummydis) BRPop(timeout time.Duration, keys ...string) *redis.Result {
+	return c.mock(fmt.Sprintf("BRPOP %s %v", strings.Join(keys, " "), timeout.Seconds()))
+}
+
+func (c *dummydis) BLMove(source, destination, srcSide, destSide string, timeout time.Duration) *redis.Result {
+	return c.mock(fmt.Sprintf("BLMOVE %s %s %s %s %v", source, destination, srcSide, destSide, timeout.Seconds()))
+}
@@ -0,0 +1,78 @@
+package dummyrds_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+	. "github.com/5112100070/publib/storage/redis/dummyrds"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPushVariadic(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("LPUSH foo a b", 2, false)
+	m.AddMock("RPUSH foo a b", 2, false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Nil(t, rds.LPush("foo", "a", "b"))
+	assert.Nil(t, rds.RPush("foo", "a", "b"))
+}
+
+func TestRPop(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("RPOP foo", "result", false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, "result", rds.RPop("foo").String())
+	assert.Equal(t, "", rds.RPop("none").String())
+}
+
+func TestLRange(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("LRANGE foo 0 -1", []string{"a", "b"}, false)
+	m.AddMock("LTRIM foo 0 9", "OK", false)
+	m.AddMock("LTRIM err 0 9", "failed", true)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, []string{"a", "b"}, rds.LRange("foo", 0, -1).StringSlice())
+	assert.Nil(t, rds.LTrim("foo", 0, 9))
+	assert.EqualError(t, rds.LTrim("err", 0, 9), "failed")
+}
+
+func TestLRemLIndex(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("LREM foo -1 a", 1, false)
+	m.AddMock("LINDEX foo 2", "c", false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, 1, rds.LRem("foo", -1, "a").Int())
+	assert.Equal(t, "c", rds.LIndex("foo", 2).String())
+}
+
+func TestBlocking(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("BLPOP foo bar 1.5", []string{"foo", "a"}, false)
+	m.AddMock("BRPOP foo 0", []string{"foo", "b"}, false)
+	m.AddMock("BLMOVE foo bar RIGHT LEFT 1", "c", false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, []string{"foo", "a"}, rds.BLPop(1500*time.Millisecond, "foo", "bar").StringSlice())
+	assert.Equal(t, []string{"foo", "b"}, rds.BRPop(0, "foo").StringSlice())
+	assert.Equal(t, "c", rds.BLMove("foo", "bar", redis.Right, redis.Left, time.Second).String())
+}
@@ -0,0 +1,12 @@
+package dummyrds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+func (c *dummydis) Eval(script string, keys []string, args ...interface{}) *redis.Result {
+	return c.mock(fmt.Sprintf("EVAL %s %d %s %s", script, len(keys), strings.Join(keys, " "), join(args)))
+}
@@ -0,0 +1,21 @@
+package dummyrds_test
+
+import (
+	"testing"
+
+	. "github.com/5112100070/publib/storage/redis/dummyrds"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScript(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("EVAL return 1 2 foo bar a 1", 1, false)
+	m.AddMock("EVAL return 0 1 err ", "failed", true)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, 1, rds.Eval("return 1", []string{"foo", "bar"}, "a", 1).Int())
+	assert.EqualError(t, rds.Eval("return 0", []string{"err"}).Error, "failed")
+}
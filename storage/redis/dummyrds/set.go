@@ -0,0 +1,64 @@
+package dummyrds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+func (c *dummydis) SAdd(key string, members ...interface{}) *redis.Result {
+	return c.mock(fmt.Sprintf("SADD %s %s", key, join(members)))
+}
+
+func (c *dummydis) SRem(key string, members ...interface{}) *redis.Result {
+	return c.mock(fmt.Sprintf("SREM %s %s", key, join(members)))
+}
+
+func (c *dummydis) SMembers(key string) *redis.Result {
+	return c.mock("SMEMBERS " + key)
+}
+
+func (c *dummydis) SIsMember(key string, member interface{}) *redis.Result {
+	return c.mock(fmt.Sprintf("SISMEMBER %s %v", key, member))
+}
+
+func (c *dummydis) SMIsMember(key string, members ...interface{}) *redis.Result {
+	return c.mock(fmt.Sprintf("SMISMEMBER %s %s", key, join(members)))
+}
+
+func (c *dummydis) SCard(key string) *redis.Result {
+	return c.mock("SCARD " + key)
+}
+
+func (c *dummydis) SPop(key string, count int) *redis.Result {
+	return c.mock(fmt.Sprintf("SPOP %s %d", key, count))
+}
+
+func (c *dummydis) SRandMember(key string, count int) *redis.Result {
+	return c.mock(fmt.Sprintf("SRANDMEMBER %s %d", key, count))
+}
+
+func (c *dummydis) SInter(keys ...string) *redis.Result {
+	return c.mock("SINTER " + strings.Join(keys, " "))
+}
+
+func (c *dummydis) SUnion(keys ...string) *redis.Result {
+	return c.mock("SUNION " + strings.Join(keys, " "))
+}
+
+func (c *dummydis) SDiff(keys ...string) *redis.Result {
+	return c.mock("SDIFF " + strings.Join(keys, " "))
+}
+
+func (c *dummydis) SInterStore(destination string, keys ...string) *redis.Result {
+	return c.mock(fmt.Sprintf("SINTERSTORE %s %s", destination, strings.Join(keys, " ")))
+}
+
+func (c *dummydis) SUnionStore(destination string, keys ...string) *redis.Result {
+	return c.mock(fmt.Sprintf("SUNIONSTORE %s %s", destination, strings.Join(keys, " ")))
+}
+
+func (c *dummydis) SDiffStore(destination string, keys ...string) *redis.Result {
+	return c.mock(fmt.Sprintf("SDIFFSTORE %s %s", destination, strings.Join(keys, " ")))
+}
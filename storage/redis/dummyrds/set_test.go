@@ -0,0 +1,86 @@
+package dummyrds_test
+
+import (
+	"testing"
+
+	. "github.com/5112100070/publib/storage/redis/dummyrds"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSAddSRem(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("SADD foo a b", 2, false)
+	m.AddMock("SREM foo a", 1, false)
+	m.AddMock("SADD err a", "failed", true)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, 2, rds.SAdd("foo", "a", "b").Int())
+	assert.Equal(t, 1, rds.SRem("foo", "a").Int())
+	assert.EqualError(t, rds.SAdd("err", "a").Error, "failed")
+}
+
+func TestSMembers(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("SMEMBERS foo", []string{"a", "b"}, false)
+	m.AddMock("SCARD foo", 2, false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, map[string]struct{}{"a": {}, "b": {}}, rds.SMembers("foo").StringSet())
+	assert.Equal(t, 2, rds.SCard("foo").Int())
+	assert.Nil(t, rds.SMembers("none").StringSet())
+}
+
+func TestSIsMember(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("SISMEMBER foo a", 1, false)
+	m.AddMock("SISMEMBER foo b", 0, false)
+	m.AddMock("SMISMEMBER foo a b", []bool{true, false}, false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.True(t, rds.SIsMember("foo", "a").Bool())
+	assert.False(t, rds.SIsMember("foo", "b").Bool())
+	assert.Equal(t, []bool{true, false}, rds.SMIsMember("foo", "a", "b").BoolSlice())
+}
+
+func TestSPopSRandMember(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("SPOP foo 0", "a", false)
+	m.AddMock("SRANDMEMBER foo 2", []string{"a", "b"}, false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, "a", rds.SPop("foo", 0).String())
+	assert.Equal(t, []string{"a", "b"}, rds.SRandMember("foo", 2).StringSlice())
+}
+
+func TestSetOperations(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("SINTER foo bar", []string{"a"}, false)
+	m.AddMock("SUNION foo bar", []string{"a", "b", "c"}, false)
+	m.AddMock("SDIFF foo bar", []string{"b"}, false)
+	m.AddMock("SINTERSTORE dst foo bar", 1, false)
+	m.AddMock("SUNIONSTORE dst foo bar", 3, false)
+	m.AddMock("SDIFFSTORE dst foo bar", 1, false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, []string{"a"}, rds.SInter("foo", "bar").StringSlice())
+	assert.Equal(t, []string{"a", "b", "c"}, rds.SUnion("foo", "bar").StringSlice())
+	assert.Equal(t, []string{"b"}, rds.SDiff("foo", "bar").StringSlice())
+	assert.Equal(t, 1, rds.SInterStore("dst", "foo", "bar").Int())
+	assert.Equal(t, 3, rds.SUnionStore("dst", "foo", "bar").Int())
+	assert.Equal(t, 1, rds.SDiffStore("dst", "foo", "bar").Int())
+}
@@ -0,0 +1,31 @@
+package dummyrds
+
+import (
+	"sync/atomic"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Status replies Config.Status, a connected master when it is not set.
+// A closed client is disconnected with redis.ErrClosed
+func (c *dummydis) Status() redis.Status {
+	status := c.config.Status
+	if status == (redis.Status{}) {
+		status = redis.Status{
+			Name:      "dummyrds",
+			Connected: true,
+			Role:      "master",
+		}
+	}
+
+	if atomic.LoadInt32(&c.closed) == 1 {
+		status.Connected = false
+		status.LastError = redis.ErrClosed.Error()
+	}
+
+	return status
+}
+
+func (c *dummydis) DoHeartBeat() {}
+
+func (c *dummydis) StopBeat() {}
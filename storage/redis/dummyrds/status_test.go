@@ -0,0 +1,27 @@
+package dummyrds_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+	. "github.com/5112100070/publib/storage/redis/dummyrds"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatus(t *testing.T) {
+	rds := New(Config{})
+	rds.DoHeartBeat()
+	defer rds.StopBeat()
+
+	assert.Equal(t, redis.Status{Name: "dummyrds", Connected: true, Role: "master"}, rds.Status())
+
+	status := redis.Status{
+		Name:        "replica",
+		Role:        "slave",
+		LastError:   "connection refused",
+		PingLatency: time.Millisecond,
+	}
+	rds = New(Config{Status: status})
+	assert.Equal(t, status, rds.Status())
+}
@@ -0,0 +1,93 @@
+package dummyrds
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+func (c *dummydis) IncrBy(key string, increment int64) (int64, error) {
+	res := c.mock(fmt.Sprintf("INCRBY %s %d", key, increment))
+	return res.Int64(), res.Error
+}
+
+func (c *dummydis) DecrBy(key string, decrement int64) (int64, error) {
+	res := c.mock(fmt.Sprintf("DECRBY %s %d", key, decrement))
+	return res.Int64(), res.Error
+}
+
+func (c *dummydis) IncrByFloat(key string, increment float64) (float64, error) {
+	res := c.mock(fmt.Sprintf("INCRBYFLOAT %s %v", key, increment))
+	return res.Float64(), res.Error
+}
+
+func (c *dummydis) SetNX(key string, value interface{}) (bool, error) {
+	res := c.mock(fmt.Sprintf("SETNX %s %v", key, value))
+	return res.Bool(), res.Error
+}
+
+func (c *dummydis) GetSet(key string, value interface{}) (string, error) {
+	return c.mock(fmt.Sprintf("GETSET %s %v", key, value)).StringResult()
+}
+
+func (c *dummydis) GetDel(key string) (string, error) {
+	return c.mock("GETDEL " + key).StringResult()
+}
+
+func (c *dummydis) GetEx(key string, expiration time.Duration) (string, error) {
+	return c.mock(fmt.Sprintf("GETEX %s %v", key, expiration)).StringResult()
+}
+
+// MSet mock key lists the pairs sorted by key, e.g. "MSET a 1 b 2"
+func (c *dummydis) MSet(values map[string]interface{}) error {
+	return c.mock("MSET " + sortedPairs(values)).Error
+}
+
+func (c *dummydis) MSetNX(values map[string]interface{}) (bool, error) {
+	res := c.mock("MSETNX " + sortedPairs(values))
+	return res.Bool(), res.Error
+}
+
+func (c *dummydis) Append(key string, value interface{}) (int64, error) {
+	res := c.mock(fmt.Sprintf("APPEND %s %v", key, value))
+	return res.Int64(), res.Error
+}
+
+func (c *dummydis) StrLen(key string) (int64, error) {
+	res := c.mock("STRLEN " + key)
+	return res.Int64(), res.Error
+}
+
+func (c *dummydis) GetRange(key string, start, end int) (string, error) {
+	res := c.mock(fmt.Sprintf("GETRANGE %s %d %d", key, start, end))
+	return res.String(), res.Error
+}
+
+func (c *dummydis) SetRange(key string, offset int, value interface{}) (int64, error) {
+	res := c.mock(fmt.Sprintf("SETRANGE %s %d %v", key, offset, value))
+	return res.Int64(), res.Error
+}
+
+// sortedPairs formats values as key value pairs sorted by key so mock keys are deterministic
+func sortedPairs(values map[string]interface{}) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = fmt.Sprintf("%s %v", k, values[k])
+	}
+	return strings.Join(pairs, " ")
+}
+
+// SetWithOptions mock key lists the options in SetOptions.Args order, e.g. "SET foo bar NX PX 1500 GET"
+func (c *dummydis) SetWithOptions(key string, value interface{}, opts redis.SetOptions) (bool, string, error) {
+	args := append([]interface{}{key, value}, opts.Args()...)
+	return opts.ParseReply(c.mock("SET " + join(args)))
+}
@@ -0,0 +1,154 @@
+package dummyrds_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+	. "github.com/5112100070/publib/storage/redis/dummyrds"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncrMultiple(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("INCR foo", "1", false)
+	m.AddMock("INCR bar", "1", false)
+	m.AddMock("DECR foo", "failed", true)
+	m.AddMock("DECR bar", "0", false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Nil(t, rds.Incr("foo", "bar"))
+	assert.EqualError(t, rds.Decr("bar", "foo"), "failed")
+}
+
+func TestIncrBy(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("INCRBY foo 5", 5, false)
+	m.AddMock("DECRBY foo 2", 3, false)
+	m.AddMock("INCRBYFLOAT foo 0.5", "3.5", false)
+	m.AddMock("INCRBY err 1", "failed", true)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	val, err := rds.IncrBy("foo", 5)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), val)
+
+	val, err = rds.DecrBy("foo", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), val)
+
+	f, err := rds.IncrByFloat("foo", 0.5)
+	assert.Nil(t, err)
+	assert.Equal(t, 3.5, f)
+
+	_, err = rds.IncrBy("err", 1)
+	assert.EqualError(t, err, "failed")
+}
+
+func TestSetNX(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("SETNX foo bar", 1, false)
+	m.AddMock("MSETNX a 1 b 2", 0, false)
+	m.AddMock("MSET a 1 b 2", "OK", false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	ok, err := rds.SetNX("foo", "bar")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = rds.MSetNX(map[string]interface{}{"b": 2, "a": 1})
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	assert.Nil(t, rds.MSet(map[string]interface{}{"b": 2, "a": 1}))
+}
+
+func TestGetSetDelEx(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("GETSET foo bar", "old", false)
+	m.AddMock("GETSET none bar", nil, false)
+	m.AddMock("GETDEL foo", "bar", false)
+	m.AddMock("GETEX foo 1m0s", "bar", false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	val, err := rds.GetSet("foo", "bar")
+	assert.Nil(t, err)
+	assert.Equal(t, "old", val)
+
+	_, err = rds.GetSet("none", "bar")
+	assert.Equal(t, redis.ErrNil, err)
+
+	val, err = rds.GetDel("foo")
+	assert.Nil(t, err)
+	assert.Equal(t, "bar", val)
+
+	val, err = rds.GetEx("foo", time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, "bar", val)
+}
+
+func TestAppendRange(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("APPEND foo bar", 6, false)
+	m.AddMock("STRLEN foo", 6, false)
+	m.AddMock("GETRANGE foo 0 2", "foo", false)
+	m.AddMock("SETRANGE foo 3 baz", 6, false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	n, err := rds.Append("foo", "bar")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(6), n)
+
+	n, err = rds.StrLen("foo")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(6), n)
+
+	s, err := rds.GetRange("foo", 0, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "foo", s)
+
+	n, err = rds.SetRange("foo", 3, "baz")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(6), n)
+}
+
+func TestSetWithOptions(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("SET foo bar NX PX 1500", "OK", false)
+	m.AddMock("SET foo baz NX PX 1500", nil, false)
+	m.AddMock("SET foo qux XX EX 10 GET", "bar", false)
+	m.AddMock("SET err bar", "failed", true)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	written, _, err := rds.SetWithOptions("foo", "bar", redis.SetOptions{NX: true, TTL: 1500 * time.Millisecond})
+	assert.NoError(t, err)
+	assert.True(t, written)
+
+	written, _, _ = rds.SetWithOptions("foo", "baz", redis.SetOptions{NX: true, TTL: 1500 * time.Millisecond})
+	assert.False(t, written)
+
+	written, previous, _ := rds.SetWithOptions("foo", "qux", redis.SetOptions{XX: true, TTL: 10 * time.Second, Get: true})
+	assert.True(t, written)
+	assert.Equal(t, "bar", previous)
+
+	_, _, err = rds.SetWithOptions("err", "bar", redis.SetOptions{})
+	assert.EqualError(t, err, "failed")
+}
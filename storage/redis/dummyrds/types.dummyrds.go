@@ -1,15 +1,22 @@
 package dummyrds
 
+import (
+	"github.com/5112100070/publib/storage/redis"
+)
+
 // Mocker mapping
 type Mocker map[string]mock
 
 type dummydis struct {
 	config Config
+	closed int32
 }
 
 // Config for dummy elastic
 type Config struct {
 	MockingMap Mocker
+	// Status is replied by Status, a connected master when it is not set
+	Status redis.Status
 }
 
 // Mock result
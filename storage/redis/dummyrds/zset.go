@@ -0,0 +1,101 @@
+package dummyrds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+func (c *dummydis) ZRem(key string, members ...interface{}) *redis.Result {
+	return c.mock(fmt.Sprintf("ZREM %s %s", key, join(members)))
+}
+
+func (c *dummydis) ZIncrBy(key string, increment float64, member interface{}) *redis.Result {
+	return c.mock(fmt.Sprintf("ZINCRBY %s %v %v", key, increment, member))
+}
+
+func (c *dummydis) ZScore(key string, member interface{}) *redis.Result {
+	return c.mock(fmt.Sprintf("ZSCORE %s %v", key, member))
+}
+
+func (c *dummydis) ZRank(key string, member interface{}) *redis.Result {
+	return c.mock(fmt.Sprintf("ZRANK %s %v", key, member))
+}
+
+func (c *dummydis) ZRevRank(key string, member interface{}) *redis.Result {
+	return c.mock(fmt.Sprintf("ZREVRANK %s %v", key, member))
+}
+
+func (c *dummydis) ZRevRange(key string, start, stop int) *redis.Result {
+	return c.mock(fmt.Sprintf("ZREVRANGE %s %d %d", key, start, stop))
+}
+
+func (c *dummydis) ZRevRangeByScore(key, max, min string, limit int) *redis.Result {
+	return c.mock(byScore("ZREVRANGEBYSCORE", key, max, min, limit, false))
+}
+
+func (c *dummydis) ZRangeWithScores(key string, start, stop int) *redis.Result {
+	return c.mock(fmt.Sprintf("ZRANGE %s %d %d WITHSCORES", key, start, stop))
+}
+
+func (c *dummydis) ZRevRangeWithScores(key string, start, stop int) *redis.Result {
+	return c.mock(fmt.Sprintf("ZREVRANGE %s %d %d WITHSCORES", key, start, stop))
+}
+
+func (c *dummydis) ZRangeByScoreWithScores(key, min, max string, limit int) *redis.Result {
+	return c.mock(byScore("ZRANGEBYSCORE", key, min, max, limit, true))
+}
+
+func (c *dummydis) ZRevRangeByScoreWithScores(key, max, min string, limit int) *redis.Result {
+	return c.mock(byScore("ZREVRANGEBYSCORE", key, max, min, limit, true))
+}
+
+func (c *dummydis) ZCard(key string) *redis.Result {
+	return c.mock("ZCARD " + key)
+}
+
+func (c *dummydis) ZCount(key, min, max string) *redis.Result {
+	return c.mock(fmt.Sprintf("ZCOUNT %s %s %s", key, min, max))
+}
+
+func (c *dummydis) ZRemRangeByScore(key, min, max string) *redis.Result {
+	return c.mock(fmt.Sprintf("ZREMRANGEBYSCORE %s %s %s", key, min, max))
+}
+
+func (c *dummydis) ZRemRangeByRank(key string, start, stop int) *redis.Result {
+	return c.mock(fmt.Sprintf("ZREMRANGEBYRANK %s %d %d", key, start, stop))
+}
+
+func (c *dummydis) ZPopMin(key string, count int) *redis.Result {
+	return c.mock(fmt.Sprintf("ZPOPMIN %s %d", key, count))
+}
+
+func (c *dummydis) ZPopMax(key string, count int) *redis.Result {
+	return c.mock(fmt.Sprintf("ZPOPMAX %s %d", key, count))
+}
+
+func (c *dummydis) ZUnionStore(destination string, store redis.ZStore) *redis.Result {
+	return c.mock(fmt.Sprintf("ZUNIONSTORE %s %s", destination, join(store.Args())))
+}
+
+// byScore builds the mock key of ZRANGEBYSCORE and ZREVRANGEBYSCORE
+func byScore(command, key, from, to string, limit int, withScores bool) string {
+	req := fmt.Sprintf("%s %s %s %s", command, key, from, to)
+	if withScores {
+		req += " WITHSCORES"
+	}
+	if limit > 0 {
+		req = fmt.Sprintf("%s LIMIT 0 %d", req, limit)
+	}
+	return req
+}
+
+// join formats values separated by a single space
+func join(values []interface{}) string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = fmt.Sprintf("%v", v)
+	}
+	return strings.Join(s, " ")
+}
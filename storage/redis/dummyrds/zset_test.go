@@ -0,0 +1,134 @@
+package dummyrds_test
+
+import (
+	"testing"
+
+	"github.com/5112100070/publib/storage/redis"
+	. "github.com/5112100070/publib/storage/redis/dummyrds"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZRem(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("ZREM foo a b", 2, false)
+	m.AddMock("ZREM err a", "failed", true)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, 2, rds.ZRem("foo", "a", "b").Int())
+	assert.EqualError(t, rds.ZRem("err", "a").Error, "failed")
+}
+
+func TestZIncrBy(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("ZINCRBY foo 1.5 a", "3.5", false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, 3.5, rds.ZIncrBy("foo", 1.5, "a").Float64())
+}
+
+func TestZScoreRank(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("ZSCORE foo a", "2", false)
+	m.AddMock("ZRANK foo a", 0, false)
+	m.AddMock("ZREVRANK foo a", 1, false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, 2.0, rds.ZScore("foo", "a").Float64())
+	assert.Equal(t, 0, rds.ZRank("foo", "a").Int())
+	assert.Equal(t, 1, rds.ZRevRank("foo", "a").Int())
+	assert.Nil(t, rds.ZRank("foo", "none").Value)
+}
+
+func TestZRevRange(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("ZREVRANGE foo 0 -1", []string{"b", "a"}, false)
+	m.AddMock("ZREVRANGEBYSCORE foo +inf -inf LIMIT 0 1", []string{"b"}, false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, []string{"b", "a"}, rds.ZRevRange("foo", 0, -1).StringSlice())
+	assert.Equal(t, []string{"b"}, rds.ZRevRangeByScore("foo", "+inf", "-inf", 1).StringSlice())
+}
+
+func TestZRangeWithScores(t *testing.T) {
+	z := []redis.Z{{Score: 1, Member: "a"}, {Score: 2, Member: "b"}}
+
+	m := Mocker{}
+	m.AddMock("ZRANGE foo 0 -1 WITHSCORES", z, false)
+	m.AddMock("ZREVRANGE foo 0 -1 WITHSCORES", []string{"b", "2", "a", "1"}, false)
+	m.AddMock("ZRANGEBYSCORE foo -inf +inf WITHSCORES", z, false)
+	m.AddMock("ZREVRANGEBYSCORE foo +inf -inf WITHSCORES LIMIT 0 1", z[1:], false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, z, rds.ZRangeWithScores("foo", 0, -1).ZSlice())
+	assert.Equal(t, []redis.Z{{Score: 2, Member: "b"}, {Score: 1, Member: "a"}}, rds.ZRevRangeWithScores("foo", 0, -1).ZSlice())
+	assert.Equal(t, z, rds.ZRangeByScoreWithScores("foo", "-inf", "+inf", 0).ZSlice())
+	assert.Equal(t, z[1:], rds.ZRevRangeByScoreWithScores("foo", "+inf", "-inf", 1).ZSlice())
+}
+
+func TestZCardCount(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("ZCARD foo", 3, false)
+	m.AddMock("ZCOUNT foo 1 2", 2, false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, 3, rds.ZCard("foo").Int())
+	assert.Equal(t, 2, rds.ZCount("foo", "1", "2").Int())
+}
+
+func TestZRemRange(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("ZREMRANGEBYSCORE foo -inf 10", 4, false)
+	m.AddMock("ZREMRANGEBYRANK foo 0 1", 2, false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, 4, rds.ZRemRangeByScore("foo", "-inf", "10").Int())
+	assert.Equal(t, 2, rds.ZRemRangeByRank("foo", 0, 1).Int())
+}
+
+func TestZPop(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("ZPOPMIN foo 1", []string{"a", "1"}, false)
+	m.AddMock("ZPOPMAX foo 1", []string{"c", "3"}, false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	assert.Equal(t, []redis.Z{{Score: 1, Member: "a"}}, rds.ZPopMin("foo", 1).ZSlice())
+	assert.Equal(t, []redis.Z{{Score: 3, Member: "c"}}, rds.ZPopMax("foo", 1).ZSlice())
+	assert.Nil(t, rds.ZPopMin("none", 1).ZSlice())
+}
+
+func TestZUnionStore(t *testing.T) {
+	m := Mocker{}
+	m.AddMock("ZUNIONSTORE dest 2 foo bar WEIGHTS 1 0.5 AGGREGATE MAX", 3, false)
+
+	rds := New(Config{
+		MockingMap: m,
+	})
+
+	store := redis.ZStore{Keys: []string{"foo", "bar"}, Weights: []float64{1, 0.5}, Aggregate: redis.AggregateMax}
+	assert.Equal(t, 3, rds.ZUnionStore("dest", store).Int())
+	assert.Error(t, rds.ZUnionStore("dest", redis.ZStore{Keys: []string{"foo"}}).Error)
+}
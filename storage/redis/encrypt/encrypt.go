@@ -0,0 +1,682 @@
+// Package encrypt offers a redis.Redis decorator encrypting values with AES-GCM.
+//
+// Every value is stored as the magic 0xC0 0xEC, the length of the key ID, the key ID,
+// the nonce and the sealed value. The redis key, and the field for hash values, are
+// authenticated along, so a value copied under another key fails with ErrTampered.
+// Rotating keys is adding a new key as CurrentKey while keeping the old ones readable.
+//
+// Values are encrypted by Set, Setex, PSetEx, SetNX, SetWithOptions, GetSet, MSet,
+// MSetNX, HSet, HMSet and HSetNX, and decrypted by Get, GetDel, GetEx, GetSet,
+// Set with GET, SetWithOptions, MGet, HGet, HMGet, HVals, HGetAll and HScan, so
+// redis.NewHScanIterator reads plaintext too. Rename re-encrypts the values under the
+// new key and Restore rolls back a value dumped from another key. The commands working
+// on the stored bytes, Append, StrLen, HStrLen, GetRange, SetRange and the bit commands,
+// return ErrUnsupported as they would read or break the encrypted value. Use the
+// decorated client for plaintext bitmaps.
+package encrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+var magic = []byte{0xC0, 0xEC}
+
+var _ redis.Redis = (*Client)(nil)
+
+// New encrypting decorator of rds
+func New(rds redis.Redis, config Config) (*Client, error) {
+	if _, ok := config.Keys[config.CurrentKey]; !ok {
+		return nil, fmt.Errorf("%w: current key %q not found", ErrInvalidConfig, config.CurrentKey)
+	}
+
+	aeads := make(map[string]cipher.AEAD, len(config.Keys))
+	for id, key := range config.Keys {
+		if len(id) == 0 || len(id) > 255 {
+			return nil, fmt.Errorf("%w: key id %q must be 1 to 255 bytes", ErrInvalidConfig, id)
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %q: %v", ErrInvalidConfig, id, err)
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %q: %v", ErrInvalidConfig, id, err)
+		}
+		aeads[id] = aead
+	}
+
+	return &Client{
+		Redis:          rds,
+		current:        config.CurrentKey,
+		aeads:          aeads,
+		allowPlaintext: config.AllowPlaintext,
+	}, nil
+}
+
+func (c *Client) Get(key string) *redis.Result {
+	return c.decryptResult(c.Redis.Get(key), keyAD(key))
+}
+
+func (c *Client) GetDel(key string) (string, error) {
+	return c.decryptString(keyAD(key))(c.Redis.GetDel(key))
+}
+
+func (c *Client) GetEx(key string, expiration time.Duration) (string, error) {
+	return c.decryptString(keyAD(key))(c.Redis.GetEx(key, expiration))
+}
+
+func (c *Client) MGet(keys ...string) *redis.Result {
+	ad := make([]string, len(keys))
+	for i, key := range keys {
+		ad[i] = keyAD(key)
+	}
+	return c.decryptValues(c.Redis.MGet(keys...), ad)
+}
+
+func (c *Client) HGet(key, field string) *redis.Result {
+	return c.decryptResult(c.Redis.HGet(key, field), hashAD(key, field))
+}
+
+func (c *Client) HMGet(key string, fields ...string) *redis.Result {
+	ad := make([]string, len(fields))
+	for i, field := range fields {
+		ad[i] = hashAD(key, field)
+	}
+	return c.decryptValues(c.Redis.HMGet(key, fields...), ad)
+}
+
+// HVals reads the whole hash, the fields authenticate the values
+func (c *Client) HVals(key string) *redis.Result {
+	res := c.HGetAll(key)
+	if res.Error != nil {
+		return res
+	}
+
+	pairs, _ := res.Value.([]interface{})
+	values := make([]interface{}, 0, len(pairs)/2)
+	for i := 1; i < len(pairs); i += 2 {
+		values = append(values, pairs[i])
+	}
+	return &redis.Result{Value: values}
+}
+
+// HGetAll decrypts the values of the field value pairs
+func (c *Client) HGetAll(key string) *redis.Result {
+	res := c.Redis.HGetAll(key)
+	if res.Error != nil {
+		return res
+	}
+
+	pairs, err := c.decryptPairs(key, res)
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+
+	return &redis.Result{Value: pairs}
+}
+
+// HScan decrypts the values of the field value pairs of the page
+func (c *Client) HScan(key string, cursor int, match string, count int) *redis.Result {
+	res := c.Redis.HScan(key, cursor, match, count)
+	if res.Error != nil {
+		return res
+	}
+
+	page, err := values(res)
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+	if len(page) != 2 {
+		return &redis.Result{Error: fmt.Errorf("encrypt: unexpected scan reply of %d elements", len(page))}
+	}
+
+	pairs, err := c.decryptPairs(key, &redis.Result{Value: page[1]})
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+
+	return &redis.Result{Value: []interface{}{page[0], pairs}}
+}
+
+// decryptPairs decrypts the values of the field value pairs of the hash key
+func (c *Client) decryptPairs(key string, res *redis.Result) ([]interface{}, error) {
+	pairs, err := values(res)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 1; i < len(pairs); i += 2 {
+		field := toString(pairs[i-1])
+		value, err := c.decrypt(pairs[i], hashAD(key, field))
+		if err != nil {
+			return nil, err
+		}
+		pairs[i] = value
+	}
+
+	return pairs, nil
+}
+
+// Set only accepts string keys, the previous value replied with GET is decrypted
+func (c *Client) Set(key, value interface{}, args ...interface{}) *redis.Result {
+	k, ok := key.(string)
+	if !ok {
+		return &redis.Result{Error: fmt.Errorf("%w: %T", ErrInvalidKey, key)}
+	}
+
+	value, err := c.encrypt(value, keyAD(k))
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+
+	res := c.Redis.Set(k, value, args...)
+	for _, arg := range args {
+		if s, ok := arg.(string); ok && strings.EqualFold(s, "GET") {
+			return c.decryptResult(res, keyAD(k))
+		}
+	}
+	return res
+}
+
+func (c *Client) Setex(key string, expireTime int, value interface{}) error {
+	value, err := c.encrypt(value, keyAD(key))
+	if err != nil {
+		return err
+	}
+	return c.Redis.Setex(key, expireTime, value)
+}
+
+func (c *Client) PSetEx(key string, ttl time.Duration, value interface{}) error {
+	value, err := c.encrypt(value, keyAD(key))
+	if err != nil {
+		return err
+	}
+	return c.Redis.PSetEx(key, ttl, value)
+}
+
+func (c *Client) SetNX(key string, value interface{}) (bool, error) {
+	value, err := c.encrypt(value, keyAD(key))
+	if err != nil {
+		return false, err
+	}
+	return c.Redis.SetNX(key, value)
+}
+
+func (c *Client) SetWithOptions(key string, value interface{}, opts redis.SetOptions) (bool, string, error) {
+	value, err := c.encrypt(value, keyAD(key))
+	if err != nil {
+		return false, "", err
+	}
+
+	written, previous, err := c.Redis.SetWithOptions(key, value, opts)
+	if err != nil || previous == "" {
+		return written, previous, err
+	}
+
+	previous, err = c.decryptString(keyAD(key))(previous, nil)
+	return written, previous, err
+}
+
+func (c *Client) GetSet(key string, value interface{}) (string, error) {
+	value, err := c.encrypt(value, keyAD(key))
+	if err != nil {
+		return "", err
+	}
+	return c.decryptString(keyAD(key))(c.Redis.GetSet(key, value))
+}
+
+func (c *Client) MSet(values map[string]interface{}) error {
+	values, err := c.encryptMap(values, keyAD)
+	if err != nil {
+		return err
+	}
+	return c.Redis.MSet(values)
+}
+
+func (c *Client) MSetNX(values map[string]interface{}) (bool, error) {
+	values, err := c.encryptMap(values, keyAD)
+	if err != nil {
+		return false, err
+	}
+	return c.Redis.MSetNX(values)
+}
+
+func (c *Client) HSet(key, field string, value interface{}) error {
+	value, err := c.encrypt(value, hashAD(key, field))
+	if err != nil {
+		return err
+	}
+	return c.Redis.HSet(key, field, value)
+}
+
+func (c *Client) HMSet(key string, values map[string]interface{}) error {
+	values, err := c.encryptMap(values, func(field string) string { return hashAD(key, field) })
+	if err != nil {
+		return err
+	}
+	return c.Redis.HMSet(key, values)
+}
+
+func (c *Client) HSetNX(key, field string, value interface{}) *redis.Result {
+	value, err := c.encrypt(value, hashAD(key, field))
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+	return c.Redis.HSetNX(key, field, value)
+}
+
+// renameStringScript renames a string and replaces its value if it is still the one read
+const renameStringScript = `
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then return 0 end
+redis.call('RENAME', KEYS[1], KEYS[2])
+redis.call('SET', KEYS[2], ARGV[2], 'KEEPTTL')
+return 1`
+
+// renameHashScript renames a hash and replaces its values if they are still the ones read,
+// ARGV holds the number of fields, the fields with their values and the new values
+const renameHashScript = `
+local n = tonumber(ARGV[1])
+if redis.call('HLEN', KEYS[1]) ~= n then return 0 end
+for i = 1, n do
+	if redis.call('HGET', KEYS[1], ARGV[2*i]) ~= ARGV[2*i+1] then return 0 end
+end
+redis.call('RENAME', KEYS[1], KEYS[2])
+for i = 1, n do
+	redis.call('HSET', KEYS[2], ARGV[2*i], ARGV[2*n+1+i])
+end
+return 1`
+
+// Rename re-encrypts the value of a string or the values of a hash under newKey, atomically
+// with the rename. Returns ErrConflict when the value is written during the rename
+func (c *Client) Rename(key, newKey string) *redis.Result {
+	res := c.Redis.Get(key)
+	switch {
+	case res.Error == nil && res.Value != nil:
+		return c.renameString(key, newKey, res.Bytes())
+	case res.Error != nil && !isWrongType(res.Error):
+		return res
+	case res.Error == nil:
+		// Missing key, redis replies the error
+		return c.Redis.Rename(key, newKey)
+	}
+
+	res = c.Redis.HGetAll(key)
+	if res.Error == nil {
+		return c.renameHash(key, newKey, res)
+	}
+	if !isWrongType(res.Error) {
+		return res
+	}
+
+	// Other types are not encrypted
+	return c.Redis.Rename(key, newKey)
+}
+
+func (c *Client) renameString(key, newKey string, stored []byte) *redis.Result {
+	renamed, err := c.reencrypt(stored, keyAD(key), keyAD(newKey))
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+
+	return c.rename(renameStringScript, key, newKey, stored, renamed)
+}
+
+func (c *Client) renameHash(key, newKey string, res *redis.Result) *redis.Result {
+	pairs, err := values(res)
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+
+	n := len(pairs) / 2
+	args := make([]interface{}, 0, 1+3*n)
+	args = append(args, n)
+	args = append(args, pairs...)
+	for i := 1; i < len(pairs); i += 2 {
+		field := toString(pairs[i-1])
+		renamed, err := c.reencrypt([]byte(toString(pairs[i])), hashAD(key, field), hashAD(newKey, field))
+		if err != nil {
+			return &redis.Result{Error: err}
+		}
+		args = append(args, renamed)
+	}
+
+	return c.rename(renameHashScript, key, newKey, args...)
+}
+
+// rename runs a rename script, replying OK like RENAME
+func (c *Client) rename(script, key, newKey string, args ...interface{}) *redis.Result {
+	res := c.Redis.Eval(script, []string{key, newKey}, args...)
+	if res.Error != nil {
+		return res
+	}
+	if res.Int() == 0 {
+		return &redis.Result{Error: ErrConflict}
+	}
+	return &redis.Result{Value: "OK"}
+}
+
+// reencrypt seals a stored value again under the associated data of its new place,
+// values stored in plaintext are kept as is
+func (c *Client) reencrypt(stored []byte, from, to string) ([]byte, error) {
+	if !bytes.HasPrefix(stored, magic) {
+		return stored, nil
+	}
+
+	plaintext, err := c.decrypt(stored, from)
+	if err != nil {
+		return nil, err
+	}
+	return c.encrypt(plaintext, to)
+}
+
+// Restore checks that a restored string or hash authenticates under key. A value dumped
+// from another key fails with ErrTampered, it is removed and the replaced value restored
+func (c *Client) Restore(key string, ttl time.Duration, value []byte, replace bool) error {
+	var previous []byte
+	var previousTTL time.Duration
+	if replace {
+		res := c.Redis.Dump(key)
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res.Value != nil {
+			previous = res.Bytes()
+
+			var err error
+			previousTTL, err = c.Redis.PTTL(key)
+			if err == redis.ErrNoExpire || err == redis.ErrKeyNotExist {
+				previousTTL = 0
+			} else if err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := c.Redis.Restore(key, ttl, value, replace); err != nil {
+		return err
+	}
+
+	err := c.authenticate(key)
+	if err != ErrTampered {
+		return err
+	}
+
+	// Roll back the restore
+	if previous != nil {
+		if err := c.Redis.Restore(key, previousTTL, previous, true); err != nil {
+			return err
+		}
+	} else if err := c.Redis.Del(key); err != nil {
+		return err
+	}
+
+	return ErrTampered
+}
+
+// authenticate reads a string or hash value, ErrTampered when it was encrypted for another key
+func (c *Client) authenticate(key string) error {
+	res := c.Get(key)
+	if res.Error == nil || res.Error == ErrNotEncrypted {
+		return nil
+	}
+	if !isWrongType(res.Error) {
+		return res.Error
+	}
+
+	res = c.HGetAll(key)
+	if res.Error == nil || res.Error == ErrNotEncrypted || isWrongType(res.Error) {
+		return nil
+	}
+	return res.Error
+}
+
+// Append is not supported, it would break the encrypted value
+func (c *Client) Append(key string, value interface{}) (int64, error) {
+	return 0, ErrUnsupported
+}
+
+// StrLen is not supported, it would return the length of the encrypted value
+func (c *Client) StrLen(key string) (int64, error) {
+	return 0, ErrUnsupported
+}
+
+// HStrLen is not supported, it would return the length of the encrypted value
+func (c *Client) HStrLen(key, field string) *redis.Result {
+	return &redis.Result{Error: ErrUnsupported}
+}
+
+// GetRange is not supported, it would return bytes of the encrypted value
+func (c *Client) GetRange(key string, start, end int) (string, error) {
+	return "", ErrUnsupported
+}
+
+// SetRange is not supported, it would break the encrypted value
+func (c *Client) SetRange(key string, offset int, value interface{}) (int64, error) {
+	return 0, ErrUnsupported
+}
+
+// SetBit is not supported, it would break the encrypted value
+func (c *Client) SetBit(key string, offset int64, value int) *redis.Result {
+	return &redis.Result{Error: ErrUnsupported}
+}
+
+// GetBit is not supported, it would return bits of the encrypted value
+func (c *Client) GetBit(key string, offset int64) *redis.Result {
+	return &redis.Result{Error: ErrUnsupported}
+}
+
+// BitCount is not supported, it would count the bits of the encrypted value
+func (c *Client) BitCount(key string) *redis.Result {
+	return &redis.Result{Error: ErrUnsupported}
+}
+
+// BitCountRange is not supported, it would count the bits of the encrypted value
+func (c *Client) BitCountRange(key string, start, end int64) *redis.Result {
+	return &redis.Result{Error: ErrUnsupported}
+}
+
+// BitPos is not supported, it would search the bits of the encrypted value
+func (c *Client) BitPos(key string, bit int) *redis.Result {
+	return &redis.Result{Error: ErrUnsupported}
+}
+
+// BitPosRange is not supported, it would search the bits of the encrypted value
+func (c *Client) BitPosRange(key string, bit int, start, end int64) *redis.Result {
+	return &redis.Result{Error: ErrUnsupported}
+}
+
+// BitOp is not supported, it would store a value decrypting to nothing
+func (c *Client) BitOp(op, destination string, keys ...string) *redis.Result {
+	return &redis.Result{Error: ErrUnsupported}
+}
+
+// BitField is not supported, it would read or break the encrypted value
+func (c *Client) BitField(key string, ops ...redis.BitFieldOp) *redis.Result {
+	return &redis.Result{Error: ErrUnsupported}
+}
+
+// isWrongType reports the error replied when a command does not apply to the type of the key
+func isWrongType(err error) bool {
+	return strings.HasPrefix(err.Error(), "WRONGTYPE")
+}
+
+// encrypt seals value with the current key, the plaintext is the bytes redigo would
+// send for value, see redis.FormatArg
+func (c *Client) encrypt(value interface{}, ad string) ([]byte, error) {
+	plaintext := redis.FormatArg(value)
+
+	aead := c.aeads[c.current]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(magic)+1+len(c.current)+len(nonce)+len(plaintext)+aead.Overhead())
+	out = append(out, magic...)
+	out = append(out, byte(len(c.current)))
+	out = append(out, c.current...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, plaintext, []byte(ad)), nil
+}
+
+func (c *Client) encryptMap(values map[string]interface{}, ad func(string) string) (map[string]interface{}, error) {
+	encrypted := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		v, err := c.encrypt(v, ad(k))
+		if err != nil {
+			return nil, err
+		}
+		encrypted[k] = v
+	}
+	return encrypted, nil
+}
+
+// decrypt opens a stored value, nil replies are returned as is
+func (c *Client) decrypt(value interface{}, ad string) (interface{}, error) {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return nil, fmt.Errorf("%w: unexpected reply type %T", ErrNotEncrypted, value)
+	}
+
+	if !bytes.HasPrefix(data, magic) {
+		if c.allowPlaintext {
+			return value, nil
+		}
+		return nil, ErrNotEncrypted
+	}
+
+	data = data[len(magic):]
+	if len(data) < 1 || len(data) < 1+int(data[0]) {
+		return nil, ErrTampered
+	}
+	id, data := string(data[1:1+int(data[0])]), data[1+int(data[0]):]
+
+	aead, ok := c.aeads[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, id)
+	}
+
+	if len(data) < aead.NonceSize() {
+		return nil, ErrTampered
+	}
+	nonce, sealed := data[:aead.NonceSize()], data[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, sealed, []byte(ad))
+	if err != nil {
+		return nil, ErrTampered
+	}
+
+	return plaintext, nil
+}
+
+func (c *Client) decryptResult(res *redis.Result, ad string) *redis.Result {
+	if res.Error != nil {
+		return res
+	}
+
+	value, err := c.decrypt(res.Value, ad)
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+	return &redis.Result{Value: value}
+}
+
+// decryptString decrypts the replies of the typed string commands
+func (c *Client) decryptString(ad string) func(string, error) (string, error) {
+	return func(value string, err error) (string, error) {
+		if err != nil {
+			return value, err
+		}
+
+		plaintext, err := c.decrypt(value, ad)
+		if err != nil {
+			return "", err
+		}
+		return toString(plaintext), nil
+	}
+}
+
+// decryptValues decrypts an array reply, ad holds the associated data of every element
+func (c *Client) decryptValues(res *redis.Result, ad []string) *redis.Result {
+	if res.Error != nil {
+		return res
+	}
+
+	vals, err := values(res)
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+
+	for i := range vals {
+		if i >= len(ad) {
+			break
+		}
+		value, err := c.decrypt(vals[i], ad[i])
+		if err != nil {
+			return &redis.Result{Error: err}
+		}
+		vals[i] = value
+	}
+
+	return &redis.Result{Value: vals}
+}
+
+// keyAD is the associated data of a string value
+func keyAD(key string) string {
+	return "k" + key
+}
+
+// hashAD is the associated data of a hash field, the key length keeps
+// every key and field pair distinct
+func hashAD(key, field string) string {
+	return "h" + strconv.Itoa(len(key)) + ":" + key + field
+}
+
+// values copies an array reply of redigo or of a mock
+func values(res *redis.Result) ([]interface{}, error) {
+	switch v := res.Value.(type) {
+	case []interface{}:
+		vals := make([]interface{}, len(v))
+		copy(vals, v)
+		return vals, nil
+	case []string:
+		vals := make([]interface{}, len(v))
+		for i := range v {
+			vals[i] = v[i]
+		}
+		return vals, nil
+	}
+
+	return nil, fmt.Errorf("encrypt: unexpected reply type %T", res.Value)
+}
+
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,248 @@
+package encrypt_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+	"github.com/5112100070/publib/storage/redis/dummyrds"
+	. "github.com/5112100070/publib/storage/redis/encrypt"
+	"github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/5112100070/publib/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	key1 = bytes.Repeat([]byte{1}, 32)
+	key2 = bytes.Repeat([]byte{2}, 16)
+)
+
+func newClient(t *testing.T, rds redis.Redis, config Config) *Client {
+	c, err := New(rds, config)
+	require.NoError(t, err)
+	return c
+}
+
+func TestEncrypt(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	c := newClient(t, rds, Config{Keys: map[string][]byte{"k1": key1}, CurrentKey: "k1"})
+	foo, bar, hash := redistest.Key(t, "foo"), redistest.Key(t, "bar"), redistest.Key(t, "hash")
+
+	require.NoError(t, c.Set(foo, "token").Error)
+	require.NoError(t, c.Setex(bar, 100, 42))
+	assert.Equal(t, "token", c.Get(foo).String())
+	assert.Equal(t, 42, c.Get(bar).Int())
+	assert.NotContains(t, rds.Get(foo).String(), "token")
+
+	assert.Equal(t, []string{"token", "", "42"}, c.MGet(foo, redistest.Key(t, "none"), bar).StringSlice())
+	res := c.Get(redistest.Key(t, "none"))
+	assert.NoError(t, res.Error)
+	assert.Nil(t, res.Value)
+
+	require.NoError(t, c.HSet(hash, "email", "a@b.c"))
+	require.NoError(t, c.HMSet(hash, map[string]interface{}{"phone": "0812"}))
+	assert.Equal(t, "a@b.c", c.HGet(hash, "email").String())
+	assert.Equal(t, []string{"0812", "a@b.c"}, c.HMGet(hash, "phone", "email").StringSlice())
+	assert.Equal(t, map[string]string{"email": "a@b.c", "phone": "0812"}, toMap(c.HGetAll(hash).StringSlice()))
+	assert.ElementsMatch(t, []string{"a@b.c", "0812"}, c.HVals(hash).StringSlice())
+
+	previous, err := c.GetSet(foo, "token2")
+	require.NoError(t, err)
+	assert.Equal(t, "token", previous)
+
+	_, previous, err = c.SetWithOptions(foo, "token3", redis.SetOptions{Get: true})
+	require.NoError(t, err)
+	assert.Equal(t, "token2", previous)
+
+	value, err := c.GetDel(foo)
+	require.NoError(t, err)
+	assert.Equal(t, "token3", value)
+}
+
+func TestEncrypt_HScan(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	c := newClient(t, rds, Config{Keys: map[string][]byte{"k1": key1}, CurrentKey: "k1"})
+	hash := redistest.Key(t, "hash")
+
+	require.NoError(t, c.HMSet(hash, map[string]interface{}{"email": "a@b.c", "phone": "0812"}))
+
+	got := map[string]string{}
+	it := redis.NewHScanIterator(c, hash, "", 1)
+	for it.Next() {
+		got[it.Field()] = it.Value()
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, map[string]string{"email": "a@b.c", "phone": "0812"}, got)
+
+	// a value moved to another field fails authentication
+	require.NoError(t, rds.HSet(hash, "phone", rds.HGet(hash, "email").Bytes()))
+	it = redis.NewHScanIterator(c, hash, "phone", 10)
+	for it.Next() {
+	}
+	assert.Equal(t, ErrTampered, it.Err())
+}
+
+func TestEncrypt_Set(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	c := newClient(t, rds, Config{Keys: map[string][]byte{"k1": key1}, CurrentKey: "k1"})
+	foo := redistest.Key(t, "foo")
+
+	assert.True(t, errors.Is(c.Set(42, "value").Error, ErrInvalidKey))
+	assert.True(t, errors.Is(c.Set([]byte(foo), "value").Error, ErrInvalidKey))
+
+	// values read back as the plain client stores them
+	hash := redistest.Key(t, "hash")
+	for _, value := range []interface{}{true, false, nil, 1.5, int64(7)} {
+		require.NoError(t, c.HSet(hash, "field", value))
+		require.NoError(t, rds.HSet(hash, "plain", value))
+		assert.Equal(t, rds.HGet(hash, "plain").String(), c.HGet(hash, "field").String(), "%#v", value)
+	}
+
+	assert.Equal(t, "OK", c.Set(foo, "old").String())
+	res := c.Set(foo, "new", "GET")
+	require.NoError(t, res.Error)
+	assert.Equal(t, "old", res.String())
+	assert.Equal(t, "new", c.Get(foo).String())
+}
+
+func TestEncrypt_Unsupported(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	c := newClient(t, rds, Config{Keys: map[string][]byte{"k1": key1}, CurrentKey: "k1"})
+	foo := redistest.Key(t, "foo")
+	require.NoError(t, c.Set(foo, "token").Error)
+
+	_, err := c.Append(foo, "x")
+	assert.Equal(t, ErrUnsupported, err)
+	_, err = c.SetRange(foo, 0, "x")
+	assert.Equal(t, ErrUnsupported, err)
+	_, err = c.GetRange(foo, 0, -1)
+	assert.Equal(t, ErrUnsupported, err)
+	_, err = c.StrLen(foo)
+	assert.Equal(t, ErrUnsupported, err)
+	assert.Equal(t, ErrUnsupported, c.HStrLen(foo, "field").Error)
+	assert.Equal(t, ErrUnsupported, c.SetBit(foo, 7, 1).Error)
+	assert.Equal(t, ErrUnsupported, c.GetBit(foo, 7).Error)
+	assert.Equal(t, ErrUnsupported, c.BitCount(foo).Error)
+	assert.Equal(t, ErrUnsupported, c.BitCountRange(foo, 0, -1).Error)
+	assert.Equal(t, ErrUnsupported, c.BitPos(foo, 1).Error)
+	assert.Equal(t, ErrUnsupported, c.BitPosRange(foo, 1, 0, -1).Error)
+	assert.Equal(t, ErrUnsupported, c.BitOp(redis.BitNot, redistest.Key(t, "bar"), foo).Error)
+	assert.Equal(t, ErrUnsupported, c.BitField(foo, redis.BitFieldGet("u8", 0)).Error)
+
+	// the value is left intact
+	assert.Equal(t, "token", c.Get(foo).String())
+}
+
+func TestEncrypt_Rename(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	c := newClient(t, rds, Config{Keys: map[string][]byte{"k1": key1}, CurrentKey: "k1"})
+	foo, bar := redistest.Key(t, "{rename}foo"), redistest.Key(t, "{rename}bar")
+	hash, renamedHash := redistest.Key(t, "{rename}hash"), redistest.Key(t, "{rename}hash2")
+	set, renamedSet := redistest.Key(t, "{rename}set"), redistest.Key(t, "{rename}set2")
+
+	require.NoError(t, c.PSetEx(foo, time.Minute, "token"))
+	require.NoError(t, c.Rename(foo, bar).Error)
+	assert.Equal(t, "token", c.Get(bar).String())
+	assert.Equal(t, 0, rds.Exists(foo).Int())
+	ttl, err := rds.PTTL(bar)
+	require.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(5*time.Second), "ttl is kept")
+
+	require.NoError(t, c.HMSet(hash, map[string]interface{}{"email": "a@b.c", "phone": "0812"}))
+	require.NoError(t, c.Rename(hash, renamedHash).Error)
+	assert.Equal(t, map[string]string{"email": "a@b.c", "phone": "0812"}, toMap(c.HGetAll(renamedHash).StringSlice()))
+
+	// other types are not encrypted
+	require.NoError(t, rds.SAdd(set, "a").Error)
+	require.NoError(t, c.Rename(set, renamedSet).Error)
+	assert.Equal(t, []string{"a"}, rds.SMembers(renamedSet).StringSlice())
+
+	assert.Error(t, c.Rename(redistest.Key(t, "{rename}none"), bar).Error)
+}
+
+func TestEncrypt_Restore(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	c := newClient(t, rds, Config{Keys: map[string][]byte{"k1": key1}, CurrentKey: "k1"})
+	foo, bar, baz := redistest.Key(t, "foo"), redistest.Key(t, "bar"), redistest.Key(t, "baz")
+
+	require.NoError(t, c.Set(foo, "foo").Error)
+	require.NoError(t, c.Set(bar, "bar").Error)
+	dump := rds.Dump(foo).Bytes()
+
+	// restored under another key, the value can not be authenticated
+	assert.Equal(t, ErrTampered, c.Restore(baz, 0, dump, false))
+	assert.Equal(t, 0, rds.Exists(baz).Int())
+	assert.Equal(t, ErrTampered, c.Restore(bar, 0, dump, true))
+	assert.Equal(t, "bar", c.Get(bar).String(), "the replaced value is restored")
+
+	require.NoError(t, c.Restore(foo, time.Minute, dump, true))
+	assert.Equal(t, "foo", c.Get(foo).String())
+}
+
+func TestEncrypt_Rotation(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	foo, bar := redistest.Key(t, "foo"), redistest.Key(t, "bar")
+
+	old := newClient(t, rds, Config{Keys: map[string][]byte{"k1": key1}, CurrentKey: "k1"})
+	require.NoError(t, old.Set(foo, "old").Error)
+
+	rotated := newClient(t, rds, Config{Keys: map[string][]byte{"k1": key1, "k2": key2}, CurrentKey: "k2"})
+	require.NoError(t, rotated.Set(bar, "new").Error)
+	assert.Equal(t, []string{"old", "new"}, rotated.MGet(foo, bar).StringSlice())
+
+	assert.True(t, errors.Is(old.Get(bar).Error, ErrUnknownKey))
+}
+
+func TestEncrypt_Tampered(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	c := newClient(t, rds, Config{Keys: map[string][]byte{"k1": key1}, CurrentKey: "k1"})
+	foo, bar, plain := redistest.Key(t, "foo"), redistest.Key(t, "bar"), redistest.Key(t, "plain")
+
+	require.NoError(t, c.Set(foo, "token").Error)
+	stored := rds.Get(foo).Bytes()
+
+	// copied under another key
+	require.NoError(t, rds.Set(bar, stored).Error)
+	assert.Equal(t, ErrTampered, c.Get(bar).Error)
+	assert.Empty(t, c.Get(bar).String())
+
+	// modified
+	stored[len(stored)-1] ^= 1
+	require.NoError(t, rds.Set(foo, stored).Error)
+	assert.Equal(t, ErrTampered, c.Get(foo).Error)
+
+	// truncated
+	require.NoError(t, rds.Set(foo, stored[:6]).Error)
+	assert.Equal(t, ErrTampered, c.Get(foo).Error)
+
+	require.NoError(t, rds.Set(plain, "token").Error)
+	assert.Equal(t, ErrNotEncrypted, c.Get(plain).Error)
+	assert.Equal(t, ErrNotEncrypted, c.MGet(plain, foo).Error, "the first error of the reply is returned")
+
+	migrating := newClient(t, rds, Config{Keys: map[string][]byte{"k1": key1}, CurrentKey: "k1", AllowPlaintext: true})
+	assert.Equal(t, "token", migrating.Get(plain).String())
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	rds := dummyrds.New(dummyrds.Config{})
+
+	_, err := New(rds, Config{Keys: map[string][]byte{"k1": key1}, CurrentKey: "k2"})
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+
+	_, err = New(rds, Config{Keys: map[string][]byte{"k1": []byte("short")}, CurrentKey: "k1"})
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+
+	_, err = New(rds, Config{Keys: map[string][]byte{"": key1}, CurrentKey: ""})
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+}
+
+func toMap(pairs []string) map[string]string {
+	m := make(map[string]string, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		m[pairs[i]] = pairs[i+1]
+	}
+	return m
+}
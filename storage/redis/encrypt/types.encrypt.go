@@ -0,0 +1,49 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"errors"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Error list
+var (
+	// ErrTampered is returned when a value fails authentication, it was modified,
+	// truncated or copied from another key
+	ErrTampered = errors.New("encrypt: value tampered")
+	// ErrUnknownKey is returned when a value was encrypted with a key ID that is not configured
+	ErrUnknownKey = errors.New("encrypt: unknown key id")
+	// ErrNotEncrypted is returned when a value was stored in plaintext, see Config.AllowPlaintext
+	ErrNotEncrypted = errors.New("encrypt: value not encrypted")
+	// ErrInvalidKey is returned by Set for a key that is not a string, the key authenticates the value
+	ErrInvalidKey = errors.New("encrypt: key must be a string")
+	// ErrConflict is returned by Rename when the value changed while it was re-encrypted
+	ErrConflict = errors.New("encrypt: value changed during rename")
+	// ErrUnsupported is returned by the commands working on the stored bytes, e.g. SetBit
+	ErrUnsupported = errors.New("encrypt: command not supported on encrypted values")
+	// ErrInvalidConfig is returned by New when the current key is missing or a key is not an AES key
+	ErrInvalidConfig = errors.New("encrypt: invalid config")
+)
+
+// Config of the encrypting decorator
+type Config struct {
+	// Keys by ID, every key is 16, 24 or 32 bytes for AES-128, AES-192 or AES-256.
+	// Keep the retired keys while values encrypted with them are stored
+	Keys map[string][]byte
+	// CurrentKey is the ID of the key encrypting the written values
+	CurrentKey string
+	// AllowPlaintext reads the values written before encryption as is instead of failing
+	// with ErrNotEncrypted, only while migrating
+	AllowPlaintext bool
+}
+
+// Client encrypts the values written through the embedded redis.Redis.
+// The commands that are not overridden are passed through as is
+type Client struct {
+	redis.Redis
+
+	current        string
+	aeads          map[string]cipher.AEAD
+	allowPlaintext bool
+}
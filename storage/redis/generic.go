@@ -0,0 +1,305 @@
+package redis
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	rgo "github.com/gomodule/redigo/redis"
+)
+
+// JSONCodec decodes structs with encoding/json
+var JSONCodec Codec = CodecFunc(json.Unmarshal)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// time layouts accepted by As, tried in order after unix seconds
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	// time.Time.String, how values are formatted when written by the redigo client
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+}
+
+// As converts the reply to T. It supports bool, string, []byte, every int, uint and float
+// width, time.Time from unix seconds, RFC 3339 or time.Time.String, slices and map[string]T
+// of those, and structs decoded by the codec, JSONCodec by default. A nil reply is ErrNil
+// unless T is a pointer, slice, map or interface, and a value that cannot be converted
+// returns an error wrapping ErrConvert.
+//
+//	ids, err := redis.As[[]int64](rds.LRange("ids", 0, -1))
+//	user, err := redis.As[User](rds.Get("user:1"))
+func As[T any](r *Result, codec ...Codec) (T, error) {
+	var v T
+	if r.Error != nil {
+		return v, r.Error
+	}
+
+	c := JSONCodec
+	if len(codec) > 0 && codec[0] != nil {
+		c = codec[0]
+	}
+
+	err := assign(reflect.ValueOf(&v).Elem(), r.Value, c)
+	return v, err
+}
+
+// SliceOf converts an array reply to []T, e.g. of LRange or MGet. Use a pointer
+// element type to receive nil for the missing values instead of ErrNil
+func SliceOf[T any](r *Result, codec ...Codec) ([]T, error) {
+	return As[[]T](r, codec...)
+}
+
+// MapOf converts the field and value pairs of HGetAll to map[string]T
+func MapOf[T any](r *Result, codec ...Codec) (map[string]T, error) {
+	return As[map[string]T](r, codec...)
+}
+
+// assign converts src to the type of dst and sets it
+func assign(dst reflect.Value, src interface{}, codec Codec) error {
+	typ := dst.Type()
+
+	if err, ok := src.(rgo.Error); ok {
+		return err
+	}
+
+	if src == nil {
+		switch typ.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+			dst.Set(reflect.Zero(typ))
+			return nil
+		}
+		return ErrNil
+	}
+
+	// Values of the expected type, e.g. from dummyrds
+	if sv := reflect.ValueOf(src); sv.Type().AssignableTo(typ) && typ.Kind() != reflect.Slice {
+		dst.Set(sv)
+		return nil
+	}
+
+	switch {
+	case typ == timeType:
+		t, err := parseTime(src)
+		if err != nil {
+			return convertError(src, typ, err)
+		}
+		dst.Set(reflect.ValueOf(t))
+		return nil
+	case typ.Kind() == reflect.Ptr:
+		elem := reflect.New(typ.Elem())
+		if err := assign(elem.Elem(), src, codec); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		s, err := scalar(src)
+		if err != nil {
+			return convertError(src, typ, err)
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return convertError(src, typ, err)
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s, err := scalar(src)
+		if err != nil {
+			return convertError(src, typ, err)
+		}
+		n, err := strconv.ParseInt(s, 10, typ.Bits())
+		if err != nil {
+			return convertError(src, typ, err)
+		}
+		dst.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s, err := scalar(src)
+		if err != nil {
+			return convertError(src, typ, err)
+		}
+		n, err := strconv.ParseUint(s, 10, typ.Bits())
+		if err != nil {
+			return convertError(src, typ, err)
+		}
+		dst.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		s, err := scalar(src)
+		if err != nil {
+			return convertError(src, typ, err)
+		}
+		f, err := strconv.ParseFloat(s, typ.Bits())
+		if err != nil {
+			return convertError(src, typ, err)
+		}
+		dst.SetFloat(f)
+	case reflect.String:
+		s, err := scalar(src)
+		if err != nil {
+			return convertError(src, typ, err)
+		}
+		dst.SetString(s)
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			s, err := scalar(src)
+			if err != nil {
+				return convertError(src, typ, err)
+			}
+			dst.SetBytes([]byte(s))
+			return nil
+		}
+		return assignSlice(dst, src, codec)
+	case reflect.Map:
+		return assignMap(dst, src, codec)
+	case reflect.Struct:
+		s, err := scalar(src)
+		if err != nil {
+			return convertError(src, typ, err)
+		}
+		if err := codec.Unmarshal([]byte(s), dst.Addr().Interface()); err != nil {
+			return convertError(src, typ, err)
+		}
+	case reflect.Interface:
+		dst.Set(reflect.ValueOf(src))
+	default:
+		return convertError(src, typ, fmt.Errorf("unsupported type"))
+	}
+
+	return nil
+}
+
+// assignSlice converts every element of an array reply
+func assignSlice(dst reflect.Value, src interface{}, codec Codec) error {
+	sv := reflect.ValueOf(src)
+	if _, bulk := src.([]byte); bulk || sv.Kind() != reflect.Slice {
+		return convertError(src, dst.Type(), fmt.Errorf("not an array reply"))
+	}
+
+	res := reflect.MakeSlice(dst.Type(), sv.Len(), sv.Len())
+	for i := 0; i < sv.Len(); i++ {
+		if err := assign(res.Index(i), sv.Index(i).Interface(), codec); err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+
+	dst.Set(res)
+	return nil
+}
+
+// assignMap converts the field and value pairs of an array reply, or the values of a map
+func assignMap(dst reflect.Value, src interface{}, codec Codec) error {
+	typ := dst.Type()
+	if typ.Key().Kind() != reflect.String {
+		return convertError(src, typ, fmt.Errorf("map key must be a string"))
+	}
+
+	res := reflect.MakeMap(typ)
+	set := func(field string, value interface{}) error {
+		v := reflect.New(typ.Elem()).Elem()
+		if err := assign(v, value, codec); err != nil {
+			return fmt.Errorf("field %q: %w", field, err)
+		}
+		res.SetMapIndex(reflect.ValueOf(field).Convert(typ.Key()), v)
+		return nil
+	}
+
+	sv := reflect.ValueOf(src)
+	_, bulk := src.([]byte)
+	switch {
+	case sv.Kind() == reflect.Map && sv.Type().Key().Kind() == reflect.String:
+		iter := sv.MapRange()
+		for iter.Next() {
+			if err := set(iter.Key().String(), iter.Value().Interface()); err != nil {
+				return err
+			}
+		}
+	case sv.Kind() == reflect.Slice && !bulk && sv.Len()%2 == 0:
+		for i := 0; i < sv.Len(); i += 2 {
+			field, err := scalar(sv.Index(i).Interface())
+			if err != nil {
+				return convertError(src, typ, err)
+			}
+			if err := set(field, sv.Index(i+1).Interface()); err != nil {
+				return err
+			}
+		}
+	default:
+		return convertError(src, typ, fmt.Errorf("not a field and value array reply"))
+	}
+
+	dst.Set(res)
+	return nil
+}
+
+// scalar returns the text of a bulk, integer, float or bool reply
+func scalar(src interface{}) (string, error) {
+	switch v := src.(type) {
+	case []byte:
+		return string(v), nil
+	case string:
+		return v, nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		// whole floats convert to integers
+		if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+			return strconv.FormatInt(int64(v), 10), nil
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+
+	rv := reflect.ValueOf(src)
+	switch rv.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 32), nil
+	}
+
+	return "", fmt.Errorf("not a scalar reply")
+}
+
+// parseTime reads unix seconds or one of timeLayouts
+func parseTime(src interface{}) (time.Time, error) {
+	s, err := scalar(src)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+
+	// Drop the monotonic clock reading of time.Time.String
+	if i := strings.Index(s, " m="); i > 0 {
+		s = s[:i]
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unknown time format")
+}
+
+func convertError(src interface{}, typ reflect.Type, err error) error {
+	if b, ok := src.([]byte); ok {
+		src = string(b)
+	}
+
+	return fmt.Errorf("%w: %#v to %s: %v", ErrConvert, src, typ, err)
+}
@@ -0,0 +1,180 @@
+package redis_test
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/5112100070/publib/storage/redis"
+	rgo "github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAs(t *testing.T) {
+	v, err := As[int](&Result{Value: int64(42)})
+	require.NoError(t, err)
+	assert.Equal(t, 42, v)
+
+	u8, err := As[uint8](&Result{Value: []byte("255")})
+	require.NoError(t, err)
+	assert.Equal(t, uint8(255), u8)
+
+	i16, err := As[int16](&Result{Value: "-300"})
+	require.NoError(t, err)
+	assert.Equal(t, int16(-300), i16)
+
+	u64, err := As[uint64](&Result{Value: []byte("18446744073709551615")})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(math.MaxUint64), u64)
+
+	f32, err := As[float32](&Result{Value: []byte("1.5")})
+	require.NoError(t, err)
+	assert.Equal(t, float32(1.5), f32)
+
+	b, err := As[bool](&Result{Value: int64(1)})
+	require.NoError(t, err)
+	assert.True(t, b)
+
+	b, err = As[bool](&Result{Value: []byte("false")})
+	require.NoError(t, err)
+	assert.False(t, b)
+
+	s, err := As[string](&Result{Value: int64(7)})
+	require.NoError(t, err)
+	assert.Equal(t, "7", s)
+
+	raw, err := As[[]byte](&Result{Value: "foo"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("foo"), raw)
+
+	// values of the expected type are kept, e.g. from dummyrds
+	v, err = As[int](&Result{Value: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 3, v)
+}
+
+func TestAs_Error(t *testing.T) {
+	_, err := As[int](&Result{Error: errors.New("failed")})
+	assert.EqualError(t, err, "failed")
+
+	_, err = As[int](&Result{})
+	assert.Equal(t, ErrNil, err)
+
+	p, err := As[*int](&Result{})
+	require.NoError(t, err)
+	assert.Nil(t, p, "a pointer receives nil")
+
+	// failures are reported instead of a zero value
+	for _, f := range []func() error{
+		func() error { _, err := As[int](&Result{Value: []byte("foo")}); return err },
+		func() error { _, err := As[int8](&Result{Value: int64(128)}); return err },
+		func() error { _, err := As[uint](&Result{Value: int64(-1)}); return err },
+		func() error { _, err := As[int](&Result{Value: 1.5}); return err },
+		func() error { _, err := As[bool](&Result{Value: []byte("maybe")}); return err },
+		func() error { _, err := As[time.Time](&Result{Value: []byte("yesterday")}); return err },
+		func() error { _, err := As[[]int](&Result{Value: []byte("1")}); return err },
+		func() error { _, err := As[map[string]int](&Result{Value: []interface{}{[]byte("a")}}); return err },
+		func() error { _, err := As[map[string]int](&Result{Value: []byte("ab")}); return err },
+		func() error { _, err := As[string](&Result{Value: []interface{}{}}); return err },
+		func() error { _, err := As[chan int](&Result{Value: []byte("1")}); return err },
+	} {
+		err := f()
+		assert.True(t, errors.Is(err, ErrConvert), "%v", err)
+	}
+
+	_, err = As[int8](&Result{Value: int64(128)})
+	assert.EqualError(t, err, `redis: cannot convert reply: 128 to int8: strconv.ParseInt: parsing "128": value out of range`)
+}
+
+func TestAs_Time(t *testing.T) {
+	want := time.Date(2026, 10, 19, 10, 30, 0, 500, time.UTC)
+
+	got, err := As[time.Time](&Result{Value: []byte(want.Format(time.RFC3339Nano))})
+	require.NoError(t, err)
+	assert.True(t, want.Equal(got))
+
+	got, err = As[time.Time](&Result{Value: int64(want.Unix())})
+	require.NoError(t, err)
+	assert.True(t, want.Truncate(time.Second).Equal(got))
+
+	// as written by the redigo client
+	now := time.Now()
+	got, err = As[time.Time](&Result{Value: []byte(now.String())})
+	require.NoError(t, err)
+	assert.True(t, now.Equal(got))
+}
+
+type user struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func TestAs_Struct(t *testing.T) {
+	u, err := As[user](&Result{Value: []byte(`{"name":"a","age":3}`)})
+	require.NoError(t, err)
+	assert.Equal(t, user{Name: "a", Age: 3}, u)
+
+	_, err = As[user](&Result{Value: []byte(`{"name":`)})
+	assert.True(t, errors.Is(err, ErrConvert))
+
+	// a custom codec
+	upper := CodecFunc(func(data []byte, v interface{}) error {
+		v.(*user).Name = strings.ToUpper(string(data))
+		return nil
+	})
+	u, err = As[user](&Result{Value: []byte("b")}, upper)
+	require.NoError(t, err)
+	assert.Equal(t, user{Name: "B"}, u)
+}
+
+func TestSliceOf(t *testing.T) {
+	ints, err := SliceOf[int64](&Result{Value: []interface{}{[]byte("1"), int64(2)}})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, ints)
+
+	strs, err := SliceOf[string](&Result{Value: []string{"a", "b"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, strs)
+
+	// MGet with a missing key
+	_, err = SliceOf[int](&Result{Value: []interface{}{[]byte("1"), nil}})
+	assert.EqualError(t, err, "element 1: "+ErrNil.Error())
+
+	ptrs, err := SliceOf[*int](&Result{Value: []interface{}{[]byte("1"), nil}})
+	require.NoError(t, err)
+	require.Len(t, ptrs, 2)
+	assert.Equal(t, 1, *ptrs[0])
+	assert.Nil(t, ptrs[1])
+
+	users, err := SliceOf[user](&Result{Value: []interface{}{[]byte(`{"name":"a"}`), []byte(`{"name":"b"}`)}})
+	require.NoError(t, err)
+	assert.Equal(t, []user{{Name: "a"}, {Name: "b"}}, users)
+
+	_, err = SliceOf[int](&Result{Value: []interface{}{rgo.Error("WRONGTYPE")}})
+	assert.EqualError(t, err, "element 0: WRONGTYPE")
+
+	nilSlice, err := SliceOf[int](&Result{})
+	require.NoError(t, err)
+	assert.Nil(t, nilSlice)
+}
+
+func TestMapOf(t *testing.T) {
+	m, err := MapOf[int](&Result{Value: []interface{}{[]byte("a"), []byte("1"), []byte("b"), []byte("2")}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, m)
+
+	m, err = MapOf[int](&Result{Value: map[string]string{"c": "3"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"c": 3}, m)
+
+	_, err = MapOf[int](&Result{Value: []interface{}{[]byte("a"), []byte("x")}})
+	assert.True(t, errors.Is(err, ErrConvert))
+	assert.Contains(t, err.Error(), `field "a"`)
+
+	users, err := As[map[string]user](&Result{Value: []interface{}{[]byte("1"), []byte(`{"name":"a"}`)}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]user{"1": {Name: "a"}}, users)
+}
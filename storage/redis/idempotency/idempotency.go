@@ -0,0 +1,170 @@
+// Package idempotency runs a request at most once per idempotency key and replays
+// its response to the retries.
+//
+// The first request reserves the key atomically with SET NX and a lock timeout.
+// Its response is stored under the key when it completes, and returned to every
+// following request with the same key. A duplicate arriving while the first request
+// still runs gets ErrInFlight, replied as 409 Conflict by the middleware.
+//
+//	store, err := idempotency.New(rds, idempotency.Config{
+//		Scope: func(r *http.Request) string { return userID(r) },
+//	})
+//	http.Handle("/payments", store.Middleware(pay))
+package idempotency
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// pendingPrefix marks a reserved key, followed by the token of its lock
+const pendingPrefix = "pending:"
+
+// KEYS key. ARGV token, response, ttl in milliseconds
+const completeScript = `
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then return 0 end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return 1`
+
+// KEYS key. ARGV token
+const releaseScript = `
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then return 0 end
+redis.call('DEL', KEYS[1])
+return 1`
+
+// New idempotency store
+func New(rds redis.Redis, config Config) (*Store, error) {
+	if config.Scope == nil {
+		return nil, fmt.Errorf("%w: scope is required", ErrInvalidConfig)
+	}
+
+	if config.Prefix == "" {
+		config.Prefix = "idempotency:"
+	}
+
+	// Set default one minute lock timeout
+	if config.LockTimeout <= 0 {
+		config.LockTimeout = time.Minute
+	}
+
+	// Set default 24 hours replay
+	if config.TTL <= 0 {
+		config.TTL = 24 * time.Hour
+	}
+
+	if config.Header == "" {
+		config.Header = "Idempotency-Key"
+	}
+
+	if config.OnError == nil {
+		config.OnError = func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}
+
+	if config.OnFinishError == nil {
+		config.OnFinishError = func(r *http.Request, err error) {}
+	}
+
+	return &Store{
+		rds:         rds,
+		prefix:      config.Prefix,
+		lockTimeout: config.LockTimeout,
+		ttl:         config.TTL,
+		header:      config.Header,
+		scope:       config.Scope,
+		onError:     config.OnError,
+		onFinish:    config.OnFinishError,
+	}, nil
+}
+
+// Begin reserves key. It returns the lock of a newly reserved key, or the stored
+// response when the key was completed. ErrInFlight is returned while another
+// request holds the key
+func (s *Store) Begin(key string) (*Lock, *Response, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lock := &Lock{store: s, key: s.prefix + key, token: pendingPrefix + token}
+
+	// The key may expire between SET and GET, reserve it again then
+	for attempt := 0; attempt < 2; attempt++ {
+		reserved, _, err := s.rds.SetWithOptions(lock.key, lock.token, redis.SetOptions{NX: true, TTL: s.lockTimeout})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if reserved {
+			return lock, nil, nil
+		}
+
+		res := s.rds.Get(lock.key)
+		if res.Error != nil {
+			return nil, nil, res.Error
+		}
+
+		if res.Value == nil {
+			continue
+		}
+
+		value := res.String()
+		if strings.HasPrefix(value, pendingPrefix) {
+			return nil, nil, ErrInFlight
+		}
+
+		var resp Response
+		if err := json.Unmarshal([]byte(value), &resp); err != nil {
+			return nil, nil, fmt.Errorf("idempotency: decode response: %v", err)
+		}
+		return nil, &resp, nil
+	}
+
+	return nil, nil, ErrInFlight
+}
+
+// Complete stores the response of the locked request, it is replayed for the TTL
+func (l *Lock) Complete(resp Response) error {
+	value, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	ttl := int64(l.store.ttl / time.Millisecond)
+	return l.check(l.store.rds.Eval(completeScript, []string{l.key}, l.token, value, ttl))
+}
+
+// Release frees the key without a response, so a retry runs the request again
+func (l *Lock) Release() error {
+	return l.check(l.store.rds.Eval(releaseScript, []string{l.key}, l.token))
+}
+
+func (l *Lock) check(res *redis.Result) error {
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.Int() == 0 {
+		return ErrLockLost
+	}
+
+	return nil
+}
+
+// newToken returns a random lock token
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("idempotency: generate token: %v", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
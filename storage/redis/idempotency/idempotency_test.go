@@ -0,0 +1,99 @@
+package idempotency_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis/dummyrds"
+	. "github.com/5112100070/publib/storage/redis/idempotency"
+	"github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/5112100070/publib/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	store := newStore(t, rds, Config{Prefix: redistest.Key(t, "idem:")})
+
+	lock, resp, err := store.Begin("pay-1")
+	require.NoError(t, err)
+	require.NotNil(t, lock)
+	assert.Nil(t, resp)
+
+	_, _, err = store.Begin("pay-1")
+	assert.Equal(t, ErrInFlight, err)
+
+	want := Response{
+		StatusCode: http.StatusCreated,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       []byte(`{"id":1}`),
+	}
+	require.NoError(t, lock.Complete(want))
+	assert.Equal(t, ErrLockLost, lock.Release(), "a completed key stays")
+
+	for i := 0; i < 2; i++ {
+		lock, resp, err = store.Begin("pay-1")
+		require.NoError(t, err)
+		assert.Nil(t, lock)
+		assert.Equal(t, &want, resp)
+	}
+}
+
+func TestStore_Release(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	store := newStore(t, rds, Config{Prefix: redistest.Key(t, "idem:")})
+
+	lock, _, err := store.Begin("pay-1")
+	require.NoError(t, err)
+	require.NoError(t, lock.Release())
+
+	// the retry runs again
+	lock, _, err = store.Begin("pay-1")
+	require.NoError(t, err)
+	assert.NotNil(t, lock)
+}
+
+func TestStore_LockTimeout(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	store := newStore(t, rds, Config{
+		Prefix:      redistest.Key(t, "idem:"),
+		LockTimeout: 100 * time.Millisecond,
+	})
+
+	crashed, _, err := store.Begin("pay-1")
+	require.NoError(t, err)
+
+	time.Sleep(150 * time.Millisecond)
+	retry, _, err := store.Begin("pay-1")
+	require.NoError(t, err)
+	require.NotNil(t, retry)
+
+	// the late request does not overwrite the retry
+	assert.Equal(t, ErrLockLost, crashed.Complete(Response{StatusCode: http.StatusOK}))
+	assert.Equal(t, ErrLockLost, crashed.Release())
+	_, _, err = store.Begin("pay-1")
+	assert.Equal(t, ErrInFlight, err)
+
+	require.NoError(t, retry.Complete(Response{StatusCode: http.StatusAccepted}))
+	_, resp, err := store.Begin("pay-1")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+}
+
+func TestStore_Corrupted(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	prefix := redistest.Key(t, "idem:")
+	store := newStore(t, rds, Config{Prefix: prefix})
+
+	require.NoError(t, rds.Set(prefix+"pay-1", "not json").Error)
+	_, _, err := store.Begin("pay-1")
+	assert.Error(t, err)
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	_, err := New(dummyrds.New(dummyrds.Config{}), Config{})
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+}
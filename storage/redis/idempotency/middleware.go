@@ -0,0 +1,119 @@
+package idempotency
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+)
+
+// ReplayedHeader is set on the responses replayed by the middleware
+const ReplayedHeader = "Idempotent-Replayed"
+
+// Middleware runs the requests carrying the idempotency header at most once per
+// scope, key, method and path. Duplicates get the stored response, or 409 Conflict
+// while the first request is still running. Responses with a 5xx status are not
+// stored, the key is released so the client can retry
+func (s *Store) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(s.header)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		scope := s.scope(r)
+		if scope == "" {
+			s.onError(w, r, ErrNoScope)
+			return
+		}
+
+		// The scope is quoted so its spaces can not forge the key of another scope
+		lock, resp, err := s.Begin(strconv.Quote(scope) + " " + r.Method + " " + r.URL.Path + " " + key)
+		switch {
+		case err == ErrInFlight:
+			http.Error(w, "request with the same idempotency key is in progress", http.StatusConflict)
+			return
+		case err != nil:
+			s.onError(w, r, err)
+			return
+		case resp != nil:
+			replay(w, resp)
+			return
+		}
+
+		rec := &recorder{ResponseWriter: w}
+		defer func() {
+			// A panicking request may be retried
+			if p := recover(); p != nil {
+				if err := lock.Release(); err != nil {
+					s.onFinish(r, err)
+				}
+				panic(p)
+			}
+		}()
+
+		next.ServeHTTP(rec, r)
+
+		if rec.status >= http.StatusInternalServerError {
+			if err := lock.Release(); err != nil {
+				s.onFinish(r, err)
+			}
+			return
+		}
+
+		if err := lock.Complete(rec.response()); err != nil {
+			s.onFinish(r, err)
+		}
+	})
+}
+
+func replay(w http.ResponseWriter, resp *Response) {
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(resp.StatusCode)
+	w.Write(resp.Body)
+}
+
+// recorder writes the response through while keeping a copy of it
+type recorder struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+		r.header = r.ResponseWriter.Header().Clone()
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the original writer for http.ResponseController
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// response returns the recorded response, 200 OK when nothing was written
+func (r *recorder) response() Response {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+
+	return Response{
+		StatusCode: r.status,
+		Header:     r.header,
+		Body:       r.body.Bytes(),
+	}
+}
@@ -0,0 +1,181 @@
+package idempotency_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+	"github.com/5112100070/publib/storage/redis/dummyrds"
+	. "github.com/5112100070/publib/storage/redis/idempotency"
+	"github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/5112100070/publib/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// user scopes the keys by the X-User header of the tests
+func user(r *http.Request) string {
+	return r.Header.Get("X-User")
+}
+
+func newStore(t *testing.T, rds redis.Redis, config Config) *Store {
+	if config.Scope == nil {
+		config.Scope = user
+	}
+
+	store, err := New(rds, config)
+	require.NoError(t, err)
+	return store
+}
+
+func request(handler http.Handler, method, path, key string) *httptest.ResponseRecorder {
+	return requestAs(handler, "alice", method, path, key)
+}
+
+func requestAs(handler http.Handler, userID, method, path, key string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader("{}"))
+	r.Header.Set("X-User", userID)
+	if key != "" {
+		r.Header.Set("Idempotency-Key", key)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	store := newStore(t, rds, Config{Prefix: redistest.Key(t, "idem:"), Scope: user})
+
+	var calls int32
+	release := make(chan struct{})
+	handler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		if r.URL.Query().Get("wait") != "" {
+			<-release
+		}
+		w.Header().Set("X-Payment", fmt.Sprint(n))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "payment %d", n)
+	}))
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- request(handler, http.MethodPost, "/pay?wait=1", "k1") }()
+
+	// the duplicate is rejected while the first request runs
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, time.Second, 5*time.Millisecond)
+	w := request(handler, http.MethodPost, "/pay", "k1")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	close(release)
+	first := <-done
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, "payment 1", first.Body.String())
+	assert.Empty(t, first.Header().Get(ReplayedHeader))
+
+	// the response is replayed without running the handler
+	w = request(handler, http.MethodPost, "/pay", "k1")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "payment 1", w.Body.String())
+	assert.Equal(t, "1", w.Header().Get("X-Payment"))
+	assert.Equal(t, "true", w.Header().Get(ReplayedHeader))
+
+	// keys are scoped by caller, method and path, requests without a key always run
+	assert.Equal(t, "payment 2", request(handler, http.MethodPost, "/refund", "k1").Body.String())
+	assert.Equal(t, "payment 3", requestAs(handler, "bob", http.MethodPost, "/pay", "k1").Body.String())
+	assert.Equal(t, "payment 4", requestAs(handler, `alice" POST /pay k1`, http.MethodPost, "/pay", "k1").Body.String())
+	assert.Equal(t, "payment 5", request(handler, http.MethodPost, "/pay", "").Body.String())
+	assert.Equal(t, "payment 6", request(handler, http.MethodPost, "/pay", "").Body.String())
+	assert.Equal(t, int32(6), atomic.LoadInt32(&calls))
+}
+
+func TestMiddleware_Scope(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+
+	var errs []error
+	store := newStore(t, rds, Config{
+		Prefix: redistest.Key(t, "idem:"),
+		Scope:  user,
+		OnError: func(w http.ResponseWriter, r *http.Request, err error) {
+			errs = append(errs, err)
+			w.WriteHeader(http.StatusUnauthorized)
+		},
+	})
+	handler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// anonymous requests carrying a key are rejected, without a key they run
+	assert.Equal(t, http.StatusUnauthorized, requestAs(handler, "", http.MethodPost, "/pay", "k1").Code)
+	assert.Equal(t, []error{ErrNoScope}, errs)
+	assert.Equal(t, http.StatusOK, requestAs(handler, "", http.MethodPost, "/pay", "").Code)
+}
+
+func TestMiddleware_FinishError(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	prefix := redistest.Key(t, "idem:")
+
+	var errs []error
+	store := newStore(t, rds, Config{
+		Prefix:        prefix,
+		Scope:         user,
+		OnFinishError: func(r *http.Request, err error) { errs = append(errs, err) },
+	})
+
+	// the lock is lost while the request runs
+	handler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, rds.Del(prefix+`"alice" POST /pay k1`))
+		io.WriteString(w, "paid")
+	}))
+
+	w := request(handler, http.MethodPost, "/pay", "k1")
+	assert.Equal(t, "paid", w.Body.String())
+	assert.Equal(t, []error{ErrLockLost}, errs)
+}
+
+func TestMiddleware_Retry(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	store := newStore(t, rds, Config{Prefix: redistest.Key(t, "idem:"), Scope: user})
+
+	var calls int32
+	handler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		case 2:
+			panic("boom")
+		default:
+			io.WriteString(w, "paid")
+		}
+	}))
+
+	// server errors and panics are not stored, the retries run again
+	assert.Equal(t, http.StatusServiceUnavailable, request(handler, http.MethodPost, "/pay", "k1").Code)
+	assert.Panics(t, func() { request(handler, http.MethodPost, "/pay", "k1") })
+
+	w := request(handler, http.MethodPost, "/pay", "k1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "paid", w.Body.String())
+
+	w = request(handler, http.MethodPost, "/pay", "k1")
+	assert.Equal(t, "paid", w.Body.String())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestMiddleware_Error(t *testing.T) {
+	store := newStore(t, dummyrds.New(dummyrds.Config{MockingMap: dummyrds.Mocker{}}), Config{Scope: user})
+
+	called := false
+	handler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	w := request(handler, http.MethodPost, "/pay", "k1")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.False(t, called)
+}
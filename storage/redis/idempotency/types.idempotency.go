@@ -0,0 +1,76 @@
+package idempotency
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Error list
+var (
+	// ErrInFlight is returned by Begin while the request holding the key is still running
+	ErrInFlight = errors.New("idempotency: request in flight")
+	// ErrLockLost is returned by Complete and Release when the lock timeout expired
+	// and the key was reserved again, the response is not stored
+	ErrLockLost = errors.New("idempotency: lock lost")
+	// ErrNoScope is passed to OnError when Scope returns no caller for a request
+	// carrying an idempotency key
+	ErrNoScope = errors.New("idempotency: no scope")
+	// ErrInvalidConfig is returned by New when the scope is missing
+	ErrInvalidConfig = errors.New("idempotency: invalid config")
+)
+
+// Config of an idempotency store
+type Config struct {
+	// Prefix of the keys, default is "idempotency:"
+	Prefix string
+	// LockTimeout releases a key whose request did not complete, e.g. after a crash.
+	// It should be longer than the slowest request, default is one minute
+	LockTimeout time.Duration
+	// TTL is how long a response is replayed, default is 24 hours
+	TTL time.Duration
+
+	// Header carrying the key of a request, default is "Idempotency-Key".
+	// Requests without it are not deduplicated
+	Header string
+	// Scope returns the caller owning the keys of a request, e.g. the authenticated
+	// user ID. It is required: a key is only replayed to the caller
+	// who sent it first, otherwise any client reusing the key gets the response of
+	// another. Requests with an empty scope get OnError with ErrNoScope
+	Scope func(r *http.Request) string
+	// OnError writes the response when the middleware fails to reserve a key,
+	// default is 500 Internal Server Error
+	OnError func(w http.ResponseWriter, r *http.Request, err error)
+	// OnFinishError reports the errors completing or releasing a key once the
+	// response was written, the key stays reserved until the lock timeout.
+	// Default ignores them
+	OnFinishError func(r *http.Request, err error)
+}
+
+// Store reserves idempotency keys and keeps the responses of completed requests
+type Store struct {
+	rds         redis.Redis
+	prefix      string
+	lockTimeout time.Duration
+	ttl         time.Duration
+	header      string
+	scope       func(r *http.Request) string
+	onError     func(w http.ResponseWriter, r *http.Request, err error)
+	onFinish    func(r *http.Request, err error)
+}
+
+// Lock is a reserved key, finish it with Complete or Release
+type Lock struct {
+	store *Store
+	key   string
+	token string
+}
+
+// Response of a completed request
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
@@ -0,0 +1,199 @@
+package redis
+
+import "context"
+
+// HScanIterator walks the fields of a hash with HSCAN, a page at a time.
+// As with HSCAN itself, a field modified during the iteration may be returned more than once.
+//
+//	it := redis.NewHScanIterator(rds, "hash", "", 100)
+//	for it.Next() {
+//		fmt.Println(it.Field(), it.Value())
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type HScanIterator struct {
+	rds   Redis
+	key   string
+	match string
+	count int
+
+	cursor  int
+	started bool
+	page    []string
+	pos     int
+
+	field string
+	value string
+	err   error
+}
+
+// NewHScanIterator returns an iterator over the fields of key matching match,
+// count is the page size hint sent to HSCAN
+func NewHScanIterator(rds Redis, key, match string, count int) *HScanIterator {
+	return &HScanIterator{
+		rds:   rds,
+		key:   key,
+		match: match,
+		count: count,
+	}
+}
+
+// Next advances to the next field, it returns false when the hash is exhausted or an error occurred
+func (it *HScanIterator) Next() bool {
+	for it.pos+2 > len(it.page) {
+		if it.err != nil || (it.started && it.cursor == 0) {
+			return false
+		}
+
+		cursor, page, err := it.rds.HScan(it.key, it.cursor, it.match, it.count).ScanResult()
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.started = true
+		it.cursor, it.page, it.pos = cursor, page, 0
+	}
+
+	it.field, it.value = it.page[it.pos], it.page[it.pos+1]
+	it.pos += 2
+
+	return true
+}
+
+// Field of the current entry
+func (it *HScanIterator) Field() string {
+	return it.field
+}
+
+// Value of the current entry
+func (it *HScanIterator) Value() string {
+	return it.value
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *HScanIterator) Err() error {
+	return it.err
+}
+
+// ScanOptions filters the keys walked by SCAN
+type ScanOptions struct {
+	// Match is a glob pattern, every key is walked when empty
+	Match string
+	// Count is the page size hint sent to SCAN
+	Count int
+	// Type only walks the keys of the given type, e.g. "string", "hash", "zset"
+	Type string
+}
+
+// scan requests a single page
+func (o ScanOptions) scan(rds Redis, cursor int) (int, []string, error) {
+	if o.Type != "" {
+		return rds.ScanType(cursor, o.Match, o.Count, o.Type).ScanResult()
+	}
+	return rds.Scan(cursor, o.Match, o.Count).ScanResult()
+}
+
+// ScanIterator walks the keyspace with SCAN, a page at a time.
+// As with SCAN itself, a key may be returned more than once.
+//
+//	it := redis.NewScanIterator(rds, redis.ScanOptions{Match: "user:*", Count: 1000})
+//	for it.Next() {
+//		fmt.Println(it.Key())
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type ScanIterator struct {
+	rds  Redis
+	opts ScanOptions
+
+	cursor  int
+	started bool
+	page    []string
+	pos     int
+
+	key string
+	err error
+}
+
+// NewScanIterator returns an iterator over the keys selected by opts
+func NewScanIterator(rds Redis, opts ScanOptions) *ScanIterator {
+	return &ScanIterator{
+		rds:  rds,
+		opts: opts,
+	}
+}
+
+// Next advances to the next key, it returns false when the keyspace is exhausted or an error occurred
+func (it *ScanIterator) Next() bool {
+	for it.pos >= len(it.page) {
+		if it.err != nil || (it.started && it.cursor == 0) {
+			return false
+		}
+
+		cursor, page, err := it.opts.scan(it.rds, it.cursor)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.started = true
+		it.cursor, it.page, it.pos = cursor, page, 0
+	}
+
+	it.key = it.page[it.pos]
+	it.pos++
+
+	return true
+}
+
+// Key of the current entry
+func (it *ScanIterator) Key() string {
+	return it.key
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *ScanIterator) Err() error {
+	return it.err
+}
+
+// ScanAll calls fn for every key selected by opts.
+// It stops at the first error returned by SCAN or fn, or when ctx is done
+func ScanAll(ctx context.Context, rds Redis, opts ScanOptions, fn func(key string) error) error {
+	return scanPages(ctx, rds, opts, func(keys []string) error {
+		for _, key := range keys {
+			if err := fn(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// scanPages calls fn with every non empty page of keys selected by opts
+func scanPages(ctx context.Context, rds Redis, opts ScanOptions, fn func(keys []string) error) error {
+	cursor := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		next, keys, err := opts.scan(rds, cursor)
+		if err != nil {
+			return err
+		}
+
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
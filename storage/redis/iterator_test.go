@@ -0,0 +1,111 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/5112100070/publib/storage/redis"
+	"github.com/5112100070/publib/storage/redis/dummyrds"
+	"github.com/stretchr/testify/assert"
+)
+
+// page builds a scan reply
+func page(cursor string, values ...string) []interface{} {
+	items := make([]interface{}, len(values))
+	for i, v := range values {
+		items[i] = []byte(v)
+	}
+	return []interface{}{[]byte(cursor), items}
+}
+
+func TestHScanIterator(t *testing.T) {
+	m := dummyrds.Mocker{}
+	m.AddMock("HSCAN foo 0 f* 2", page("5", "f1", "1", "f2", "2"), false)
+	m.AddMock("HSCAN foo 5 f* 2", page("7"), false)
+	m.AddMock("HSCAN foo 7 f* 2", page("0", "f3", "3"), false)
+
+	it := NewHScanIterator(dummyrds.New(dummyrds.Config{MockingMap: m}), "foo", "f*", 2)
+
+	got := map[string]string{}
+	for it.Next() {
+		got[it.Field()] = it.Value()
+	}
+
+	assert.NoError(t, it.Err())
+	assert.Equal(t, map[string]string{"f1": "1", "f2": "2", "f3": "3"}, got)
+	assert.False(t, it.Next())
+}
+
+func TestHScanIterator_Error(t *testing.T) {
+	m := dummyrds.Mocker{}
+	m.AddMock("HSCAN foo 0  0", page("5", "f1", "1"), false)
+	m.AddMock("HSCAN foo 5  0", "failed", true)
+
+	it := NewHScanIterator(dummyrds.New(dummyrds.Config{MockingMap: m}), "foo", "", 0)
+
+	assert.True(t, it.Next())
+	assert.Equal(t, "f1", it.Field())
+	assert.False(t, it.Next())
+	assert.EqualError(t, it.Err(), "failed")
+	assert.False(t, it.Next())
+}
+
+func TestScanIterator(t *testing.T) {
+	m := dummyrds.Mocker{}
+	m.AddMock("SCAN 0 user:* 100", page("3", "user:1", "user:2"), false)
+	m.AddMock("SCAN 3 user:* 100", page("0", "user:3"), false)
+	m.AddMock("SCAN 0 user:* 100 hash", page("0", "user:2"), false)
+
+	rds := dummyrds.New(dummyrds.Config{MockingMap: m})
+
+	var got []string
+	it := NewScanIterator(rds, ScanOptions{Match: "user:*", Count: 100})
+	for it.Next() {
+		got = append(got, it.Key())
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []string{"user:1", "user:2", "user:3"}, got)
+
+	it = NewScanIterator(rds, ScanOptions{Match: "user:*", Count: 100, Type: "hash"})
+	assert.True(t, it.Next())
+	assert.Equal(t, "user:2", it.Key())
+	assert.False(t, it.Next())
+	assert.NoError(t, it.Err())
+}
+
+func TestScanAll(t *testing.T) {
+	m := dummyrds.Mocker{}
+	m.AddMock("SCAN 0 user:* 100", page("3", "user:1", "user:2"), false)
+	m.AddMock("SCAN 3 user:* 100", page("0", "user:3"), false)
+	m.AddMock("SCAN 0 err:* 100", "failed", true)
+
+	rds := dummyrds.New(dummyrds.Config{MockingMap: m})
+	opts := ScanOptions{Match: "user:*", Count: 100}
+
+	var got []string
+	err := ScanAll(context.Background(), rds, opts, func(key string) error {
+		got = append(got, key)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user:1", "user:2", "user:3"}, got)
+
+	// stops on the first error of fn
+	stop := errors.New("stop")
+	got = nil
+	err = ScanAll(context.Background(), rds, opts, func(key string) error {
+		got = append(got, key)
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, []string{"user:1"}, got)
+
+	err = ScanAll(context.Background(), rds, ScanOptions{Match: "err:*", Count: 100}, func(string) error { return nil })
+	assert.EqualError(t, err, "failed")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = ScanAll(ctx, rds, opts, func(string) error { return nil })
+	assert.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,297 @@
+// Package jobqueue implements a delayed job queue with retries on redis sorted sets.
+//
+// A job is scheduled in a sorted set scored by the time it becomes due. Claiming
+// runs a script that picks the due jobs and pushes their score forward by the
+// visibility timeout in one step, so concurrent workers never claim the same job,
+// and a job whose worker crashed becomes due again once the timeout expires.
+// A failed job is rescheduled with an exponential backoff, and moved to the
+// dead-letter set once it was claimed MaxAttempts times.
+//
+//	q := jobqueue.New(rds, jobqueue.Config{Name: "mail"})
+//	q.EnqueueAfter(payload, 10*time.Minute)
+//
+//	w := jobqueue.NewWorker(q, jobqueue.WorkerConfig{Concurrency: 4}, send)
+//	err := w.Run(ctx)
+//
+// All keys of a queue share a hash tag, so the queue works on a sharded client.
+package jobqueue
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/5112100070/publib/convert"
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// KEYS schedule, jobs. ARGV id, payload, due
+const enqueueScript = `
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+redis.call('ZADD', KEYS[1], ARGV[3], ARGV[1])
+return 1`
+
+// KEYS schedule, jobs, attempts, dead. ARGV now, visibility deadline, limit, max attempts.
+// Replies id, payload and attempt of every claimed job
+const claimScript = `
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[3])
+local res = {}
+for _, id in ipairs(ids) do
+	local attempt = redis.call('HINCRBY', KEYS[3], id, 1)
+	if attempt > tonumber(ARGV[4]) then
+		redis.call('ZREM', KEYS[1], id)
+		redis.call('ZADD', KEYS[4], ARGV[1], id)
+	else
+		redis.call('ZADD', KEYS[1], ARGV[2], id)
+		table.insert(res, id)
+		table.insert(res, redis.call('HGET', KEYS[2], id) or '')
+		table.insert(res, tostring(attempt))
+	end
+end
+return res`
+
+// KEYS schedule, jobs, attempts. ARGV id, attempt
+const ackScript = `
+if redis.call('HGET', KEYS[3], ARGV[1]) ~= ARGV[2] then return 0 end
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HDEL', KEYS[2], ARGV[1])
+redis.call('HDEL', KEYS[3], ARGV[1])
+return 1`
+
+// KEYS schedule, attempts, dead. ARGV id, attempt, retry at, max attempts, now.
+// Replies 0 when the lease is lost, 1 when rescheduled and 2 when dead
+const failScript = `
+if redis.call('HGET', KEYS[2], ARGV[1]) ~= ARGV[2] then return 0 end
+if tonumber(ARGV[2]) >= tonumber(ARGV[4]) then
+	redis.call('ZREM', KEYS[1], ARGV[1])
+	redis.call('ZADD', KEYS[3], ARGV[5], ARGV[1])
+	return 2
+end
+redis.call('ZADD', KEYS[1], ARGV[3], ARGV[1])
+return 1`
+
+// KEYS schedule, attempts, dead. ARGV id, now
+const requeueScript = `
+if redis.call('ZREM', KEYS[3], ARGV[1]) == 0 then return 0 end
+redis.call('HDEL', KEYS[2], ARGV[1])
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+return 1`
+
+// KEYS jobs, attempts, dead. ARGV id
+const deleteScript = `
+if redis.call('ZREM', KEYS[3], ARGV[1]) == 0 then return 0 end
+redis.call('HDEL', KEYS[1], ARGV[1])
+redis.call('HDEL', KEYS[2], ARGV[1])
+return 1`
+
+// New job queue
+func New(rds redis.Redis, config Config) *Queue {
+
+	// Set default one minute visibility timeout
+	if config.VisibilityTimeout <= 0 {
+		config.VisibilityTimeout = time.Minute
+	}
+
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 5
+	}
+
+	// Set default backoff from one second up to one hour
+	if config.Backoff <= 0 {
+		config.Backoff = time.Second
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = time.Hour
+	}
+
+	prefix := "{" + config.Name + "}:"
+	return &Queue{
+		rds:        rds,
+		schedule:   prefix + "schedule",
+		jobs:       prefix + "jobs",
+		attempts:   prefix + "attempts",
+		dead:       prefix + "dead",
+		visibility: config.VisibilityTimeout,
+		maxAttempt: config.MaxAttempts,
+		backoff:    config.Backoff,
+		maxBackoff: config.MaxBackoff,
+	}
+}
+
+// EnqueueAt schedules payload to be due at, returns the ID of the job
+func (q *Queue) EnqueueAt(payload string, at time.Time) (string, error) {
+	id, err := newID()
+	if err != nil {
+		return "", err
+	}
+
+	res := q.rds.Eval(enqueueScript, []string{q.schedule, q.jobs}, id, payload, millis(at))
+	if res.Error != nil {
+		return "", res.Error
+	}
+
+	return id, nil
+}
+
+// EnqueueAfter schedules payload to be due after delay, returns the ID of the job
+func (q *Queue) EnqueueAfter(payload string, delay time.Duration) (string, error) {
+	return q.EnqueueAt(payload, time.Now().Add(delay))
+}
+
+// Claim returns up to limit due jobs, oldest first. Each job must be acknowledged
+// with Ack or Fail within the visibility timeout, otherwise it is claimed again
+func (q *Queue) Claim(limit int) ([]Job, error) {
+	now := time.Now()
+	keys := []string{q.schedule, q.jobs, q.attempts, q.dead}
+	res := q.rds.Eval(claimScript, keys, millis(now), millis(now.Add(q.visibility)), limit, q.maxAttempt)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	values := res.StringSlice()
+	jobs := make([]Job, 0, len(values)/3)
+	for i := 0; i+2 < len(values); i += 3 {
+		jobs = append(jobs, Job{
+			ID:      values[i],
+			Payload: values[i+1],
+			Attempt: convert.ToInt(values[i+2]),
+		})
+	}
+
+	return jobs, nil
+}
+
+// Ack removes a processed job, ErrLeaseLost when it was claimed again in the meantime
+func (q *Queue) Ack(job Job) error {
+	res := q.rds.Eval(ackScript, []string{q.schedule, q.jobs, q.attempts}, job.ID, job.Attempt)
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.Int() == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+// Fail reschedules a job after its backoff, or moves it to the dead-letter set when it
+// reached the max attempts. ErrLeaseLost is returned when it was claimed again in the meantime
+func (q *Queue) Fail(job Job) (dead bool, err error) {
+	now := time.Now()
+	retryAt := now.Add(q.Backoff(job.Attempt))
+	keys := []string{q.schedule, q.attempts, q.dead}
+	res := q.rds.Eval(failScript, keys, job.ID, job.Attempt, millis(retryAt), q.maxAttempt, millis(now))
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	switch res.Int() {
+	case 0:
+		return false, ErrLeaseLost
+	case 2:
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// Backoff returns the retry delay after the failure of attempt
+func (q *Queue) Backoff(attempt int) time.Duration {
+	delay := q.backoff
+	for i := 1; i < attempt && delay < q.maxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > q.maxBackoff {
+		return q.maxBackoff
+	}
+
+	return delay
+}
+
+// Dead returns up to limit jobs of the dead-letter set, oldest first. Their
+// attempt is the number of times they were claimed
+func (q *Queue) Dead(limit int) ([]Job, error) {
+	res := q.rds.ZRange(q.dead, 0, limit-1)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	ids := res.StringSlice()
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	payloads := q.rds.HMGet(q.jobs, ids...)
+	if payloads.Error != nil {
+		return nil, payloads.Error
+	}
+
+	attempts := q.rds.HMGet(q.attempts, ids...)
+	if attempts.Error != nil {
+		return nil, attempts.Error
+	}
+
+	values, counts := payloads.StringSlice(), attempts.StringSlice()
+	jobs := make([]Job, len(ids))
+	for i, id := range ids {
+		jobs[i] = Job{ID: id}
+		if i < len(values) {
+			jobs[i].Payload = values[i]
+		}
+		if i < len(counts) {
+			jobs[i].Attempt = convert.ToInt(counts[i])
+		}
+	}
+
+	return jobs, nil
+}
+
+// Requeue schedules a dead job to be due now with its attempts reset,
+// ErrNotDead when it is not in the dead-letter set
+func (q *Queue) Requeue(id string) error {
+	res := q.rds.Eval(requeueScript, []string{q.schedule, q.attempts, q.dead}, id, millis(time.Now()))
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.Int() == 0 {
+		return ErrNotDead
+	}
+
+	return nil
+}
+
+// Delete removes a dead job with its payload and attempts, other jobs are left as is
+func (q *Queue) Delete(id string) error {
+	return q.rds.Eval(deleteScript, []string{q.jobs, q.attempts, q.dead}, id).Error
+}
+
+// Len returns the number of scheduled jobs, including the claimed ones
+func (q *Queue) Len() (int, error) {
+	res := q.rds.ZCard(q.schedule)
+	return res.Int(), res.Error
+}
+
+// DeadLen returns the number of jobs in the dead-letter set
+func (q *Queue) DeadLen() (int, error) {
+	res := q.rds.ZCard(q.dead)
+	return res.Int(), res.Error
+}
+
+// newID returns a random job ID
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("jobqueue: generate id: %v", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// millis converts t to the millisecond schedule score
+func millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,233 @@
+package jobqueue_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis/dummyrds"
+	. "github.com/5112100070/publib/storage/redis/jobqueue"
+	"github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/5112100070/publib/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueue(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	q := New(rds, Config{Name: redistest.Key(t, "jobs")})
+
+	a, err := q.EnqueueAfter("a", 0)
+	require.NoError(t, err)
+	_, err = q.EnqueueAfter("later", time.Hour)
+	require.NoError(t, err)
+	b, err := q.EnqueueAt("b", time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+
+	n, err := q.Len()
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	// due jobs only, the oldest first
+	jobs, err := q.Claim(10)
+	require.NoError(t, err)
+	assert.Equal(t, []Job{{ID: b, Payload: "b", Attempt: 1}, {ID: a, Payload: "a", Attempt: 1}}, jobs)
+
+	jobs2, err := q.Claim(10)
+	require.NoError(t, err)
+	assert.Empty(t, jobs2, "claimed jobs are not due until their visibility timeout")
+
+	require.NoError(t, q.Ack(jobs[0]))
+	require.NoError(t, q.Ack(jobs[1]))
+	assert.Equal(t, ErrLeaseLost, q.Ack(jobs[1]))
+
+	n, err = q.Len()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestQueue_ConcurrentClaim(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	q := New(rds, Config{Name: redistest.Key(t, "jobs")})
+
+	for i := 0; i < 50; i++ {
+		_, err := q.EnqueueAfter("job", 0)
+		require.NoError(t, err)
+	}
+
+	var mu sync.Mutex
+	claimed := map[string]int{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				jobs, err := q.Claim(3)
+				if !assert.NoError(t, err) || len(jobs) == 0 {
+					return
+				}
+
+				mu.Lock()
+				for _, job := range jobs {
+					claimed[job.ID]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, claimed, 50)
+	for id, n := range claimed {
+		assert.Equal(t, 1, n, id)
+	}
+}
+
+func TestQueue_VisibilityTimeout(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	q := New(rds, Config{
+		Name:              redistest.Key(t, "jobs"),
+		VisibilityTimeout: 100 * time.Millisecond,
+	})
+
+	_, err := q.EnqueueAfter("a", 0)
+	require.NoError(t, err)
+
+	first, err := q.Claim(1)
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+
+	// the worker crashed, the job is claimed again
+	time.Sleep(150 * time.Millisecond)
+	second, err := q.Claim(1)
+	require.NoError(t, err)
+	require.Len(t, second, 1)
+	assert.Equal(t, first[0].ID, second[0].ID)
+	assert.Equal(t, 2, second[0].Attempt)
+
+	assert.Equal(t, ErrLeaseLost, q.Ack(first[0]))
+	_, err = q.Fail(first[0])
+	assert.Equal(t, ErrLeaseLost, err)
+	assert.NoError(t, q.Ack(second[0]))
+}
+
+func TestQueue_Retry(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	q := New(rds, Config{
+		Name:        redistest.Key(t, "jobs"),
+		MaxAttempts: 2,
+		Backoff:     100 * time.Millisecond,
+	})
+
+	id, err := q.EnqueueAfter("a", 0)
+	require.NoError(t, err)
+
+	jobs, err := q.Claim(1)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	dead, err := q.Fail(jobs[0])
+	require.NoError(t, err)
+	assert.False(t, dead)
+
+	// not due before the backoff
+	jobs, err = q.Claim(1)
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+
+	time.Sleep(150 * time.Millisecond)
+	jobs, err = q.Claim(1)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, 2, jobs[0].Attempt)
+
+	dead, err = q.Fail(jobs[0])
+	require.NoError(t, err)
+	assert.True(t, dead)
+
+	n, err := q.Len()
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	deadJobs, err := q.Dead(10)
+	require.NoError(t, err)
+	assert.Equal(t, []Job{{ID: id, Payload: "a", Attempt: 2}}, deadJobs)
+
+	// requeued with its attempts reset
+	require.NoError(t, q.Requeue(id))
+	assert.Equal(t, ErrNotDead, q.Requeue(id))
+
+	jobs, err = q.Claim(1)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, Job{ID: id, Payload: "a", Attempt: 1}, jobs[0])
+}
+
+func TestQueue_ExpiredMaxAttempts(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	name := redistest.Key(t, "jobs")
+	q := New(rds, Config{
+		Name:              name,
+		VisibilityTimeout: 50 * time.Millisecond,
+		MaxAttempts:       1,
+	})
+
+	id, err := q.EnqueueAfter("a", 0)
+	require.NoError(t, err)
+
+	jobs, err := q.Claim(1)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+
+	// a job whose workers keep crashing ends up dead too
+	time.Sleep(100 * time.Millisecond)
+	jobs, err = q.Claim(1)
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+
+	n, err := q.DeadLen()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	require.NoError(t, q.Delete(id))
+	deadJobs, err := q.Dead(10)
+	require.NoError(t, err)
+	assert.Empty(t, deadJobs)
+
+	// nothing of the job is left
+	assert.Zero(t, rds.HLen("{"+name+"}:jobs").Int())
+	assert.Zero(t, rds.HLen("{"+name+"}:attempts").Int())
+}
+
+func TestQueue_Backoff(t *testing.T) {
+	q := New(dummyrds.New(dummyrds.Config{}), Config{
+		Backoff:    time.Second,
+		MaxBackoff: 10 * time.Second,
+	})
+
+	assert.Equal(t, time.Second, q.Backoff(1))
+	assert.Equal(t, 2*time.Second, q.Backoff(2))
+	assert.Equal(t, 8*time.Second, q.Backoff(4))
+	assert.Equal(t, 10*time.Second, q.Backoff(5))
+	assert.Equal(t, 10*time.Second, q.Backoff(100))
+}
+
+func TestQueue_Error(t *testing.T) {
+	m := dummyrds.Mocker{}
+	m.AddMock("ZCARD {foo}:schedule", "failed", true)
+	m.AddMock("ZRANGE {foo}:dead 0 9", "failed", true)
+
+	q := New(dummyrds.New(dummyrds.Config{MockingMap: m}), Config{Name: "foo"})
+
+	_, err := q.Len()
+	assert.EqualError(t, err, "failed")
+
+	_, err = q.Dead(10)
+	assert.EqualError(t, err, "failed")
+
+	_, err = q.Claim(1)
+	assert.Error(t, err)
+	_, err = q.EnqueueAfter("a", 0)
+	assert.Error(t, err)
+}
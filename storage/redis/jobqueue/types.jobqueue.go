@@ -0,0 +1,85 @@
+package jobqueue
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Error list
+var (
+	// ErrLeaseLost is returned by Ack and Fail when the visibility timeout of the job
+	// expired and it was claimed again, the job is left to its new owner
+	ErrLeaseLost = errors.New("jobqueue: job was claimed again after its visibility timeout")
+	// ErrNotDead is returned by Requeue when the job is not in the dead-letter set
+	ErrNotDead = errors.New("jobqueue: job is not dead")
+	// ErrShutdownTimeout is returned by Worker.Run when the running jobs did not
+	// finish within the shutdown timeout, their contexts are cancelled
+	ErrShutdownTimeout = errors.New("jobqueue: shutdown timeout, running jobs were cancelled")
+)
+
+// Config of a job queue
+type Config struct {
+	// Name of the queue, the keys are derived from it and share its hash tag
+	Name string
+	// VisibilityTimeout is how long a claimed job may run before it is claimed
+	// again, default is one minute
+	VisibilityTimeout time.Duration
+	// MaxAttempts is the number of claims after which a failed job is moved to
+	// the dead-letter set, default is 5
+	MaxAttempts int
+	// Backoff is the retry delay after the first failure, doubled after every
+	// following failure up to MaxBackoff. Default is one second and one hour
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// Job is a unit of work claimed from the queue
+type Job struct {
+	ID      string
+	Payload string
+	// Attempt is 1 on the first claim
+	Attempt int
+}
+
+// Queue of delayed jobs backed by a schedule sorted set, scored by the time a job
+// becomes due, a hash of payloads, a hash of attempts and a dead-letter sorted set
+type Queue struct {
+	rds        redis.Redis
+	schedule   string
+	jobs       string
+	attempts   string
+	dead       string
+	visibility time.Duration
+	maxAttempt int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+// Handler processes a job, the job is retried when it returns an error or panics
+type Handler func(ctx context.Context, job Job) error
+
+// WorkerConfig of a worker pool
+type WorkerConfig struct {
+	// Concurrency is the number of jobs processed at once, default is 1
+	Concurrency int
+	// PollInterval is how long an idle worker waits before claiming again, default is one second
+	PollInterval time.Duration
+	// ShutdownTimeout is how long Run waits for the running jobs once its context is done,
+	// default is 30 seconds
+	ShutdownTimeout time.Duration
+	// OnError is called with the errors of claiming, acknowledging and failing jobs
+	OnError func(err error)
+}
+
+// Worker pool claiming and processing jobs
+type Worker struct {
+	queue           *Queue
+	handler         Handler
+	concurrency     int
+	pollInterval    time.Duration
+	shutdownTimeout time.Duration
+	onError         func(err error)
+}
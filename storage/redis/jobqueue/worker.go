@@ -0,0 +1,123 @@
+package jobqueue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// NewWorker returns a pool of workers processing the jobs of queue with handler
+func NewWorker(queue *Queue, config WorkerConfig, handler Handler) *Worker {
+
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+
+	// Set default one second poll interval
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Second
+	}
+
+	// Set default 30 seconds shutdown timeout
+	if config.ShutdownTimeout <= 0 {
+		config.ShutdownTimeout = 30 * time.Second
+	}
+
+	if config.OnError == nil {
+		config.OnError = func(error) {}
+	}
+
+	return &Worker{
+		queue:           queue,
+		handler:         handler,
+		concurrency:     config.Concurrency,
+		pollInterval:    config.PollInterval,
+		shutdownTimeout: config.ShutdownTimeout,
+		onError:         config.OnError,
+	}
+}
+
+// Run processes jobs until ctx is done, then stops claiming and waits for the running
+// jobs to finish. When they do not finish within the shutdown timeout their contexts are
+// cancelled and ErrShutdownTimeout is returned right away, without waiting for handlers
+// ignoring their context. Unfinished jobs are claimed again after their visibility timeout
+func (w *Worker) Run(ctx context.Context) error {
+
+	// Running jobs outlive ctx until the shutdown timeout
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx, jobCtx)
+		}()
+	}
+
+	<-ctx.Done()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(w.shutdownTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return nil
+	case <-timer.C:
+		cancel()
+		return ErrShutdownTimeout
+	}
+}
+
+// loop claims and processes one job at a time until ctx is done
+func (w *Worker) loop(ctx, jobCtx context.Context) {
+	for ctx.Err() == nil {
+		jobs, err := w.queue.Claim(1)
+		if err != nil {
+			w.onError(err)
+		}
+
+		if len(jobs) == 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(w.pollInterval):
+			}
+			continue
+		}
+
+		w.process(jobCtx, jobs[0])
+	}
+}
+
+// process runs the handler, then acknowledges the job or fails it on error
+func (w *Worker) process(ctx context.Context, job Job) {
+	if err := w.handle(ctx, job); err != nil {
+		if _, err := w.queue.Fail(job); err != nil {
+			w.onError(err)
+		}
+		return
+	}
+
+	if err := w.queue.Ack(job); err != nil {
+		w.onError(err)
+	}
+}
+
+// handle runs the handler, a panic is returned as an error
+func (w *Worker) handle(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("jobqueue: job %s panicked: %v", job.ID, r)
+		}
+	}()
+
+	return w.handler(ctx, job)
+}
@@ -0,0 +1,162 @@
+package jobqueue_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/5112100070/publib/storage/redis/jobqueue"
+	"github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/5112100070/publib/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorker(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	q := New(rds, Config{
+		Name:    redistest.Key(t, "jobs"),
+		Backoff: 10 * time.Millisecond,
+	})
+
+	for _, payload := range []string{"a", "b", "fail", "panic"} {
+		_, err := q.EnqueueAfter(payload, 0)
+		require.NoError(t, err)
+	}
+
+	var mu sync.Mutex
+	done := map[string]int{}
+	var failed, panicked int32
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := NewWorker(q, WorkerConfig{Concurrency: 3, PollInterval: 10 * time.Millisecond}, func(ctx context.Context, job Job) error {
+		switch {
+		case job.Payload == "fail" && atomic.AddInt32(&failed, 1) == 1:
+			return errors.New("failed")
+		case job.Payload == "panic" && atomic.AddInt32(&panicked, 1) == 1:
+			panic("boom")
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		done[job.Payload] = job.Attempt
+		if len(done) == 4 {
+			cancel()
+		}
+		return nil
+	})
+
+	errc := make(chan error, 1)
+	go func() { errc <- w.Run(ctx) }()
+
+	select {
+	case err := <-errc:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("jobs were not processed")
+	}
+
+	// failed jobs are retried
+	assert.Equal(t, map[string]int{"a": 1, "b": 1, "fail": 2, "panic": 2}, done)
+
+	n, err := q.Len()
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestWorker_GracefulShutdown(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	q := New(rds, Config{Name: redistest.Key(t, "jobs")})
+
+	_, err := q.EnqueueAfter("slow", 0)
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	w := NewWorker(q, WorkerConfig{PollInterval: 10 * time.Millisecond}, func(ctx context.Context, job Job) error {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		return ctx.Err()
+	})
+
+	errc := make(chan error, 1)
+	go func() { errc <- w.Run(ctx) }()
+
+	<-started
+	cancel()
+
+	// the running job finishes and is acknowledged
+	require.NoError(t, <-errc)
+	n, err := q.Len()
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestWorker_ShutdownTimeout(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	q := New(rds, Config{Name: redistest.Key(t, "jobs")})
+
+	_, err := q.EnqueueAfter("stuck", 0)
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	w := NewWorker(q, WorkerConfig{
+		PollInterval:    10 * time.Millisecond,
+		ShutdownTimeout: 50 * time.Millisecond,
+	}, func(ctx context.Context, job Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	errc := make(chan error, 1)
+	go func() { errc <- w.Run(ctx) }()
+
+	<-started
+	cancel()
+
+	assert.Equal(t, ErrShutdownTimeout, <-errc)
+
+	// the cancelled job is rescheduled
+	n, err := q.Len()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestWorker_ShutdownTimeout_IgnoredContext(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	q := New(rds, Config{Name: redistest.Key(t, "jobs")})
+
+	_, err := q.EnqueueAfter("stuck", 0)
+	require.NoError(t, err)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := NewWorker(q, WorkerConfig{
+		PollInterval:    10 * time.Millisecond,
+		ShutdownTimeout: 50 * time.Millisecond,
+	}, func(ctx context.Context, job Job) error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	errc := make(chan error, 1)
+	go func() { errc <- w.Run(ctx) }()
+
+	<-started
+	cancel()
+
+	select {
+	case err := <-errc:
+		assert.Equal(t, ErrShutdownTimeout, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the shutdown timeout")
+	}
+}
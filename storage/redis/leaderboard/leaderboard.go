@@ -0,0 +1,242 @@
+// Package leaderboard ranks members by score on redis sorted sets, over all
+// time and over daily, weekly and monthly periods.
+//
+// Every increment updates the all-time board and the daily bucket of the board.
+// Weekly and monthly boards are merged from the daily buckets with ZUNIONSTORE
+// and reused for the merge interval, so increments never write more than two keys.
+//
+//	lb := leaderboard.New(rds, leaderboard.Config{Prefix: "lb:"})
+//	lb.Incr("top-sellers", shopID, 1)
+//	top, err := lb.Board("top-sellers", leaderboard.Weekly, time.Now()).Top(10)
+package leaderboard
+
+import (
+	"time"
+
+	"github.com/5112100070/publib/convert"
+	"github.com/5112100070/publib/storage/redis"
+)
+
+const dayFormat = "20060102"
+
+// KEYS destination, daily buckets. ARGV merge interval in milliseconds.
+// The union expires with it, so a merged board is never kept without a TTL.
+// An empty union stores nothing, the next read merges again
+const mergeScript = `
+local n = redis.call('ZUNIONSTORE', KEYS[1], #KEYS - 1, unpack(KEYS, 2))
+if n > 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return n`
+
+// New leaderboard
+func New(rds redis.Redis, config Config) *Leaderboard {
+
+	// Set default 62 days retention, the current and the previous month
+	if config.Retention <= 0 {
+		config.Retention = 62 * 24 * time.Hour
+	}
+
+	// Set default one minute merge interval
+	if config.MergeInterval <= 0 {
+		config.MergeInterval = time.Minute
+	}
+
+	if config.Location == nil {
+		config.Location = convert.JakartaLocation
+	}
+
+	return &Leaderboard{
+		rds:           rds,
+		prefix:        config.Prefix,
+		retention:     int(config.Retention / time.Second),
+		mergeInterval: config.MergeInterval,
+		location:      config.Location,
+	}
+}
+
+// Incr adds by to the score of member on board name now, returns the new all-time score
+func (l *Leaderboard) Incr(name, member string, by float64) (float64, error) {
+	return l.IncrAt(name, member, by, time.Now())
+}
+
+// IncrAt adds by to the score of member on board name on the day of t, returns the new all-time score
+func (l *Leaderboard) IncrAt(name, member string, by float64, t time.Time) (float64, error) {
+	day := l.dayKey(name, t)
+	if err := l.rds.ZIncrBy(day, by, member).Error; err != nil {
+		return 0, err
+	}
+
+	if err := l.rds.Expire(day, l.retention); err != nil {
+		return 0, err
+	}
+
+	res := l.rds.ZIncrBy(l.key(name, "all"), by, member)
+	return res.Float64(), res.Error
+}
+
+// Board returns the ranking of board name over the period containing at, at is ignored by AllTime
+func (l *Leaderboard) Board(name string, period Period, at time.Time) Board {
+	return Board{
+		lb:     l,
+		name:   name,
+		period: period,
+		at:     at.In(l.location),
+	}
+}
+
+// Top returns the n highest ranked members, none when n is not positive
+func (b Board) Top(n int) ([]Entry, error) {
+	if n <= 0 {
+		return []Entry{}, nil
+	}
+
+	key, err := b.Key()
+	if err != nil {
+		return nil, err
+	}
+
+	return b.entries(key, 0, n-1)
+}
+
+// Rank returns the rank and score of member, ErrNotRanked when it has no score
+func (b Board) Rank(member string) (Entry, error) {
+	key, err := b.Key()
+	if err != nil {
+		return Entry{}, err
+	}
+
+	rank, err := b.rank(key, member)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	res := b.lb.rds.ZScore(key, member)
+	if res.Error != nil {
+		return Entry{}, res.Error
+	}
+
+	return Entry{Member: member, Score: res.Float64(), Rank: rank + 1}, nil
+}
+
+// Around returns member with up to n members ranked above and n below it,
+// ErrNotRanked when it has no score
+func (b Board) Around(member string, n int) ([]Entry, error) {
+	key, err := b.Key()
+	if err != nil {
+		return nil, err
+	}
+
+	rank, err := b.rank(key, member)
+	if err != nil {
+		return nil, err
+	}
+
+	start := rank - n
+	if start < 0 {
+		start = 0
+	}
+
+	return b.entries(key, start, rank+n)
+}
+
+// Len returns the number of ranked members
+func (b Board) Len() (int, error) {
+	key, err := b.Key()
+	if err != nil {
+		return 0, err
+	}
+
+	res := b.lb.rds.ZCard(key)
+	return res.Int(), res.Error
+}
+
+// Key returns the sorted set of the board, merging the weekly or monthly board
+// when it is missing or older than the merge interval
+func (b Board) Key() (string, error) {
+	l := b.lb
+	switch b.period {
+	case Daily:
+		return l.dayKey(b.name, b.at), nil
+	case Weekly:
+		// days since Monday
+		start := b.at.AddDate(0, 0, -(int(b.at.Weekday())+6)%7)
+		return l.merge(l.key(b.name, "w:"+start.Format(dayFormat)), b.name, start, 7)
+	case Monthly:
+		start := b.at.AddDate(0, 0, 1-b.at.Day())
+		days := start.AddDate(0, 1, -1).Day()
+		return l.merge(l.key(b.name, "m:"+start.Format("200601")), b.name, start, days)
+	}
+
+	return l.key(b.name, "all"), nil
+}
+
+// rank returns the zero based rank of member from the highest score
+func (b Board) rank(key, member string) (int, error) {
+	res := b.lb.rds.ZRevRank(key, member)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	if res.Value == nil {
+		return 0, ErrNotRanked
+	}
+
+	return res.Int(), nil
+}
+
+// entries returns the members ranked from start to stop, zero based
+func (b Board) entries(key string, start, stop int) ([]Entry, error) {
+	res := b.lb.rds.ZRevRangeWithScores(key, start, stop)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	zs := res.ZSlice()
+	entries := make([]Entry, len(zs))
+	for i, z := range zs {
+		entries[i] = Entry{
+			Member: convert.ToString(z.Member),
+			Score:  z.Score,
+			Rank:   start + i + 1,
+		}
+	}
+
+	return entries, nil
+}
+
+// merge stores the union of days daily buckets from start in destination, unless
+// it was merged within the merge interval
+func (l *Leaderboard) merge(destination, name string, start time.Time, days int) (string, error) {
+	res := l.rds.Exists(destination)
+	if res.Error != nil {
+		return "", res.Error
+	}
+
+	if res.Bool() {
+		return destination, nil
+	}
+
+	keys := make([]string, days+1)
+	keys[0] = destination
+	for i := 1; i < len(keys); i++ {
+		keys[i] = l.dayKey(name, start.AddDate(0, 0, i-1))
+	}
+
+	if res := l.rds.Eval(mergeScript, keys, redis.Milliseconds(l.mergeInterval)); res.Error != nil {
+		return "", res.Error
+	}
+
+	return destination, nil
+}
+
+// key returns the key of board name with suffix.
+// The name is a hash tag so every bucket of a board lives on the same shard
+func (l *Leaderboard) key(name, suffix string) string {
+	return l.prefix + "{" + name + "}:" + suffix
+}
+
+// dayKey returns the daily bucket of board name on the day of t
+func (l *Leaderboard) dayKey(name string, t time.Time) string {
+	return l.key(name, "d:"+t.In(l.location).Format(dayFormat))
+}
@@ -0,0 +1,160 @@
+package leaderboard_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis/dummyrds"
+	. "github.com/5112100070/publib/storage/redis/leaderboard"
+	"github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/5112100070/publib/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var wib = time.FixedZone("WIB", 7*60*60)
+
+func day(d, hour int) time.Time {
+	return time.Date(2026, 10, d, hour, 0, 0, 0, wib)
+}
+
+func TestLeaderboard(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	lb := New(rds, Config{Prefix: redistest.Key(t, "lb:")})
+
+	incr := func(member string, by float64, at time.Time) {
+		_, err := lb.IncrAt("sellers", member, by, at)
+		require.NoError(t, err)
+	}
+
+	incr("a", 5, day(11, 12)) // Sunday of the previous week
+	incr("a", 1, day(12, 9))
+	incr("b", 2, day(12, 10))
+	incr("b", 2, day(18, 23))
+	incr("c", 1, day(18, 23))
+	incr("a", 10, day(19, 8))
+	// Sunday in UTC, Monday in WIB
+	incr("d", 3, time.Date(2026, 10, 18, 17, 30, 0, 0, time.UTC))
+
+	score, err := lb.IncrAt("sellers", "c", 0, day(18, 23))
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), score, "the all-time score is returned")
+
+	top, err := lb.Board("sellers", Daily, day(19, 0)).Top(10)
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{{Member: "a", Score: 10, Rank: 1}, {Member: "d", Score: 3, Rank: 2}}, top)
+
+	top, err = lb.Board("sellers", Weekly, day(15, 0)).Top(10)
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{{Member: "b", Score: 4, Rank: 1}, {Member: "c", Score: 1, Rank: 2}, {Member: "a", Score: 1, Rank: 3}}, top)
+
+	top, err = lb.Board("sellers", Monthly, day(31, 0)).Top(2)
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{{Member: "a", Score: 16, Rank: 1}, {Member: "b", Score: 4, Rank: 2}}, top)
+
+	for _, n := range []int{0, -1} {
+		top, err = lb.Board("sellers", AllTime, time.Time{}).Top(n)
+		require.NoError(t, err)
+		assert.Empty(t, top, "top %d", n)
+	}
+
+	n, err := lb.Board("sellers", AllTime, time.Time{}).Len()
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+}
+
+func TestBoard_Rank(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	lb := New(rds, Config{Prefix: redistest.Key(t, "lb:")})
+
+	for i, member := range []string{"a", "b", "c", "d", "e", "f"} {
+		_, err := lb.Incr("searchers", member, float64(10-i))
+		require.NoError(t, err)
+	}
+	board := lb.Board("searchers", AllTime, time.Now())
+
+	entry, err := board.Rank("c")
+	require.NoError(t, err)
+	assert.Equal(t, Entry{Member: "c", Score: 8, Rank: 3}, entry)
+
+	around, err := board.Around("c", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{{Member: "b", Score: 9, Rank: 2}, {Member: "c", Score: 8, Rank: 3}, {Member: "d", Score: 7, Rank: 4}}, around)
+
+	// the window is cut at the top and the bottom
+	around, err = board.Around("a", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, members(around))
+	around, err = board.Around("f", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d", "e", "f"}, members(around))
+
+	_, err = board.Rank("none")
+	assert.Equal(t, ErrNotRanked, err)
+	_, err = board.Around("none", 1)
+	assert.Equal(t, ErrNotRanked, err)
+}
+
+func TestBoard_MergeInterval(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	lb := New(rds, Config{
+		Prefix:        redistest.Key(t, "lb:"),
+		MergeInterval: 100 * time.Millisecond,
+	})
+	board := lb.Board("sellers", Weekly, time.Now())
+
+	_, err := lb.Incr("sellers", "a", 1)
+	require.NoError(t, err)
+
+	entry, err := board.Rank("a")
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), entry.Score)
+
+	key, err := board.Key()
+	require.NoError(t, err)
+	ttl, err := rds.PTTL(key)
+	require.NoError(t, err, "the merged board expires")
+	assert.True(t, ttl > 0 && ttl <= 100*time.Millisecond, "ttl %v", ttl)
+
+	// the merged board is reused until the interval passes
+	_, err = lb.Incr("sellers", "a", 1)
+	require.NoError(t, err)
+	entry, err = board.Rank("a")
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), entry.Score)
+
+	time.Sleep(150 * time.Millisecond)
+	entry, err = board.Rank("a")
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), entry.Score)
+}
+
+func TestLeaderboard_Error(t *testing.T) {
+	m := dummyrds.Mocker{}
+	m.AddMock("ZINCRBY {foo}:d:20261019 1 a", "failed", true)
+	m.AddMock("ZREVRANGE {foo}:all 0 9 WITHSCORES", "failed", true)
+	m.AddMock("EXISTS {foo}:w:20261019", "failed", true)
+	m.AddMock("ZREVRANK {foo}:all a", "failed", true)
+
+	lb := New(dummyrds.New(dummyrds.Config{MockingMap: m}), Config{Location: wib})
+
+	_, err := lb.IncrAt("foo", "a", 1, day(19, 12))
+	assert.EqualError(t, err, "failed")
+
+	_, err = lb.Board("foo", AllTime, time.Now()).Top(10)
+	assert.EqualError(t, err, "failed")
+
+	_, err = lb.Board("foo", Weekly, day(21, 12)).Top(10)
+	assert.EqualError(t, err, "failed")
+
+	_, err = lb.Board("foo", AllTime, time.Now()).Rank("a")
+	assert.EqualError(t, err, "failed")
+}
+
+func members(entries []Entry) []string {
+	res := make([]string, len(entries))
+	for i, e := range entries {
+		res[i] = e.Member
+	}
+	return res
+}
@@ -0,0 +1,65 @@
+package leaderboard
+
+import (
+	"errors"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Error list
+var (
+	// ErrNotRanked is returned by Rank and Around when the member has no score on the board
+	ErrNotRanked = errors.New("leaderboard: member is not ranked")
+)
+
+// Period of a board
+type Period int
+
+// Periods of a board, the days, weeks and months split in the configured location
+const (
+	AllTime Period = iota
+	Daily
+	// Weekly starts on Monday
+	Weekly
+	Monthly
+)
+
+// Config of a leaderboard
+type Config struct {
+	// Prefix of the board keys
+	Prefix string
+	// Retention is how long a daily bucket is kept, it should cover the oldest
+	// week or month read. Default is 62 days
+	Retention time.Duration
+	// MergeInterval is how long a merged weekly or monthly board is read before
+	// it is merged again from the daily buckets, default is one minute
+	MergeInterval time.Duration
+	// Location splits the days, default is WIB (Asia/Jakarta)
+	Location *time.Location
+}
+
+// Leaderboard keeps an all-time sorted set and a daily bucket per board name,
+// weekly and monthly boards are merged from the daily buckets
+type Leaderboard struct {
+	rds           redis.Redis
+	prefix        string
+	retention     int
+	mergeInterval time.Duration
+	location      *time.Location
+}
+
+// Board is the ranking of a board name over one period, get it with Leaderboard.Board
+type Board struct {
+	lb     *Leaderboard
+	name   string
+	period Period
+	at     time.Time
+}
+
+// Entry is a ranked member, the highest score is rank 1
+type Entry struct {
+	Member string
+	Score  float64
+	Rank   int
+}
@@ -0,0 +1,189 @@
+// Package migrate copies keys from one redis to another with DUMP and RESTORE,
+// keeping the value type and the remaining time to live of every key.
+//
+// The source is walked with SCAN, so keys written during the migration may or
+// may not be copied, and a key may be scanned twice. A stopped migration is
+// resumed from the cursor of its last progress.
+//
+//	progress, err := migrate.Migrate(ctx, source, target, migrate.Config{
+//		Scan: redis.ScanOptions{Match: "user:*", Count: 1000},
+//		Rate: 5000,
+//		OnProgress: func(p migrate.Progress) { log.Println(p.Cursor, p.Copied) },
+//	})
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Migrate copies the keys selected by config from source to target until the keyspace
+// is exhausted, ctx is done or a command fails. The returned progress holds the cursor
+// to resume from
+func Migrate(ctx context.Context, source, target redis.Redis, config Config) (Progress, error) {
+
+	// Set default 100 keys per page
+	if config.Scan.Count <= 0 {
+		config.Scan.Count = 100
+	}
+
+	m := &migration{
+		source: source,
+		target: target,
+		config: config,
+		start:  time.Now(),
+	}
+	if config.Rate > 0 {
+		m.interval = time.Second / time.Duration(config.Rate)
+	}
+
+	m.progress.Cursor = config.Cursor
+	err := m.run(ctx)
+	m.progress.Elapsed = time.Since(m.start)
+
+	return m.progress, err
+}
+
+// migration is the state of a running Migrate
+type migration struct {
+	source   redis.Redis
+	target   redis.Redis
+	config   Config
+	progress Progress
+
+	start    time.Time
+	interval time.Duration
+	next     time.Time
+}
+
+func (m *migration) run(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		next, keys, err := m.scan()
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if err := m.wait(ctx); err != nil {
+				return err
+			}
+
+			if err := m.copy(key); err != nil {
+				return fmt.Errorf("migrate: %s: %w", key, err)
+			}
+		}
+
+		m.progress.Cursor = next
+		m.progress.Done = next == 0
+		m.progress.Elapsed = time.Since(m.start)
+		if m.config.OnProgress != nil {
+			m.config.OnProgress(m.progress)
+		}
+
+		if m.progress.Done {
+			return nil
+		}
+	}
+}
+
+// scan requests the page of the current cursor
+func (m *migration) scan() (int, []string, error) {
+	opts := m.config.Scan
+	if opts.Type != "" {
+		return m.source.ScanType(m.progress.Cursor, opts.Match, opts.Count, opts.Type).ScanResult()
+	}
+	return m.source.Scan(m.progress.Cursor, opts.Match, opts.Count).ScanResult()
+}
+
+// wait blocks until the rate limit allows the next key
+func (m *migration) wait(ctx context.Context) error {
+	if m.interval == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	if m.next.Before(now) {
+		m.next = now
+	}
+
+	if delay := m.next.Sub(now); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	m.next = m.next.Add(m.interval)
+
+	return nil
+}
+
+// copy copies a single key with its remaining time to live
+func (m *migration) copy(key string) error {
+	m.progress.Scanned++
+
+	// The ttl is read after DUMP, so the copy never outlives the source key
+	var value []byte
+	if !m.config.DryRun {
+		res := m.source.Dump(key)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.Value == nil {
+			m.progress.Expired++
+			return nil
+		}
+		value = res.Bytes()
+	}
+
+	ttl, err := m.source.PTTL(key)
+	switch {
+	case err == redis.ErrNoExpire:
+		ttl = 0
+	case err == redis.ErrKeyNotExist:
+		m.progress.Expired++
+		return nil
+	case err != nil:
+		return err
+	case ttl <= 0:
+		// Expires right now, restoring it with ttl zero would make it persistent
+		m.progress.Expired++
+		return nil
+	}
+
+	if m.config.DryRun {
+		if !m.config.Replace {
+			res := m.target.Exists(key)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.Int() > 0 {
+				m.progress.Existing++
+				return nil
+			}
+		}
+		m.progress.Copied++
+		return nil
+	}
+
+	switch err := m.target.Restore(key, ttl, value, m.config.Replace); err {
+	case nil:
+		m.progress.Copied++
+	case redis.ErrKeyExists:
+		m.progress.Existing++
+	default:
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package migrate_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+	"github.com/5112100070/publib/storage/redis/dummyrds"
+	. "github.com/5112100070/publib/storage/redis/migrate"
+	"github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/5112100070/publib/storage/redis/redistest"
+	"github.com/5112100070/publib/storage/redis/sharded"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newServer(t *testing.T) redis.Redis {
+	return redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+}
+
+func TestMigrate(t *testing.T) {
+	source, target := newServer(t), newServer(t)
+	user, other := redistest.Key(t, "user:"), redistest.Key(t, "other")
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, source.Set(user+strconv.Itoa(i), i).Error)
+	}
+	require.NoError(t, source.PSetEx(user+"ttl", time.Minute, "ttl"))
+	require.NoError(t, source.Set(other, "other").Error)
+	require.NoError(t, source.Set(user+"0", "new").Error)
+	require.NoError(t, target.Set(user+"0", "old").Error)
+
+	var pages []Progress
+	config := Config{
+		Scan:       redis.ScanOptions{Match: user + "*"},
+		OnProgress: func(p Progress) { pages = append(pages, p) },
+	}
+	progress, err := Migrate(context.Background(), source, target, config)
+	require.NoError(t, err)
+	assert.True(t, progress.Done)
+	assert.Zero(t, progress.Cursor)
+	assert.Equal(t, 6, progress.Scanned)
+	assert.Equal(t, 5, progress.Copied)
+	assert.Equal(t, 1, progress.Existing)
+	assert.Equal(t, progress.Scanned, pages[len(pages)-1].Scanned)
+
+	assert.Equal(t, "old", target.Get(user+"0").String(), "existing keys are left untouched")
+	assert.Equal(t, "4", target.Get(user+"4").String())
+	assert.Equal(t, 0, target.Exists(other).Int())
+	ttl, err := target.PTTL(user + "ttl")
+	require.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(5*time.Second))
+	_, err = target.PTTL(user + "1")
+	assert.Equal(t, redis.ErrNoExpire, err)
+
+	config.Replace = true
+	progress, err = Migrate(context.Background(), source, target, config)
+	require.NoError(t, err)
+	assert.Equal(t, 6, progress.Copied)
+	assert.Equal(t, "new", target.Get(user+"0").String())
+}
+
+func TestMigrate_DryRun(t *testing.T) {
+	source, target := newServer(t), newServer(t)
+	foo, bar := redistest.Key(t, "foo"), redistest.Key(t, "bar")
+	require.NoError(t, source.Set(foo, "1").Error)
+	require.NoError(t, source.Set(bar, "1").Error)
+	require.NoError(t, target.Set(bar, "2").Error)
+
+	config := Config{Scan: redis.ScanOptions{Match: redistest.Key(t, "*")}, DryRun: true}
+	progress, err := Migrate(context.Background(), source, target, config)
+	require.NoError(t, err)
+	assert.Equal(t, 1, progress.Copied)
+	assert.Equal(t, 1, progress.Existing)
+	assert.Equal(t, 0, target.Exists(foo).Int())
+
+	config.Replace = true
+	progress, err = Migrate(context.Background(), source, target, config)
+	require.NoError(t, err)
+	assert.Equal(t, 2, progress.Copied)
+	assert.Equal(t, "2", target.Get(bar).String())
+}
+
+func TestMigrate_Resume(t *testing.T) {
+	// Every shard is scanned in its own pages
+	addrs := []string{redistest.StartServer(t), redistest.StartServer(t), redistest.StartServer(t)}
+	source := sharded.New(redigo.Config{Endpoint: addrs[0]}, redigo.Config{Endpoint: addrs[1]}, redigo.Config{Endpoint: addrs[2]})
+	target := newServer(t)
+
+	prefix := redistest.Key(t, "")
+	for i := 0; i < 30; i++ {
+		require.NoError(t, source.Set(prefix+strconv.Itoa(i), i).Error)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	config := Config{
+		Scan:       redis.ScanOptions{Match: prefix + "*"},
+		OnProgress: func(Progress) { cancel() },
+	}
+	first, err := Migrate(ctx, source, target, config)
+	assert.Equal(t, context.Canceled, err)
+	assert.False(t, first.Done)
+	assert.NotZero(t, first.Cursor)
+	require.NotZero(t, first.Copied)
+	require.Less(t, first.Copied, 30)
+
+	config.Cursor, config.OnProgress = first.Cursor, nil
+	rest, err := Migrate(context.Background(), source, target, config)
+	require.NoError(t, err)
+	assert.True(t, rest.Done)
+	assert.Equal(t, 30, first.Copied+rest.Copied)
+	assert.Zero(t, rest.Existing)
+
+	for i := 0; i < 30; i++ {
+		assert.Equal(t, strconv.Itoa(i), target.Get(prefix+strconv.Itoa(i)).String())
+	}
+}
+
+func TestMigrate_Rate(t *testing.T) {
+	source, target := newServer(t), newServer(t)
+	prefix := redistest.Key(t, "")
+	for i := 0; i < 10; i++ {
+		require.NoError(t, source.Set(prefix+strconv.Itoa(i), i).Error)
+	}
+
+	progress, err := Migrate(context.Background(), source, target, Config{
+		Scan: redis.ScanOptions{Match: prefix + "*"},
+		Rate: 50,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 10, progress.Copied)
+	assert.GreaterOrEqual(t, progress.Elapsed, 180*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	progress, err = Migrate(ctx, source, target, Config{
+		Scan:    redis.ScanOptions{Match: prefix + "*"},
+		Rate:    1,
+		Replace: true,
+	})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 1, progress.Copied)
+}
+
+func TestMigrate_Errors(t *testing.T) {
+	m := dummyrds.Mocker{}
+	m.AddMock("SCAN 0 * 100", []interface{}{[]byte("7"), []interface{}{[]byte("gone"), []byte("expiring"), []byte("dumped"), []byte("foo")}}, false)
+	m.AddMock("DUMP gone", []byte("serialized"), false)
+	m.AddMock("PTTL gone", int64(-2), false)
+	m.AddMock("DUMP expiring", []byte("serialized"), false)
+	m.AddMock("PTTL expiring", int64(0), false)
+	m.AddMock("DUMP dumped", nil, false)
+	m.AddMock("DUMP foo", []byte("serialized"), false)
+	m.AddMock("PTTL foo", int64(1500), false)
+	source := dummyrds.New(dummyrds.Config{MockingMap: m})
+
+	t.Run("restore", func(t *testing.T) {
+		tm := dummyrds.Mocker{}
+		tm.AddMock("RESTORE foo 1.5s serialized", "OOM command not allowed", true)
+		target := dummyrds.New(dummyrds.Config{MockingMap: tm})
+
+		progress, err := Migrate(context.Background(), source, target, Config{Scan: redis.ScanOptions{Match: "*"}})
+		assert.EqualError(t, err, "migrate: foo: OOM command not allowed")
+		assert.Zero(t, progress.Cursor, "the failed page is scanned again on resume")
+		assert.Equal(t, 4, progress.Scanned)
+		assert.Equal(t, 3, progress.Expired)
+		assert.Zero(t, progress.Copied)
+	})
+
+	t.Run("scan", func(t *testing.T) {
+		target := dummyrds.New(dummyrds.Config{MockingMap: dummyrds.Mocker{}})
+
+		progress, err := Migrate(context.Background(), source, target, Config{Scan: redis.ScanOptions{Match: "*"}, Cursor: 7})
+		assert.EqualError(t, err, "No mocking found for SCAN 7 * 100")
+		assert.Equal(t, 7, progress.Cursor)
+	})
+}
@@ -0,0 +1,40 @@
+package migrate
+
+import (
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Config of a migration
+type Config struct {
+	// Scan selects the keys copied, every key when empty. Default Count is 100
+	Scan redis.ScanOptions
+	// Cursor resumes a stopped migration from its Progress.Cursor
+	Cursor int
+	// Rate limits the keys copied per second, zero is unlimited
+	Rate int
+	// Replace overwrites the keys existing on the target, they are left untouched otherwise
+	Replace bool
+	// DryRun scans the source and checks the target without copying any key
+	DryRun bool
+	// OnProgress is called after every page of keys
+	OnProgress func(Progress)
+}
+
+// Progress of a migration, the counters of a resumed migration start from zero
+type Progress struct {
+	// Cursor to resume from, the keys of the pages before it are done
+	Cursor int
+	// Done is true once the whole keyspace was scanned
+	Done bool
+	// Scanned keys, including the ones not copied
+	Scanned int
+	// Copied keys, or the keys that would be copied in a dry run
+	Copied int
+	// Existing keys on the target, left untouched unless Replace is set
+	Existing int
+	// Expired keys, gone from the source before they could be copied
+	Expired int
+	Elapsed time.Duration
+}
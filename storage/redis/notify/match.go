@@ -0,0 +1,94 @@
+package notify
+
+// match reports whether key matches the glob-style pattern of KEYS:
+// * matches any run of characters, ? any single character, [abc], [^a] and [a-z]
+// a character set, and a backslash escapes the following character
+func match(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			// Collapse consecutive stars, then try every split of the key
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if match(pattern, key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(key) == 0 {
+				return false
+			}
+		case '[':
+			if len(key) == 0 {
+				return false
+			}
+			end, ok := matchSet(pattern, key[0])
+			if !ok {
+				return false
+			}
+			pattern = pattern[end:]
+			key = key[1:]
+			continue
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(key) == 0 || pattern[0] != key[0] {
+				return false
+			}
+		}
+
+		pattern = pattern[1:]
+		key = key[1:]
+	}
+
+	return len(key) == 0
+}
+
+// matchSet matches c against the set at the start of pattern, it returns the
+// length of the set and whether c is in it
+func matchSet(pattern string, c byte) (int, bool) {
+	i := 1
+	negate := i < len(pattern) && pattern[i] == '^'
+	if negate {
+		i++
+	}
+
+	found := false
+	for first := true; i < len(pattern) && (first || pattern[i] != ']'); first = false {
+		lo := pattern[i]
+		if lo == '\\' && i+1 < len(pattern) {
+			i++
+			lo = pattern[i]
+		}
+
+		hi := lo
+		if i+2 < len(pattern) && pattern[i+1] == '-' && pattern[i+2] != ']' {
+			hi = pattern[i+2]
+			i += 2
+		}
+
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		if lo <= c && c <= hi {
+			found = true
+		}
+		i++
+	}
+
+	// An unterminated set matches up to the end of the pattern
+	if i < len(pattern) {
+		i++
+	}
+
+	return i, found != negate
+}
@@ -0,0 +1,321 @@
+// Package notify listens to the keyevent notifications of redis, e.g. to drop
+// local cache entries when their key expires or is deleted.
+//
+// The listener subscribes to __keyevent@<db>__:<event> on its own connection, or
+// to __keyspace@<db>__:<pattern> for the patterns of Config.Keys, and reconnects
+// with an exponential delay when the connection is lost. Received events are
+// queued and dispatched by a separate goroutine, so slow handlers never block the
+// connection, and the events arriving while the queue is full are dropped.
+//
+//	l := notify.New(notify.Config{Endpoint: addr, Enable: true, OnReconnect: cache.Clear})
+//	l.Handle("product:*", func(e notify.Event) { cache.Delete(e.Key) })
+//	err := l.Run(ctx)
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	rgo "github.com/gomodule/redigo/redis"
+)
+
+// minReconnectDelay is the delay before the first reconnection
+const minReconnectDelay = 100 * time.Millisecond
+
+// event classes of notify-keyspace-events, events missing here enable every class
+var eventClasses = map[string]string{
+	EventSet:      "$",
+	"setrange":    "$",
+	"incrby":      "$",
+	"append":      "$",
+	EventDel:      "g",
+	EventExpire:   "g",
+	EventRename:   "g",
+	"rename_from": "g",
+	"persist":     "g",
+	EventExpired:  "x",
+	EventEvicted:  "e",
+	"hset":        "h",
+	"hdel":        "h",
+	"lpush":       "l",
+	"rpush":       "l",
+	"sadd":        "s",
+	"srem":        "s",
+	"zadd":        "z",
+	"zrem":        "z",
+}
+
+// New listener, start it with Run
+func New(config Config) *Listener {
+
+	if len(config.Events) == 0 {
+		config.Events = []string{EventSet, EventDel, EventExpired}
+	}
+
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1024
+	}
+
+	// Set default 10 seconds health check
+	if config.HealthCheck <= 0 {
+		config.HealthCheck = 10 * time.Second
+	}
+
+	// Set default 5 seconds max reconnect delay
+	if config.MaxReconnectDelay <= 0 {
+		config.MaxReconnectDelay = 5 * time.Second
+	}
+
+	if config.OnError == nil {
+		config.OnError = func(error) {}
+	}
+
+	if config.OnReconnect == nil {
+		config.OnReconnect = func() {}
+	}
+
+	return &Listener{
+		endpoint:          config.Endpoint,
+		db:                config.DB,
+		events:            config.Events,
+		keys:              config.Keys,
+		enable:            config.Enable,
+		healthCheck:       config.HealthCheck,
+		maxReconnectDelay: config.MaxReconnectDelay,
+		onError:           config.OnError,
+		onReconnect:       config.OnReconnect,
+		queue:             make(chan Event, config.QueueSize),
+	}
+}
+
+// Handle calls handler with the events of the keys matching pattern, a glob-style
+// pattern as in KEYS. Every matching handler is called, in the order they were added
+func (l *Listener) Handle(pattern string, handler Handler) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.handlers = append(l.handlers, route{pattern: pattern, handler: handler})
+}
+
+// Dropped returns the number of events dropped because the queue was full
+func (l *Listener) Dropped() uint64 {
+	return atomic.LoadUint64(&l.dropped)
+}
+
+// Run receives and dispatches the events until ctx is done, reconnecting when
+// the connection is lost. The queued events are dispatched before it returns.
+// A listener runs once, the next calls return ErrRunAgain
+func (l *Listener) Run(ctx context.Context) error {
+	if !atomic.CompareAndSwapUint32(&l.ran, 0, 1) {
+		return ErrRunAgain
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for event := range l.queue {
+			l.dispatch(event)
+		}
+	}()
+
+	delay := minReconnectDelay
+	for connected := false; ctx.Err() == nil; {
+		err := l.listen(ctx, func() {
+			if connected {
+				l.onReconnect()
+			}
+			connected = true
+			delay = minReconnectDelay
+		})
+		if ctx.Err() != nil {
+			break
+		}
+		l.onError(err)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+
+		if delay *= 2; delay > l.maxReconnectDelay {
+			delay = l.maxReconnectDelay
+		}
+	}
+
+	close(l.queue)
+	wg.Wait()
+
+	return nil
+}
+
+// listen subscribes on a new connection and queues the events until the connection
+// fails or ctx is done, subscribed is called once the subscriptions are confirmed
+func (l *Listener) listen(ctx context.Context, subscribed func()) error {
+	conn, err := rgo.Dial("tcp", l.endpoint, rgo.DialReadTimeout(2*l.healthCheck))
+	if err != nil {
+		return err
+	}
+
+	if l.enable {
+		if err := l.enableEvents(conn); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+
+	psc := rgo.PubSubConn{Conn: conn}
+	keyspace := len(l.keys) > 0
+
+	// The keyspace channels name the key and carry the event, the keyevent ones
+	// are the other way around
+	var channels []interface{}
+	var prefix string
+	var subscribe func(channels ...interface{}) error
+	if keyspace {
+		prefix = fmt.Sprintf("__keyspace@%d__:", l.db)
+		for _, pattern := range l.keys {
+			channels = append(channels, prefix+pattern)
+		}
+		subscribe = psc.PSubscribe
+	} else {
+		prefix = fmt.Sprintf("__keyevent@%d__:", l.db)
+		for _, event := range l.events {
+			channels = append(channels, prefix+event)
+		}
+		subscribe = psc.Subscribe
+	}
+
+	if err := subscribe(channels...); err != nil {
+		psc.Close()
+		return err
+	}
+
+	// Closing the connection unblocks Receive
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(l.healthCheck)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				psc.Close()
+				return
+			case <-done:
+				psc.Close()
+				return
+			case <-ticker.C:
+				psc.Ping("")
+			}
+		}
+	}()
+
+	confirmed := 0
+	for {
+		switch msg := psc.Receive().(type) {
+		case error:
+			return msg
+		case rgo.Subscription:
+			if confirmed++; confirmed == len(channels) {
+				subscribed()
+			}
+		case rgo.Message:
+			if !keyspace {
+				l.enqueue(Event{Type: strings.TrimPrefix(msg.Channel, prefix), Key: string(msg.Data), DB: l.db})
+				continue
+			}
+
+			// Every event of the keys is received, keep the subscribed ones
+			if event := string(msg.Data); l.wants(event) {
+				l.enqueue(Event{Type: event, Key: strings.TrimPrefix(msg.Channel, prefix), DB: l.db})
+			}
+		}
+	}
+}
+
+// wants reports whether event is one of the events of the config
+func (l *Listener) wants(event string) bool {
+	for _, e := range l.events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// enableEvents adds the keyevent or keyspace flags of the events to notify-keyspace-events
+func (l *Listener) enableEvents(conn rgo.Conn) error {
+	reply, err := rgo.Strings(conn.Do("CONFIG", "GET", "notify-keyspace-events"))
+	if err != nil {
+		return err
+	}
+
+	flags := ""
+	if len(reply) == 2 {
+		flags = reply[1]
+	}
+
+	wanted := "E"
+	if len(l.keys) > 0 {
+		wanted = "K"
+	}
+	for _, event := range l.events {
+		class, ok := eventClasses[event]
+		if !ok {
+			class = "A"
+		}
+		wanted += class
+	}
+
+	updated := flags
+	for _, flag := range wanted {
+		// A is the alias of every class but the key miss and new key events
+		if !strings.ContainsRune(updated, flag) && !(flag != 'E' && flag != 'K' && strings.ContainsRune(updated, 'A')) {
+			updated += string(flag)
+		}
+	}
+
+	if updated == flags {
+		return nil
+	}
+
+	_, err = conn.Do("CONFIG", "SET", "notify-keyspace-events", updated)
+	return err
+}
+
+// enqueue queues event without blocking, it is dropped when the queue is full
+func (l *Listener) enqueue(event Event) {
+	select {
+	case l.queue <- event:
+	default:
+		atomic.AddUint64(&l.dropped, 1)
+	}
+}
+
+// dispatch calls the handlers of the key, a panicking handler is reported to OnError
+func (l *Listener) dispatch(event Event) {
+	l.mu.RLock()
+	handlers := l.handlers
+	l.mu.RUnlock()
+
+	for _, r := range handlers {
+		if match(r.pattern, event.Key) {
+			l.call(r.handler, event)
+		}
+	}
+}
+
+func (l *Listener) call(handler Handler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			l.onError(fmt.Errorf("notify: handler of %s %s panicked: %v", event.Type, event.Key, r))
+		}
+	}()
+
+	handler(event)
+}
@@ -0,0 +1,362 @@
+package notify_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/5112100070/publib/storage/redis/notify"
+	"github.com/5112100070/publib/storage/redis/redistest"
+	rgo "github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder collects the events of a handler
+type recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *recorder) handle(event Event) {
+	if event.Key == readyKey {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) keys() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := make([]string, len(r.events))
+	for i, e := range r.events {
+		keys[i] = e.Key
+	}
+	return keys
+}
+
+// readyKey is published until the listener receives it
+const readyKey = "__ready__"
+
+// start runs l until the test ends, it returns once l is subscribed to the set events
+func start(t *testing.T, l *Listener, conn rgo.Conn) {
+	startOn(t, l, conn, "__keyevent@0__:set", readyKey)
+}
+
+// startOn runs l until the test ends, it returns once l receives message on channel
+func startOn(t *testing.T, l *Listener, conn rgo.Conn, channel, message string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- l.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Error("listener did not stop")
+		}
+	})
+
+	require.Eventually(t, func() bool {
+		n, err := rgo.Int(conn.Do("PUBLISH", channel, message))
+		return err == nil && n > 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func publish(t *testing.T, conn rgo.Conn, event, key string) {
+	_, err := conn.Do("PUBLISH", "__keyevent@0__:"+event, key)
+	require.NoError(t, err)
+}
+
+func TestListener_Dispatch(t *testing.T) {
+	addr := redistest.StartServer(t)
+	conn, err := rgo.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	l := New(Config{Endpoint: addr})
+	var products, all, escaped, sets recorder
+	l.Handle("product:*", products.handle)
+	l.Handle("*", all.handle)
+	l.Handle(`price:\*:[a-c]?`, escaped.handle)
+	l.Handle("user:[^0-9]*", func(e Event) {
+		if e.Type == EventSet {
+			sets.handle(e)
+		}
+	})
+
+	start(t, l, conn)
+
+	publish(t, conn, EventDel, "product:1")
+	publish(t, conn, EventExpired, "product:2")
+	publish(t, conn, EventSet, "price:*:b1")
+	publish(t, conn, EventSet, "price:x:b1")
+	publish(t, conn, EventSet, "user:alice")
+	publish(t, conn, EventDel, "user:bob")
+	publish(t, conn, EventSet, "user:1")
+	publish(t, conn, EventEvicted, "product:3") // not subscribed
+	publish(t, conn, EventSet, "done")
+
+	require.Eventually(t, func() bool {
+		keys := all.keys()
+		return len(keys) > 0 && keys[len(keys)-1] == "done"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []string{"product:1", "product:2"}, products.keys())
+	assert.Equal(t, []string{"price:*:b1"}, escaped.keys())
+	assert.Equal(t, []string{"user:alice"}, sets.keys())
+	assert.Equal(t, Event{Type: EventExpired, Key: "product:2", DB: 0}, products.events[1])
+	assert.NotContains(t, all.keys(), "product:3")
+	assert.Zero(t, l.Dropped())
+}
+
+func TestListener_Keyspace(t *testing.T) {
+	addr := redistest.StartServer(t)
+	conn, err := rgo.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	l := New(Config{Endpoint: addr, Keys: []string{"product:*", readyKey}})
+	var all recorder
+	l.Handle("*", all.handle)
+
+	startOn(t, l, conn, "__keyspace@0__:"+readyKey, EventSet)
+
+	for _, e := range []struct{ key, event string }{
+		{"product:1", EventDel},
+		{"user:1", EventDel}, // not subscribed
+		{"product:2", EventEvicted},
+		{"product:3", EventExpired},
+	} {
+		_, err := conn.Do("PUBLISH", "__keyspace@0__:"+e.key, e.event)
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool {
+		keys := all.keys()
+		return len(keys) > 0 && keys[len(keys)-1] == "product:3"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// only the keys of the patterns are received, with the events of the config
+	assert.Equal(t, []string{"product:1", "product:3"}, all.keys())
+	assert.Equal(t, Event{Type: EventExpired, Key: "product:3", DB: 0}, all.events[1])
+}
+
+func TestListener_RunAgain(t *testing.T) {
+	addr := redistest.StartServer(t)
+	conn, err := rgo.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	l := New(Config{Endpoint: addr})
+	start(t, l, conn)
+
+	// a running listener can not run again, a stopped one neither
+	assert.Equal(t, ErrRunAgain, l.Run(context.Background()))
+}
+
+func TestListener_SlowHandler(t *testing.T) {
+	addr := redistest.StartServer(t)
+	conn, err := rgo.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var handlerErr atomic.Value
+	l := New(Config{
+		Endpoint:  addr,
+		QueueSize: 2,
+		OnError:   func(err error) { handlerErr.Store(err) },
+	})
+
+	release := make(chan struct{})
+	var handled int32
+	l.Handle("*", func(e Event) {
+		if e.Key == readyKey {
+			return
+		}
+		if atomic.AddInt32(&handled, 1) == 1 {
+			<-release
+			panic("boom")
+		}
+	})
+	start(t, l, conn)
+
+	// The first event blocks the handler, the queue holds two more and the rest are dropped
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		publish(t, conn, EventSet, key)
+	}
+	require.Eventually(t, func() bool { return l.Dropped() == 2 }, 5*time.Second, 10*time.Millisecond)
+	close(release)
+
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&handled) == 3 }, 5*time.Second, 10*time.Millisecond)
+	err, _ = handlerErr.Load().(error)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "panicked: boom")
+}
+
+// proxy forwards connections to addr until they are cut
+type proxy struct {
+	ln    net.Listener
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func newProxy(t *testing.T, addr string) *proxy {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	p := &proxy{ln: ln}
+	t.Cleanup(func() { ln.Close(); p.cut() })
+
+	go func() {
+		for {
+			client, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			server, err := net.Dial("tcp", addr)
+			if err != nil {
+				client.Close()
+				continue
+			}
+			p.mu.Lock()
+			p.conns = append(p.conns, client, server)
+			p.mu.Unlock()
+			go func() { io.Copy(server, client); server.Close() }()
+			go func() { io.Copy(client, server); client.Close() }()
+		}
+	}()
+
+	return p
+}
+
+// cut closes the forwarded connections
+func (p *proxy) cut() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, c := range p.conns {
+		c.Close()
+	}
+	p.conns = nil
+}
+
+func TestListener_Reconnect(t *testing.T) {
+	addr := redistest.StartServer(t)
+	conn, err := rgo.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	p := newProxy(t, addr)
+	reconnected := make(chan struct{}, 1)
+	var errs int32
+	l := New(Config{
+		Endpoint:    p.ln.Addr().String(),
+		OnError:     func(error) { atomic.AddInt32(&errs, 1) },
+		OnReconnect: func() { reconnected <- struct{}{} },
+	})
+
+	var events recorder
+	l.Handle("*", events.handle)
+	start(t, l, conn)
+
+	p.cut()
+	select {
+	case <-reconnected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener did not reconnect")
+	}
+	assert.NotZero(t, atomic.LoadInt32(&errs))
+
+	publish(t, conn, EventDel, "after")
+	require.Eventually(t, func() bool {
+		keys := events.keys()
+		return len(keys) > 0 && keys[len(keys)-1] == "after"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestListener_Run_DialError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	errs := make(chan error, 10)
+	l := New(Config{Endpoint: addr, OnError: func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- l.Run(ctx) }()
+
+	select {
+	case err := <-errs:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("dial error not reported")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener did not stop")
+	}
+	assert.Equal(t, ErrRunAgain, l.Run(ctx), "the queue is closed")
+}
+
+func TestListener_Enable(t *testing.T) {
+	addr := redistest.StartServer(t)
+	conn, err := rgo.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	if _, err := conn.Do("CONFIG", "GET", "notify-keyspace-events"); err != nil {
+		t.Skipf("server has no keyspace notifications: %v", err)
+	}
+	_, err = conn.Do("CONFIG", "SET", "notify-keyspace-events", "")
+	require.NoError(t, err)
+
+	l := New(Config{Endpoint: addr, Enable: true})
+
+	key := redistest.Key(t, "cache")
+	var events recorder
+	l.Handle(key+"*", events.handle)
+	start(t, l, conn)
+
+	flags, err := rgo.Strings(conn.Do("CONFIG", "GET", "notify-keyspace-events"))
+	require.NoError(t, err)
+	for _, flag := range "E$gx" {
+		assert.Contains(t, flags[1], string(flag))
+	}
+
+	_, err = conn.Do("SET", key+":a", "1")
+	require.NoError(t, err)
+	_, err = conn.Do("DEL", key+":a")
+	require.NoError(t, err)
+	_, err = conn.Do("SET", key+":b", "1", "PX", 1)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		events.mu.Lock()
+		defer events.mu.Unlock()
+		return len(events.events) == 4
+	}, 5*time.Second, 10*time.Millisecond)
+
+	var types []string
+	for _, e := range events.events {
+		types = append(types, e.Type+" "+strings.TrimPrefix(e.Key, key))
+	}
+	assert.Equal(t, []string{"set :a", "del :a", "set :b", "expired :b"}, types)
+}
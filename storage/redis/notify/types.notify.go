@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Error list
+var (
+	// ErrRunAgain is returned by Run when the listener already ran, create a new one instead
+	ErrRunAgain = errors.New("notify: listener already ran")
+)
+
+// Events of the keyevent notifications, see the keyspace notifications of redis for the others
+const (
+	EventSet     = "set"
+	EventDel     = "del"
+	EventExpired = "expired"
+	EventEvicted = "evicted"
+	EventExpire  = "expire"
+	EventRename  = "rename_to"
+)
+
+// Config of a listener
+type Config struct {
+	Endpoint string
+	// DB whose events are received
+	DB int
+	// Events subscribed, default is EventSet, EventDel and EventExpired
+	Events []string
+	// Keys are the glob-style patterns of the keys whose events are received. When
+	// set, the listener subscribes to the keyspace notifications of the patterns,
+	// __keyspace@<db>__:<pattern>, so the server only sends the events of these keys.
+	// Default is the keyevent notifications of every key
+	Keys []string
+	// Enable adds the flags of Events to notify-keyspace-events with CONFIG SET when
+	// connecting. Leave it off when CONFIG is not allowed, e.g. on managed servers
+	Enable bool
+
+	// QueueSize is the number of events waiting for the handlers, the events received
+	// while the queue is full are dropped. Default is 1024
+	QueueSize int
+	// HealthCheck is the interval of the pings detecting a dead connection, default is 10 seconds
+	HealthCheck time.Duration
+	// MaxReconnectDelay caps the exponential delay between reconnections, default is 5 seconds
+	MaxReconnectDelay time.Duration
+
+	// OnError is called with the connection and handler errors
+	OnError func(err error)
+	// OnReconnect is called after the listener subscribed again, the events published
+	// while it was disconnected are lost so local caches should be cleared
+	OnReconnect func()
+}
+
+// Event is a keyevent or keyspace notification
+type Event struct {
+	// Type is the event, e.g. EventExpired
+	Type string
+	Key  string
+	DB   int
+}
+
+// Handler of the events of the keys matching its pattern
+type Handler func(event Event)
+
+// Listener subscribes to the keyevent notifications and dispatches them to the
+// handlers of the matching key patterns
+type Listener struct {
+	endpoint          string
+	db                int
+	events            []string
+	keys              []string
+	enable            bool
+	healthCheck       time.Duration
+	maxReconnectDelay time.Duration
+	onError           func(err error)
+	onReconnect       func()
+
+	mu       sync.RWMutex
+	handlers []route
+
+	queue   chan Event
+	dropped uint64
+	ran     uint32
+}
+
+// route is a handler with its key pattern
+type route struct {
+	pattern string
+	handler Handler
+}
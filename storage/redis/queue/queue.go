@@ -0,0 +1,169 @@
+// Package queue implements a reliable FIFO queue on redis lists.
+//
+// Popped items are atomically moved to a processing list and stay there until
+// they are acknowledged, so items are not lost when a consumer crashes after
+// popping them. RequeueStale moves the items that were not acknowledged within
+// the visibility timeout back to the queue, which makes the delivery at least once.
+//
+// Items are tracked by value, so every item pushed should be unique, e.g. by
+// carrying its own ID.
+package queue
+
+import (
+	"errors"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Error list
+var (
+	ErrEmpty = errors.New("queue: no item available")
+)
+
+// KEYS processing, pending, claims. ARGV item. A failing RPUSH puts the item back in
+// processing, so it is never lost between the lists. Replies 1 when requeued, 0 when
+// the item was acknowledged in the meantime
+const requeueScript = `
+if redis.call('LREM', KEYS[1], 1, ARGV[1]) == 0 then
+	redis.call('ZREM', KEYS[3], ARGV[1])
+	return 0
+end
+local pushed = redis.pcall('RPUSH', KEYS[2], ARGV[1])
+if type(pushed) == 'table' and pushed.err then
+	redis.call('LPUSH', KEYS[1], ARGV[1])
+	return pushed
+end
+redis.call('ZREM', KEYS[3], ARGV[1])
+return 1`
+
+// Config of a queue
+type Config struct {
+	// Name is the key of the pending list, the other keys are derived from it
+	Name string
+	// VisibilityTimeout is how long a popped item may stay unacknowledged
+	// before RequeueStale moves it back to the queue, default is one minute
+	VisibilityTimeout time.Duration
+}
+
+// Queue is a reliable queue backed by a pending list, a processing list and
+// a sorted set holding the time each processing item was claimed
+type Queue struct {
+	rds        redis.Redis
+	pending    string
+	processing string
+	claims     string
+	visibility time.Duration
+}
+
+// New reliable queue
+func New(rds redis.Redis, config Config) *Queue {
+
+	// Set default one minute visibility timeout
+	if config.VisibilityTimeout <= 0 {
+		config.VisibilityTimeout = time.Minute
+	}
+
+	return &Queue{
+		rds:        rds,
+		pending:    config.Name,
+		processing: config.Name + ":processing",
+		claims:     config.Name + ":claims",
+		visibility: config.VisibilityTimeout,
+	}
+}
+
+// Push appends items to the queue
+func (q *Queue) Push(items ...interface{}) error {
+	return q.rds.LPush(q.pending, items...)
+}
+
+// Pop moves the oldest item to the processing list and returns it, waiting up to timeout
+// for an item to be pushed. A zero timeout waits indefinitely. ErrEmpty is returned when
+// the timeout is reached. The item must be acknowledged with Ack once it is processed
+func (q *Queue) Pop(timeout time.Duration) (string, error) {
+	res := q.rds.BLMove(q.pending, q.processing, redis.Right, redis.Left, timeout)
+	if res.Error != nil {
+		return "", res.Error
+	}
+
+	if res.Value == nil {
+		return "", ErrEmpty
+	}
+
+	// An item left unclaimed on failure is claimed by RequeueStale instead
+	item := res.String()
+	if err := q.rds.ZAdd(q.claims, redis.Z{Score: score(time.Now()), Member: item}); err != nil {
+		return "", err
+	}
+
+	return item, nil
+}
+
+// Ack removes a processed item from the processing list
+func (q *Queue) Ack(item string) error {
+	if res := q.rds.LRem(q.processing, 1, item); res.Error != nil {
+		return res.Error
+	}
+
+	return q.rds.ZRem(q.claims, item).Error
+}
+
+// RequeueStale moves the items claimed longer than the visibility timeout ago
+// back to the head of the queue, returns the number of requeued items.
+// Call it periodically from one or more consumers
+func (q *Queue) RequeueStale() (int, error) {
+	res := q.rds.LRange(q.processing, 0, -1)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	now := time.Now()
+	deadline := score(now.Add(-q.visibility))
+
+	requeued := 0
+	for _, item := range res.StringSlice() {
+		claim := q.rds.ZScore(q.claims, item)
+		if claim.Error != nil {
+			return requeued, claim.Error
+		}
+
+		// Popped by a consumer that failed before claiming it, start its timeout now
+		if claim.Value == nil {
+			if err := q.rds.ZAdd(q.claims, redis.Z{Score: score(now), Member: item}); err != nil {
+				return requeued, err
+			}
+			continue
+		}
+
+		if claim.Float64() > deadline {
+			continue
+		}
+
+		// Nothing is requeued when the item was acknowledged in the meantime
+		moved := q.rds.Eval(requeueScript, []string{q.processing, q.pending, q.claims}, item)
+		if moved.Error != nil {
+			return requeued, moved.Error
+		}
+		requeued += moved.Int()
+	}
+
+	return requeued, nil
+}
+
+// Len returns the number of items waiting in the queue
+func (q *Queue) Len() (int, error) {
+	res := q.rds.LLen(q.pending)
+	return res.Int(), res.Error
+}
+
+// Processing returns the number of items popped but not acknowledged yet
+func (q *Queue) Processing() (int, error) {
+	res := q.rds.LLen(q.processing)
+	return res.Int(), res.Error
+}
+
+// score converts t to the millisecond claim score
+func score(t time.Time) float64 {
+	return float64(t.UnixNano() / int64(time.Millisecond))
+}
@@ -0,0 +1,161 @@
+package queue_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis/dummyrds"
+	. "github.com/5112100070/publib/storage/redis/queue"
+	"github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/5112100070/publib/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueue(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	q := New(rds, Config{Name: redistest.Key(t, "queue")})
+
+	require.NoError(t, q.Push("a", "b"))
+	require.NoError(t, q.Push("c"))
+	n, err := q.Len()
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	// first in first out
+	for _, want := range []string{"a", "b", "c"} {
+		item, err := q.Pop(time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, want, item)
+	}
+
+	_, err = q.Pop(100 * time.Millisecond)
+	assert.Equal(t, ErrEmpty, err)
+
+	n, err = q.Processing()
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	require.NoError(t, q.Ack("a"))
+	require.NoError(t, q.Ack("b"))
+	require.NoError(t, q.Ack("c"))
+
+	n, err = q.Processing()
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestQueue_RequeueStale(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	q := New(rds, Config{
+		Name:              redistest.Key(t, "queue"),
+		VisibilityTimeout: 200 * time.Millisecond,
+	})
+
+	require.NoError(t, q.Push("a", "b", "c"))
+
+	a, err := q.Pop(time.Second)
+	require.NoError(t, err)
+	b, err := q.Pop(time.Second)
+	require.NoError(t, err)
+	require.NoError(t, q.Ack(b))
+
+	// nothing is stale before the visibility timeout
+	n, err := q.RequeueStale()
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	time.Sleep(300 * time.Millisecond)
+
+	n, err = q.RequeueStale()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// the stale item is delivered again before the remaining ones
+	item, err := q.Pop(time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, a, item)
+
+	item, err = q.Pop(time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "c", item)
+}
+
+func TestQueue_RequeueUnclaimed(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	name := redistest.Key(t, "queue")
+	q := New(rds, Config{
+		Name:              name,
+		VisibilityTimeout: 200 * time.Millisecond,
+	})
+
+	// a consumer crashed right after moving the item
+	require.NoError(t, rds.LPush(name+":processing", "a"))
+
+	n, err := q.RequeueStale()
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	time.Sleep(300 * time.Millisecond)
+
+	n, err = q.RequeueStale()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	item, err := q.Pop(time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "a", item)
+}
+
+func TestQueue_RequeueStale_PushError(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	name := redistest.Key(t, "queue")
+	q := New(rds, Config{
+		Name:              name,
+		VisibilityTimeout: 100 * time.Millisecond,
+	})
+
+	require.NoError(t, q.Push("a"))
+	_, err := q.Pop(time.Second)
+	require.NoError(t, err)
+	time.Sleep(150 * time.Millisecond)
+
+	// RPUSH fails on the wrong type after LREM
+	require.NoError(t, rds.Set(name, "not a list").Error)
+	_, err = q.RequeueStale()
+	assert.Error(t, err)
+
+	// the item stays in processing, still stale
+	n, err := q.Processing()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	require.NoError(t, rds.Del(name))
+	n, err = q.RequeueStale()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	item, err := q.Pop(time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "a", item)
+}
+
+func TestQueue_Error(t *testing.T) {
+	m := dummyrds.Mocker{}
+	m.AddMock("LPUSH foo a", "failed", true)
+	m.AddMock("BLMOVE foo foo:processing RIGHT LEFT 1", "failed", true)
+	m.AddMock("LREM foo:processing 1 a", "failed", true)
+	m.AddMock("LRANGE foo:processing 0 -1", "failed", true)
+
+	q := New(dummyrds.New(dummyrds.Config{MockingMap: m}), Config{Name: "foo"})
+
+	assert.EqualError(t, q.Push("a"), "failed")
+
+	_, err := q.Pop(time.Second)
+	assert.EqualError(t, err, "failed")
+
+	assert.EqualError(t, q.Ack("a"), "failed")
+
+	_, err = q.RequeueStale()
+	assert.EqualError(t, err, "failed")
+}
@@ -0,0 +1,70 @@
+package redigo
+
+import (
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Sets the bit at offset to value, returns the previous bit
+func (c *credis) SetBit(key string, offset int64, value int) *redis.Result {
+	return c.cmd("SETBIT", key, offset, value)
+}
+
+// Returns the bit at offset, zero when the key or the offset does not exist
+func (c *credis) GetBit(key string, offset int64) *redis.Result {
+	return c.cmd("GETBIT", key, offset)
+}
+
+// Sets the bits at offsets to value in a single pipeline, returns the previous bits.
+// Read them with Result.Int64Slice or Result.BoolSlice. It is not part of redis.Redis,
+// reach it with an interface assertion
+func (c *credis) SetBitMulti(key string, offsets []int64, value int) *redis.Result {
+	args := make([][]interface{}, len(offsets))
+	for i, offset := range offsets {
+		args[i] = []interface{}{key, offset, value}
+	}
+	return c.pipeReplies("SETBIT", args)
+}
+
+// Returns the bits at offsets in a single pipeline, read them with Result.Int64Slice or Result.BoolSlice
+func (c *credis) GetBitMulti(key string, offsets []int64) *redis.Result {
+	args := make([][]interface{}, len(offsets))
+	for i, offset := range offsets {
+		args[i] = []interface{}{key, offset}
+	}
+	return c.pipeReplies("GETBIT", args)
+}
+
+// Returns the number of bits set
+func (c *credis) BitCount(key string) *redis.Result {
+	return c.cmd("BITCOUNT", key)
+}
+
+// Returns the number of bits set between the bytes start and end, both inclusive
+func (c *credis) BitCountRange(key string, start, end int64) *redis.Result {
+	return c.cmd("BITCOUNT", key, start, end)
+}
+
+// Returns the offset of the first bit set to bit, -1 when there is none
+func (c *credis) BitPos(key string, bit int) *redis.Result {
+	return c.cmd("BITPOS", key, bit)
+}
+
+// Returns the offset of the first bit set to bit between the bytes start and end, -1 when there is none
+func (c *credis) BitPosRange(key string, bit int, start, end int64) *redis.Result {
+	return c.cmd("BITPOS", key, bit, start, end)
+}
+
+// Stores the result of a bitwise operation between keys in destination, returns its length in bytes.
+// op is one of redis.BitAnd, redis.BitOr, redis.BitXor or redis.BitNot
+func (c *credis) BitOp(op, destination string, keys ...string) *redis.Result {
+	return c.cmd("BITOP", keysArgs(keys, op, destination)...)
+}
+
+// Runs the subcommands on the bitfield, read the replies with Result.Int64Slice
+func (c *credis) BitField(key string, ops ...redis.BitFieldOp) *redis.Result {
+	args := []interface{}{key}
+	for _, op := range ops {
+		args = append(args, op...)
+	}
+	return c.cmd("BITFIELD", args...)
+}
@@ -0,0 +1,63 @@
+package redigo_test
+
+import (
+	"testing"
+
+	"github.com/5112100070/publib/storage/redis"
+	. "github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/5112100070/publib/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitmapCommands(t *testing.T) {
+
+	cfg := Config{
+		Endpoint: "null",
+	}
+	c := New(cfg)
+
+	errDial := "dial tcp: address null: missing port in address"
+
+	assert.EqualError(t, c.SetBit("test", 7, 1).Error, errDial)
+	assert.Equal(t, 0, c.GetBit("test", 7).Int())
+	assert.Equal(t, 0, c.BitCount("test").Int())
+	assert.Equal(t, 0, c.BitCountRange("test", 0, -1).Int())
+	assert.EqualError(t, c.BitPos("test", 1).Error, errDial)
+	assert.EqualError(t, c.BitPosRange("test", 0, 1, 2).Error, errDial)
+	assert.EqualError(t, c.BitOp(redis.BitOr, "dest", "foo", "bar").Error, errDial)
+	assert.Nil(t, c.BitField("test", redis.BitFieldGet("u8", 0)).Int64Slice())
+}
+
+// multiBitter is the optional interface of the pipelined bit commands
+type multiBitter interface {
+	SetBitMulti(key string, offsets []int64, value int) *redis.Result
+	GetBitMulti(key string, offsets []int64) *redis.Result
+}
+
+func TestBitMulti(t *testing.T) {
+	c, ok := New(Config{Endpoint: redistest.StartServer(t)}).(multiBitter)
+	require.True(t, ok)
+	bits := redistest.Key(t, "bits")
+
+	res := c.SetBitMulti(bits, []int64{1, 7, 1}, 1)
+	require.NoError(t, res.Error)
+	assert.Equal(t, []int64{0, 0, 1}, res.Int64Slice(), "the previous bits are replied in order")
+
+	res = c.GetBitMulti(bits, []int64{0, 1, 7, 1000})
+	require.NoError(t, res.Error)
+	assert.Equal(t, []bool{false, true, true, false}, res.BoolSlice())
+	assert.Equal(t, []bool{false}, c.GetBitMulti(redistest.Key(t, "none"), []int64{3}).BoolSlice())
+
+	assert.Equal(t, []int64{1}, c.SetBitMulti(bits, []int64{7}, 0).Int64Slice())
+	assert.Equal(t, []bool{false, true, false}, c.GetBitMulti(bits, []int64{0, 1, 7}).BoolSlice())
+
+	res = c.SetBitMulti(bits, []int64{2, -1}, 1)
+	assert.Error(t, res.Error, "the offset must not be negative")
+	assert.Equal(t, []bool{true}, c.GetBitMulti(bits, []int64{2}).BoolSlice(), "the other commands of the pipeline run")
+
+	// errors of a failed connection
+	c = New(Config{Endpoint: "null"}).(multiBitter)
+	assert.EqualError(t, c.SetBitMulti("test", []int64{1, 7}, 1).Error, "dial tcp: address null: missing port in address")
+	assert.Nil(t, c.GetBitMulti("test", []int64{1, 7}).BoolSlice())
+}
@@ -0,0 +1,58 @@
+package redigo
+
+import (
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Close rejects new commands with redis.ErrClosed and waits up to Config.CloseTimeout
+// for the in-flight ones before closing the pool. When they do not drain in time the
+// pool is closed anyway and redis.ErrCloseTimeout is returned
+func (c *credis) Close() error {
+	c.closeMu.Lock()
+	if c.closed {
+		c.closeMu.Unlock()
+		return redis.ErrClosed
+	}
+	c.closed = true
+	c.closeMu.Unlock()
+
+	c.StopBeat()
+
+	drained := make(chan struct{})
+	go func() {
+		c.inflight.Wait()
+		close(drained)
+	}()
+
+	var err error
+	timer := time.NewTimer(c.closeTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-drained:
+	case <-timer.C:
+		err = redis.ErrCloseTimeout
+	}
+
+	if cerr := c.pool.Close(); cerr != nil && err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// begin registers an in-flight command, release it with c.inflight.Done.
+// Returns redis.ErrClosed once the client is closed
+func (c *credis) begin() error {
+	c.closeMu.RLock()
+	defer c.closeMu.RUnlock()
+
+	if c.closed {
+		return redis.ErrClosed
+	}
+
+	c.inflight.Add(1)
+	return nil
+}
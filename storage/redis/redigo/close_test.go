@@ -0,0 +1,66 @@
+package redigo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+	. "github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/5112100070/publib/storage/redis/redistest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClose(t *testing.T) {
+
+	cfg := Config{
+		Endpoint: "null",
+	}
+	c := New(cfg)
+	c.DoHeartBeat()
+
+	assert.NoError(t, c.Close())
+	assert.Equal(t, redis.ErrClosed, c.Get("test").Error)
+	assert.Equal(t, redis.ErrClosed, c.Incr("test"))
+	_, err := c.IncrSingle("test")
+	assert.Equal(t, redis.ErrClosed, err)
+	assert.Equal(t, redis.ErrClosed.Error(), c.Status().LastError)
+	assert.Equal(t, redis.ErrClosed, c.Close())
+}
+
+func TestClose_Drain(t *testing.T) {
+	addr := redistest.StartServer(t)
+	c := New(Config{Endpoint: addr, CloseTimeout: 2 * time.Second})
+
+	// a blocking pop is in flight while closing
+	done := make(chan *redis.Result)
+	go func() {
+		done <- c.BLPop(300*time.Millisecond, redistest.Key(t, "list"))
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	require.NoError(t, c.Close())
+	assert.True(t, time.Since(start) >= 200*time.Millisecond, "close returned after %v, before the pop drained", time.Since(start))
+
+	res := <-done
+	assert.NoError(t, res.Error, "the pop timed out instead of being interrupted")
+	assert.Nil(t, res.Value)
+}
+
+func TestClose_Timeout(t *testing.T) {
+	addr := redistest.StartServer(t)
+	c := New(Config{Endpoint: addr, CloseTimeout: 50 * time.Millisecond})
+
+	done := make(chan struct{})
+	go func() {
+		c.BLPop(500*time.Millisecond, redistest.Key(t, "list"))
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	assert.Equal(t, redis.ErrCloseTimeout, c.Close())
+	assert.True(t, time.Since(start) < 400*time.Millisecond, "close waited %v", time.Since(start))
+	<-done
+}
@@ -0,0 +1,28 @@
+package redigo
+
+import (
+	"strings"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Serializes the value of key in the format of RDB, nil when the key does not exist
+func (c *credis) Dump(key string) *redis.Result {
+	return c.cmd("DUMP", key)
+}
+
+// Creates key from a value serialized by Dump, ttl zero creates it without timeout.
+// Returns redis.ErrKeyExists when the key exists and replace is false
+func (c *credis) Restore(key string, ttl time.Duration, value []byte, replace bool) error {
+	args := []interface{}{key, redis.Milliseconds(ttl), value}
+	if replace {
+		args = append(args, "REPLACE")
+	}
+
+	err := c.cmd("RESTORE", args...).Error
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYKEY") {
+		return redis.ErrKeyExists
+	}
+	return err
+}
@@ -0,0 +1,22 @@
+package redigo_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpCommands(t *testing.T) {
+
+	cfg := Config{
+		Endpoint: "null",
+	}
+	c := New(cfg)
+
+	errDial := "dial tcp: address null: missing port in address"
+
+	assert.EqualError(t, c.Dump("foo").Error, errDial)
+	assert.EqualError(t, c.Restore("foo", time.Second, []byte("bar"), true), errDial)
+}
@@ -0,0 +1,67 @@
+package redigo
+
+import (
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Sets the value with a timeout in milliseconds precision, a sub-millisecond ttl is rounded
+// up and redis.ErrInvalidTTL is returned when it is not positive
+func (c *credis) PSetEx(key string, ttl time.Duration, value interface{}) error {
+	if ttl <= 0 {
+		return redis.ErrInvalidTTL
+	}
+	return c.cmd("PSETEX", key, redis.Milliseconds(ttl), value).Error
+}
+
+// Sets the timeout of key in milliseconds precision, returns false when the key does not exist.
+// A sub-millisecond ttl is rounded up and redis.ErrInvalidTTL is returned when it is not
+// positive, as PEXPIRE would delete the key right away
+func (c *credis) PExpire(key string, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		return false, redis.ErrInvalidTTL
+	}
+	res := c.cmd("PEXPIRE", key, redis.Milliseconds(ttl))
+	return res.Bool(), res.Error
+}
+
+// Expires key at the given second, returns false when the key does not exist
+func (c *credis) ExpireAt(key string, at time.Time) (bool, error) {
+	res := c.cmd("EXPIREAT", key, at.Unix())
+	return res.Bool(), res.Error
+}
+
+// Expires key at the given millisecond, returns false when the key does not exist
+func (c *credis) PExpireAt(key string, at time.Time) (bool, error) {
+	res := c.cmd("PEXPIREAT", key, at.UnixNano()/int64(time.Millisecond))
+	return res.Bool(), res.Error
+}
+
+// Removes the timeout of key, returns false when the key does not exist or has no timeout
+func (c *credis) Persist(key string) (bool, error) {
+	res := c.cmd("PERSIST", key)
+	return res.Bool(), res.Error
+}
+
+// Returns the remaining time to live of key, redis.ErrNoExpire when the key has no
+// timeout and redis.ErrKeyNotExist when the key does not exist
+func (c *credis) PTTL(key string) (time.Duration, error) {
+	return ttl(c.cmd("PTTL", key))
+}
+
+// ttl converts a PTTL reply
+func ttl(res *redis.Result) (time.Duration, error) {
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	switch ms := res.Int64(); ms {
+	case -1:
+		return 0, redis.ErrNoExpire
+	case -2:
+		return 0, redis.ErrKeyNotExist
+	default:
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+}
@@ -0,0 +1,43 @@
+package redigo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+	. "github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpireCommands(t *testing.T) {
+
+	cfg := Config{
+		Endpoint: "null",
+	}
+	c := New(cfg)
+
+	errDial := "dial tcp: address null: missing port in address"
+
+	assert.EqualError(t, c.PSetEx("test", time.Second, "foo"), errDial)
+
+	ok, err := c.PExpire("test", time.Second)
+	assert.False(t, ok)
+	assert.EqualError(t, err, errDial)
+
+	assert.Equal(t, redis.ErrInvalidTTL, c.PSetEx("test", 0, "foo"))
+	_, err = c.PExpire("test", -time.Second)
+	assert.Equal(t, redis.ErrInvalidTTL, err)
+
+	_, err = c.ExpireAt("test", time.Now())
+	assert.EqualError(t, err, errDial)
+
+	_, err = c.PExpireAt("test", time.Now())
+	assert.EqualError(t, err, errDial)
+
+	_, err = c.Persist("test")
+	assert.EqualError(t, err, errDial)
+
+	ttl, err := c.PTTL("test")
+	assert.Zero(t, ttl)
+	assert.EqualError(t, err, errDial)
+}
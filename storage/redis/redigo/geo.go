@@ -0,0 +1,55 @@
+package redigo
+
+import (
+	"github.com/5112100070/publib/convert"
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Adds the locations to the geospatial index, returns the number of added members
+func (c *credis) GeoAdd(key string, locations ...redis.GeoLocation) *redis.Result {
+	args := make([]interface{}, 0, 1+3*len(locations))
+	args = append(args, key)
+	for _, loc := range locations {
+		args = append(args, loc.Longitude, loc.Latitude, loc.Name)
+	}
+	return c.cmd("GEOADD", args...)
+}
+
+// Returns the positions of members, read them with Result.GeoLocations
+func (c *credis) GeoPos(key string, members ...string) *redis.Result {
+	res := c.cmd("GEOPOS", keysArgs(members, key)...)
+	if res.Error != nil {
+		return res
+	}
+
+	// the reply only holds the coordinates, name them after the requested members
+	positions, _ := res.Value.([]interface{})
+	locations := make([]redis.GeoLocation, 0, len(positions))
+	for i, pos := range positions {
+		coord, ok := pos.([]interface{})
+		if !ok || len(coord) != 2 || i >= len(members) {
+			continue
+		}
+		locations = append(locations, redis.GeoLocation{
+			Name:      members[i],
+			Longitude: convert.ToFloat64(coord[0]),
+			Latitude:  convert.ToFloat64(coord[1]),
+		})
+	}
+
+	return &redis.Result{Value: locations}
+}
+
+// Returns the distance between two members in unit, nil when a member does not exist
+func (c *credis) GeoDist(key, member1, member2, unit string) *redis.Result {
+	if unit == "" {
+		unit = redis.GeoMeters
+	}
+	return c.cmd("GEODIST", key, member1, member2, unit)
+}
+
+// Returns the members within the area of query, read them with Result.GeoLocations
+func (c *credis) GeoSearch(key string, query redis.GeoSearchQuery) *redis.Result {
+	args := append([]interface{}{key}, query.Args()...)
+	return c.cmd("GEOSEARCH", args...)
+}
@@ -0,0 +1,24 @@
+package redigo_test
+
+import (
+	"testing"
+
+	"github.com/5112100070/publib/storage/redis"
+	. "github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeoCommands(t *testing.T) {
+
+	cfg := Config{
+		Endpoint: "null",
+	}
+	c := New(cfg)
+
+	errDial := "dial tcp: address null: missing port in address"
+
+	assert.EqualError(t, c.GeoAdd("test", redis.GeoLocation{Name: "a", Longitude: 1, Latitude: 2}).Error, errDial)
+	assert.Nil(t, c.GeoPos("test", "a").GeoLocations())
+	assert.EqualError(t, c.GeoDist("test", "a", "b", "").Error, errDial)
+	assert.EqualError(t, c.GeoSearch("test", redis.GeoSearchQuery{Member: "a", Radius: 1}).Error, errDial)
+}
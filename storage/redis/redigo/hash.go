@@ -0,0 +1,48 @@
+package redigo
+
+import (
+	"fmt"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Increments the integer value of field by increment, returns the new value
+func (c *credis) HIncrBy(key, field string, increment int64) *redis.Result {
+	return c.cmd("HINCRBY", key, field, increment)
+}
+
+// Increments the float value of field by increment, returns the new value
+func (c *credis) HIncrByFloat(key, field string, increment float64) *redis.Result {
+	return c.cmd("HINCRBYFLOAT", key, field, fmt.Sprintf("%v", increment))
+}
+
+// Sets field only when it does not exist yet, read whether it was set with Result.Bool
+func (c *credis) HSetNX(key, field string, value interface{}) *redis.Result {
+	return c.cmd("HSETNX", key, field, value)
+}
+
+// Returns the number of fields of the hash
+func (c *credis) HLen(key string) *redis.Result {
+	return c.cmd("HLEN", key)
+}
+
+// Returns the values of fields, nil for the fields that do not exist
+func (c *credis) HMGet(key string, fields ...string) *redis.Result {
+	return c.cmd("HMGET", keysArgs(fields, key)...)
+}
+
+// Returns the length of the value of field
+func (c *credis) HStrLen(key, field string) *redis.Result {
+	return c.cmd("HSTRLEN", key, field)
+}
+
+// return cursor and a page of field and value pairs, read them with Result.ScanResult
+func (c *credis) HScan(key string, cursor int, match string, count int) *redis.Result {
+	if count == 0 {
+		count = 10
+	}
+	if match == "" {
+		return c.cmd("HSCAN", key, cursor, "COUNT", count)
+	}
+	return c.cmd("HSCAN", key, cursor, "MATCH", match, "COUNT", count)
+}
@@ -0,0 +1,26 @@
+package redigo_test
+
+import (
+	"testing"
+
+	. "github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashCommands(t *testing.T) {
+
+	cfg := Config{
+		Endpoint: "null",
+	}
+	c := New(cfg)
+
+	errDial := "dial tcp: address null: missing port in address"
+
+	assert.EqualError(t, c.HIncrBy("test", "foo", 2).Error, errDial)
+	assert.EqualError(t, c.HIncrByFloat("test", "foo", 1.5).Error, errDial)
+	assert.False(t, c.HSetNX("test", "foo", "bar").Bool())
+	assert.Equal(t, 0, c.HLen("test").Int())
+	assert.Nil(t, c.HMGet("test", "foo", "bar").StringSlice())
+	assert.Equal(t, 0, c.HStrLen("test", "foo").Int())
+	assert.EqualError(t, c.HScan("test", 0, "", 0).Error, errDial)
+}
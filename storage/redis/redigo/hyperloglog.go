@@ -0,0 +1,21 @@
+package redigo
+
+import (
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Adds the elements to the HyperLogLog, read with Result.Bool whether its estimation changed
+func (c *credis) PFAdd(key string, elements ...interface{}) *redis.Result {
+	args := append([]interface{}{key}, elements...)
+	return c.cmd("PFADD", args...)
+}
+
+// Returns the approximated number of unique elements of the union of the HyperLogLogs
+func (c *credis) PFCount(keys ...string) *redis.Result {
+	return c.cmd("PFCOUNT", keysArgs(keys)...)
+}
+
+// Stores the union of the HyperLogLogs in destination
+func (c *credis) PFMerge(destination string, keys ...string) error {
+	return c.cmd("PFMERGE", keysArgs(keys, destination)...).Error
+}
@@ -0,0 +1,22 @@
+package redigo_test
+
+import (
+	"testing"
+
+	. "github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHyperLogLogCommands(t *testing.T) {
+
+	cfg := Config{
+		Endpoint: "null",
+	}
+	c := New(cfg)
+
+	errDial := "dial tcp: address null: missing port in address"
+
+	assert.EqualError(t, c.PFAdd("test", "a", "b").Error, errDial)
+	assert.Equal(t, int64(0), c.PFCount("test", "foo").Int64())
+	assert.EqualError(t, c.PFMerge("dest", "foo", "bar"), errDial)
+}
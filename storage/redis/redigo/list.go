@@ -0,0 +1,61 @@
+package redigo
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Removes and returns the last element of the list stored at key
+func (c *credis) RPop(key string) *redis.Result {
+	return c.cmd("RPOP", key)
+}
+
+// Returns the elements of the list between start and stop, both inclusive
+func (c *credis) LRange(key string, start, stop int) *redis.Result {
+	return c.cmd("LRANGE", key, start, stop)
+}
+
+// Trims the list so it only contains the elements between start and stop
+func (c *credis) LTrim(key string, start, stop int) error {
+	return c.cmd("LTRIM", key, start, stop).Error
+}
+
+// Removes count occurrences of value, from the head when count is positive,
+// from the tail when negative and all of them when zero.
+// Returns the number of removed elements
+func (c *credis) LRem(key string, count int, value interface{}) *redis.Result {
+	return c.cmd("LREM", key, count, value)
+}
+
+// Returns the element at index, nil when the index is out of range
+func (c *credis) LIndex(key string, index int) *redis.Result {
+	return c.cmd("LINDEX", key, index)
+}
+
+// Pops the first element of the first non empty list, blocking up to timeout when all of them are empty.
+// Returns the key and the element, nil when the timeout is reached. A zero timeout blocks indefinitely
+func (c *credis) BLPop(timeout time.Duration, keys ...string) *redis.Result {
+	args := append(keysArgs(keys), seconds(timeout))
+	return c.cmd("BLPOP", args...)
+}
+
+// Pops the last element of the first non empty list, blocking up to timeout when all of them are empty.
+// Returns the key and the element, nil when the timeout is reached. A zero timeout blocks indefinitely
+func (c *credis) BRPop(timeout time.Duration, keys ...string) *redis.Result {
+	args := append(keysArgs(keys), seconds(timeout))
+	return c.cmd("BRPOP", args...)
+}
+
+// Atomically moves an element from the srcSide of source to the destSide of destination,
+// blocking up to timeout when source is empty. Sides are redis.Left or redis.Right.
+// Returns the moved element, nil when the timeout is reached. A zero timeout blocks indefinitely
+func (c *credis) BLMove(source, destination, srcSide, destSide string, timeout time.Duration) *redis.Result {
+	return c.cmd("BLMOVE", source, destination, srcSide, destSide, seconds(timeout))
+}
+
+// seconds formats a blocking timeout the way redis expects it
+func seconds(timeout time.Duration) string {
+	return strconv.FormatFloat(timeout.Seconds(), 'f', -1, 64)
+}
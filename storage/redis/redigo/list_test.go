@@ -0,0 +1,31 @@
+package redigo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+	. "github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListCommands(t *testing.T) {
+
+	cfg := Config{
+		Endpoint: "null",
+	}
+	c := New(cfg)
+
+	errDial := "dial tcp: address null: missing port in address"
+
+	assert.EqualError(t, c.LPush("test", 1, 2), errDial)
+	assert.EqualError(t, c.RPush("test", 1, 2), errDial)
+	assert.Equal(t, "", c.RPop("test").String())
+	assert.Nil(t, c.LRange("test", 0, -1).StringSlice())
+	assert.EqualError(t, c.LTrim("test", 0, 10), errDial)
+	assert.Equal(t, 0, c.LRem("test", 0, "foo").Int())
+	assert.Equal(t, "", c.LIndex("test", 0).String())
+	assert.EqualError(t, c.BLPop(time.Second, "foo", "bar").Error, errDial)
+	assert.EqualError(t, c.BRPop(time.Second, "foo", "bar").Error, errDial)
+	assert.EqualError(t, c.BLMove("foo", "bar", redis.Right, redis.Left, time.Second).Error, errDial)
+}
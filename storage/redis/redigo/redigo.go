@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/5112100070/publib/convert"
 	"github.com/5112100070/publib/storage/redis"
 	rgo "github.com/gomodule/redigo/redis"
 )
@@ -17,10 +16,21 @@ func New(config Config) redis.Redis {
 		config.Timeout = 10
 	}
 
+	// Set default 5 seconds close timeout
+	if config.CloseTimeout == 0 {
+		config.CloseTimeout = 5 * time.Second
+	}
+
 	// Open connection to redis server
 	return &credis{
+		closeTimeout: config.CloseTimeout,
+		status: redis.Status{
+			Name: config.Endpoint,
+		},
 		pool: rgo.Pool{
 			MaxIdle:     config.MaxIdle,
+			MaxActive:   config.MaxActive,
+			Wait:        config.Wait,
 			IdleTimeout: time.Duration(config.Timeout) * time.Second,
 			Dial: func() (rgo.Conn, error) {
 				return rgo.Dial(
@@ -68,33 +78,33 @@ func (c *credis) Expire(key string, seconds int) error {
 	return c.cmd("EXPIRE", args...).Error
 }
 
+// Incr increments every key by one in a single pipeline
 func (c *credis) Incr(keys ...string) error {
-	args := make([]interface{}, len(keys))
-	for i, v := range keys {
-		args[i] = v
-	}
-	return c.cmd("INCR", args...).Error
+	return c.pipe("INCR", keys)
 }
 
 func (c *credis) IncrSingle(keys string) (int, error) {
+	if err := c.begin(); err != nil {
+		return 0, err
+	}
+	defer c.inflight.Done()
+
 	conn := c.pool.Get()
 	defer conn.Close()
 	conn.Send("MULTI")
 	conn.Send("INCR", keys)
-	r, err := conn.Do("EXEC")
+	r, err := rgo.Values(conn.Do("EXEC"))
 	if err != nil {
 		return 0, err
 	}
 
-	return convert.ToInt(r), nil
+	// Errors of queued commands are returned inside the EXEC reply
+	return rgo.Int(r[0], nil)
 }
 
+// Decr decrements every key by one in a single pipeline
 func (c *credis) Decr(keys ...string) error {
-	args := make([]interface{}, len(keys))
-	for i, v := range keys {
-		args[i] = v
-	}
-	return c.cmd("DECR", args...).Error
+	return c.pipe("DECR", keys)
 }
 
 // HDel is used to delete multiple fields
@@ -192,6 +202,12 @@ func (c *credis) HExists(key string, fieldKey string) *redis.Result {
 
 func (c *credis) cmd(command string, args ...interface{}) *redis.Result {
 	result := &redis.Result{}
+	if err := c.begin(); err != nil {
+		result.Error = err
+		return result
+	}
+	defer c.inflight.Done()
+
 	conn := c.pool.Get()
 	defer conn.Close()
 
@@ -203,6 +219,7 @@ func (c *credis) cmd(command string, args ...interface{}) *redis.Result {
 		defer conn2.Close()
 		data, err = conn2.Do(command, args...)
 		if err != nil {
+			c.fail(err)
 			result.Error = err
 			return result
 		}
@@ -212,20 +229,96 @@ func (c *credis) cmd(command string, args ...interface{}) *redis.Result {
 	return result
 }
 
+// pipe sends command once per key in a single round trip, returns the first error replied
+func (c *credis) pipe(command string, keys []string) error {
+	if err := c.begin(); err != nil {
+		return err
+	}
+	defer c.inflight.Done()
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	for _, key := range keys {
+		if err := conn.Send(command, key); err != nil {
+			c.fail(err)
+			return err
+		}
+	}
+
+	if err := conn.Flush(); err != nil {
+		c.fail(err)
+		return err
+	}
+
+	// Every reply must be read even after an error
+	var firstErr error
+	for range keys {
+		if _, err := conn.Receive(); err != nil && firstErr == nil {
+			c.fail(err)
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// pipeReplies sends command once per argument list in a single round trip,
+// returns the replies in order and the first error replied
+func (c *credis) pipeReplies(command string, args [][]interface{}) *redis.Result {
+	result := &redis.Result{}
+	if err := c.begin(); err != nil {
+		result.Error = err
+		return result
+	}
+	defer c.inflight.Done()
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	for _, a := range args {
+		if err := conn.Send(command, a...); err != nil {
+			c.fail(err)
+			result.Error = err
+			return result
+		}
+	}
+
+	if err := conn.Flush(); err != nil {
+		c.fail(err)
+		result.Error = err
+		return result
+	}
+
+	// Every reply must be read even after an error
+	replies := make([]interface{}, len(args))
+	for i := range args {
+		reply, err := conn.Receive()
+		if err != nil && result.Error == nil {
+			c.fail(err)
+			result.Error = err
+		}
+		replies[i] = reply
+	}
+	result.Value = replies
+
+	return result
+}
+
 func (r *credis) Set(key, value interface{}, args ...interface{}) *redis.Result {
 	args = append([]interface{}{key, value}, args...)
 	return r.cmd("SET", args...)
 }
 
 // Insert all the specified values at the Head of the list stored at key
-func (c *credis) LPush(key string, value interface{}) error {
-	args := []interface{}{key, value}
+func (c *credis) LPush(key string, values ...interface{}) error {
+	args := append([]interface{}{key}, values...)
 	return c.cmd("LPUSH", args...).Error
 }
 
 // Insert all the specified values at the tail of the list stored at key
-func (c *credis) RPush(key string, value interface{}) error {
-	args := []interface{}{key, value}
+func (c *credis) RPush(key string, values ...interface{}) error {
+	args := append([]interface{}{key}, values...)
 	return c.cmd("RPUSH", args...).Error
 }
 
@@ -252,3 +345,31 @@ func (c *credis) Scan(cursor int, match string, count int) *redis.Result {
 		return c.cmd("SCAN", cursor, "COUNT", count, "MATCH", match)
 	}
 }
+
+// return cursor and a page of keys of type keyType, e.g. "string", "hash", "zset"
+func (c *credis) ScanType(cursor int, match string, count int, keyType string) *redis.Result {
+	if count == 0 {
+		count = 10
+	}
+	args := []interface{}{cursor, "COUNT", count}
+	if match != "" {
+		args = append(args, "MATCH", match)
+	}
+	return c.cmd("SCAN", append(args, "TYPE", keyType)...)
+}
+
+// Removes the keys without blocking the server, the memory is reclaimed in the background.
+// Returns the number of removed keys
+func (c *credis) Unlink(keys ...string) *redis.Result {
+	return c.cmd("UNLINK", keysArgs(keys)...)
+}
+
+// keysArgs converts keys into command arguments placed after the leading arguments
+func keysArgs(keys []string, leading ...interface{}) []interface{} {
+	args := make([]interface{}, 0, len(leading)+len(keys))
+	args = append(args, leading...)
+	for _, v := range keys {
+		args = append(args, v)
+	}
+	return args
+}
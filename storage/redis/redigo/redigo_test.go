@@ -7,8 +7,17 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/5112100070/publib/storage/redis"
 	. "github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/5112100070/publib/storage/redis/redistest"
 )
 
+func TestConformance(t *testing.T) {
+	addr := redistest.StartServer(t)
+
+	redistest.RunSuite(t, func(t *testing.T) redis.Redis {
+		return New(Config{Endpoint: addr})
+	})
+}
+
 func TestGet(t *testing.T) {
 
 	cfg := Config{
@@ -323,3 +332,23 @@ func TestScan(t *testing.T) {
 
 	assert.EqualError(t, c.Scan(0, "test", 8000).Error, "dial tcp: address null: missing port in address")
 }
+
+func TestScanType(t *testing.T) {
+	cfg := Config{
+		Endpoint: "null",
+	}
+
+	c := New(cfg)
+
+	assert.EqualError(t, c.ScanType(0, "test", 8000, "hash").Error, "dial tcp: address null: missing port in address")
+}
+
+func TestUnlink(t *testing.T) {
+	cfg := Config{
+		Endpoint: "null",
+	}
+
+	c := New(cfg)
+
+	assert.EqualError(t, c.Unlink("foo", "bar").Error, "dial tcp: address null: missing port in address")
+}
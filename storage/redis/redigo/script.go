@@ -0,0 +1,10 @@
+package redigo
+
+import (
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Runs the Lua script atomically, keys are passed as KEYS and args as ARGV
+func (c *credis) Eval(script string, keys []string, args ...interface{}) *redis.Result {
+	return c.cmd("EVAL", append(keysArgs(keys, script, len(keys)), args...)...)
+}
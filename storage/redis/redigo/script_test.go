@@ -0,0 +1,20 @@
+package redigo_test
+
+import (
+	"testing"
+
+	. "github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScriptCommands(t *testing.T) {
+
+	cfg := Config{
+		Endpoint: "null",
+	}
+	c := New(cfg)
+
+	errDial := "dial tcp: address null: missing port in address"
+
+	assert.EqualError(t, c.Eval("return 1", []string{"foo"}, "a").Error, errDial)
+}
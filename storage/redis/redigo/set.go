@@ -0,0 +1,84 @@
+package redigo
+
+import (
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Adds the members to the set, returns the number of members that were added
+func (c *credis) SAdd(key string, members ...interface{}) *redis.Result {
+	args := append([]interface{}{key}, members...)
+	return c.cmd("SADD", args...)
+}
+
+// Removes the members from the set, returns the number of members that were removed
+func (c *credis) SRem(key string, members ...interface{}) *redis.Result {
+	args := append([]interface{}{key}, members...)
+	return c.cmd("SREM", args...)
+}
+
+// Returns all the members of the set, read them with Result.StringSlice or Result.StringSet
+func (c *credis) SMembers(key string) *redis.Result {
+	return c.cmd("SMEMBERS", key)
+}
+
+// Returns whether member belongs to the set, read it with Result.Bool
+func (c *credis) SIsMember(key string, member interface{}) *redis.Result {
+	return c.cmd("SISMEMBER", key, member)
+}
+
+// Returns whether each member belongs to the set, read them with Result.BoolSlice
+func (c *credis) SMIsMember(key string, members ...interface{}) *redis.Result {
+	args := append([]interface{}{key}, members...)
+	return c.cmd("SMISMEMBER", args...)
+}
+
+// Returns the number of members of the set
+func (c *credis) SCard(key string) *redis.Result {
+	return c.cmd("SCARD", key)
+}
+
+// Removes and returns random members of the set.
+// A single member is returned when count is zero, otherwise a list of up to count members
+func (c *credis) SPop(key string, count int) *redis.Result {
+	if count > 0 {
+		return c.cmd("SPOP", key, count)
+	}
+	return c.cmd("SPOP", key)
+}
+
+// Returns random members of the set without removing them.
+// A single member is returned when count is zero, otherwise a list of members
+// which may contain duplicates when count is negative
+func (c *credis) SRandMember(key string, count int) *redis.Result {
+	if count != 0 {
+		return c.cmd("SRANDMEMBER", key, count)
+	}
+	return c.cmd("SRANDMEMBER", key)
+}
+
+func (c *credis) SInter(keys ...string) *redis.Result {
+	return c.cmd("SINTER", keysArgs(keys)...)
+}
+
+func (c *credis) SUnion(keys ...string) *redis.Result {
+	return c.cmd("SUNION", keysArgs(keys)...)
+}
+
+func (c *credis) SDiff(keys ...string) *redis.Result {
+	return c.cmd("SDIFF", keysArgs(keys)...)
+}
+
+// Stores the intersection of the sets in destination, returns the number of members stored
+func (c *credis) SInterStore(destination string, keys ...string) *redis.Result {
+	return c.cmd("SINTERSTORE", keysArgs(keys, destination)...)
+}
+
+// Stores the union of the sets in destination, returns the number of members stored
+func (c *credis) SUnionStore(destination string, keys ...string) *redis.Result {
+	return c.cmd("SUNIONSTORE", keysArgs(keys, destination)...)
+}
+
+// Stores the difference of the sets in destination, returns the number of members stored
+func (c *credis) SDiffStore(destination string, keys ...string) *redis.Result {
+	return c.cmd("SDIFFSTORE", keysArgs(keys, destination)...)
+}
@@ -0,0 +1,33 @@
+package redigo_test
+
+import (
+	"testing"
+
+	. "github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetCommands(t *testing.T) {
+
+	cfg := Config{
+		Endpoint: "null",
+	}
+	c := New(cfg)
+
+	errDial := "dial tcp: address null: missing port in address"
+
+	assert.EqualError(t, c.SAdd("test", "foo", "bar").Error, errDial)
+	assert.EqualError(t, c.SRem("test", "foo").Error, errDial)
+	assert.Nil(t, c.SMembers("test").StringSet())
+	assert.False(t, c.SIsMember("test", "foo").Bool())
+	assert.Nil(t, c.SMIsMember("test", "foo", "bar").BoolSlice())
+	assert.Equal(t, 0, c.SCard("test").Int())
+	assert.EqualError(t, c.SPop("test", 0).Error, errDial)
+	assert.EqualError(t, c.SRandMember("test", -2).Error, errDial)
+	assert.Nil(t, c.SInter("foo", "bar").StringSlice())
+	assert.Nil(t, c.SUnion("foo", "bar").StringSlice())
+	assert.Nil(t, c.SDiff("foo", "bar").StringSlice())
+	assert.EqualError(t, c.SInterStore("test", "foo", "bar").Error, errDial)
+	assert.EqualError(t, c.SUnionStore("test", "foo", "bar").Error, errDial)
+	assert.EqualError(t, c.SDiffStore("test", "foo", "bar").Error, errDial)
+}
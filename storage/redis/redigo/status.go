@@ -0,0 +1,121 @@
+package redigo
+
+import (
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+	rgo "github.com/gomodule/redigo/redis"
+)
+
+// Status return redis status
+func (c *credis) Status() redis.Status {
+	c.mu.Lock()
+	heartBeat := c.heartBeat
+	c.mu.Unlock()
+
+	// if heartbeat is not enabled, ping to get status before send status
+	if !heartBeat {
+		c.ping()
+	}
+
+	stats := c.pool.Stats()
+
+	c.mu.Lock()
+	status := c.status
+	c.mu.Unlock()
+
+	status.ActiveCount = stats.ActiveCount
+	status.IdleCount = stats.IdleCount
+	status.WaitCount = stats.WaitCount
+	status.WaitDuration = stats.WaitDuration
+	return status
+}
+
+// DoHeartBeat will automatically spawn a goroutine to ping redis every two seconds, use this carefully
+func (c *credis) DoHeartBeat() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.heartBeat {
+		return
+	}
+
+	ticker := time.NewTicker(time.Second * 2)
+	stop := make(chan bool)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.ping()
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	c.stopBeat = stop
+	c.heartBeat = true
+}
+
+// StopBeat will stop heartbeat, exit from goroutine
+func (c *credis) StopBeat() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.heartBeat {
+		return
+	}
+
+	close(c.stopBeat)
+	c.heartBeat = false
+}
+
+// ping measures the latency and reads the role of the server
+func (c *credis) ping() {
+	if err := c.begin(); err != nil {
+		c.fail(err)
+		return
+	}
+	defer c.inflight.Done()
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	start := time.Now()
+	_, err := conn.Do("PING")
+	latency := time.Since(start)
+	if err != nil {
+		c.fail(err)
+		return
+	}
+
+	// ROLE replies the role first, e.g. master, slave or sentinel
+	var role string
+	if reply, err := rgo.Values(conn.Do("ROLE")); err == nil && len(reply) > 0 {
+		role, _ = rgo.String(reply[0], nil)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.status.Connected = true
+	c.status.LastActive = time.Now().Format(time.RFC1123)
+	c.status.PingLatency = latency
+	if role != "" {
+		c.status.Role = role
+	}
+}
+
+// fail records a connection error, the errors replied by redis do not count
+func (c *credis) fail(err error) {
+	if _, ok := err.(rgo.Error); ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.status.Connected = false
+	c.status.LastError = err.Error()
+}
@@ -0,0 +1,51 @@
+package redigo_test
+
+import (
+	"testing"
+
+	. "github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatus(t *testing.T) {
+
+	cfg := Config{
+		Endpoint: "null",
+	}
+	c := New(cfg)
+
+	errDial := "dial tcp: address null: missing port in address"
+
+	status := c.Status()
+	assert.Equal(t, "null", status.Name)
+	assert.False(t, status.Connected)
+	assert.Equal(t, errDial, status.LastError)
+	assert.Empty(t, status.LastActive)
+
+	c.DoHeartBeat()
+	c.DoHeartBeat()
+	c.StopBeat()
+	c.StopBeat()
+}
+
+func TestStatus_CommandError(t *testing.T) {
+	c := New(Config{Endpoint: "null"})
+
+	c.Get("foo")
+	c.StopBeat()
+
+	// commands record their connection errors as well
+	assert.Equal(t, "dial tcp: address null: missing port in address", c.Status().LastError)
+}
+
+func TestStatus_PipeError(t *testing.T) {
+	c := New(Config{Endpoint: "null"})
+
+	// the heartbeat keeps Status from pinging by itself
+	c.DoHeartBeat()
+	defer c.StopBeat()
+
+	assert.Error(t, c.Incr("foo", "bar"))
+	assert.Equal(t, "dial tcp: address null: missing port in address", c.Status().LastError)
+	assert.False(t, c.Status().Connected)
+}
@@ -0,0 +1,105 @@
+package redigo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Increments the integer value of key by increment, returns the new value
+func (c *credis) IncrBy(key string, increment int64) (int64, error) {
+	res := c.cmd("INCRBY", key, increment)
+	return res.Int64(), res.Error
+}
+
+// Decrements the integer value of key by decrement, returns the new value
+func (c *credis) DecrBy(key string, decrement int64) (int64, error) {
+	res := c.cmd("DECRBY", key, decrement)
+	return res.Int64(), res.Error
+}
+
+// Increments the float value of key by increment, returns the new value
+func (c *credis) IncrByFloat(key string, increment float64) (float64, error) {
+	res := c.cmd("INCRBYFLOAT", key, fmt.Sprintf("%v", increment))
+	return res.Float64(), res.Error
+}
+
+// Sets key only when it does not exist yet, returns whether it was set
+func (c *credis) SetNX(key string, value interface{}) (bool, error) {
+	res := c.cmd("SETNX", key, value)
+	return res.Bool(), res.Error
+}
+
+// Sets key and returns its previous value, redis.ErrNil when it did not exist
+func (c *credis) GetSet(key string, value interface{}) (string, error) {
+	return c.cmd("GETSET", key, value).StringResult()
+}
+
+// Deletes key and returns its value, redis.ErrNil when it did not exist
+func (c *credis) GetDel(key string) (string, error) {
+	return c.cmd("GETDEL", key).StringResult()
+}
+
+// Returns the value of key and updates its expiry, redis.ErrNil when it does not exist.
+// A positive expiration sets the new time to live, a negative one removes the expiry
+// and zero keeps it as is
+func (c *credis) GetEx(key string, expiration time.Duration) (string, error) {
+	switch {
+	case expiration > 0:
+		return c.cmd("GETEX", key, "PX", redis.Milliseconds(expiration)).StringResult()
+	case expiration < 0:
+		return c.cmd("GETEX", key, "PERSIST").StringResult()
+	}
+	return c.cmd("GETEX", key).StringResult()
+}
+
+// Sets all the keys at once
+func (c *credis) MSet(values map[string]interface{}) error {
+	return c.cmd("MSET", pairsArgs(values)...).Error
+}
+
+// Sets all the keys at once only when none of them exists, returns whether they were set
+func (c *credis) MSetNX(values map[string]interface{}) (bool, error) {
+	res := c.cmd("MSETNX", pairsArgs(values)...)
+	return res.Bool(), res.Error
+}
+
+// Appends value to key, returns the new length of the value
+func (c *credis) Append(key string, value interface{}) (int64, error) {
+	res := c.cmd("APPEND", key, value)
+	return res.Int64(), res.Error
+}
+
+// Returns the length of the value of key, zero when it does not exist
+func (c *credis) StrLen(key string) (int64, error) {
+	res := c.cmd("STRLEN", key)
+	return res.Int64(), res.Error
+}
+
+// Returns the substring between start and end, both inclusive
+func (c *credis) GetRange(key string, start, end int) (string, error) {
+	res := c.cmd("GETRANGE", key, start, end)
+	return res.String(), res.Error
+}
+
+// Overwrites the value of key from offset, returns the new length of the value
+func (c *credis) SetRange(key string, offset int, value interface{}) (int64, error) {
+	res := c.cmd("SETRANGE", key, offset, value)
+	return res.Int64(), res.Error
+}
+
+// pairsArgs flattens values into key value arguments
+func pairsArgs(values map[string]interface{}) []interface{} {
+	args := make([]interface{}, 0, len(values)*2)
+	for key, val := range values {
+		args = append(args, key, val)
+	}
+	return args
+}
+
+// Sets the value with typed options, returns whether it was written and the previous value when opts.Get
+func (c *credis) SetWithOptions(key string, value interface{}, opts redis.SetOptions) (bool, string, error) {
+	args := append([]interface{}{key, value}, opts.Args()...)
+	return opts.ParseReply(c.cmd("SET", args...))
+}
@@ -0,0 +1,50 @@
+package redigo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+	. "github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringCommands(t *testing.T) {
+
+	cfg := Config{
+		Endpoint: "null",
+	}
+	c := New(cfg)
+
+	errDial := "dial tcp: address null: missing port in address"
+
+	_, err := c.IncrBy("test", 2)
+	assert.EqualError(t, err, errDial)
+	_, err = c.DecrBy("test", 2)
+	assert.EqualError(t, err, errDial)
+	_, err = c.IncrByFloat("test", 1.5)
+	assert.EqualError(t, err, errDial)
+	ok, err := c.SetNX("test", "foo")
+	assert.False(t, ok)
+	assert.EqualError(t, err, errDial)
+	_, err = c.GetSet("test", "foo")
+	assert.EqualError(t, err, errDial)
+	_, err = c.GetDel("test")
+	assert.EqualError(t, err, errDial)
+	_, err = c.GetEx("test", time.Second)
+	assert.EqualError(t, err, errDial)
+	assert.EqualError(t, c.MSet(map[string]interface{}{"foo": 1}), errDial)
+	_, err = c.MSetNX(map[string]interface{}{"foo": 1})
+	assert.EqualError(t, err, errDial)
+	_, err = c.Append("test", "foo")
+	assert.EqualError(t, err, errDial)
+	_, err = c.StrLen("test")
+	assert.EqualError(t, err, errDial)
+	_, err = c.GetRange("test", 0, -1)
+	assert.EqualError(t, err, errDial)
+	_, err = c.SetRange("test", 1, "foo")
+	assert.EqualError(t, err, errDial)
+	_, _, err = c.SetWithOptions("test", "foo", redis.SetOptions{NX: true})
+	assert.EqualError(t, err, errDial)
+	assert.EqualError(t, c.Incr("foo", "bar"), errDial)
+}
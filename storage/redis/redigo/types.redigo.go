@@ -1,12 +1,28 @@
 package redigo
 
 import (
+	"sync"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
 	rgo "github.com/gomodule/redigo/redis"
 )
 
 type credis struct {
 	// config Config
-	pool   rgo.Pool
+	pool rgo.Pool
+
+	// for stats
+	mu        sync.Mutex
+	status    redis.Status
+	heartBeat bool
+	stopBeat  chan bool
+
+	// for close
+	closeMu      sync.RWMutex
+	closed       bool
+	inflight     sync.WaitGroup
+	closeTimeout time.Duration
 }
 
 // Config of redis module
@@ -14,4 +30,11 @@ type Config struct {
 	Endpoint string
 	Timeout  int
 	MaxIdle  int
+	// MaxActive limits the connections of the pool, unlimited when zero
+	MaxActive int
+	// Wait for a free connection when MaxActive is reached instead of failing,
+	// the waits are reported by Status
+	Wait bool
+	// CloseTimeout is how long Close waits for the in-flight commands, default is 5 seconds
+	CloseTimeout time.Duration
 }
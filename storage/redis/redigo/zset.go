@@ -0,0 +1,111 @@
+package redigo
+
+import (
+	"fmt"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Removes the specified members from the sorted set, returns the number of removed members
+func (c *credis) ZRem(key string, members ...interface{}) *redis.Result {
+	args := append([]interface{}{key}, members...)
+	return c.cmd("ZREM", args...)
+}
+
+// Increments the score of member, returns the new score
+func (c *credis) ZIncrBy(key string, increment float64, member interface{}) *redis.Result {
+	return c.cmd("ZINCRBY", key, fmt.Sprintf("%v", increment), member)
+}
+
+// Returns the score of member, nil when the member does not exist
+func (c *credis) ZScore(key string, member interface{}) *redis.Result {
+	return c.cmd("ZSCORE", key, member)
+}
+
+// Returns the rank of member ordered from low to high score, nil when the member does not exist
+func (c *credis) ZRank(key string, member interface{}) *redis.Result {
+	return c.cmd("ZRANK", key, member)
+}
+
+// Returns the rank of member ordered from high to low score, nil when the member does not exist
+func (c *credis) ZRevRank(key string, member interface{}) *redis.Result {
+	return c.cmd("ZREVRANK", key, member)
+}
+
+func (c *credis) ZRevRange(key string, start, stop int) *redis.Result {
+	return c.cmd("ZREVRANGE", key, start, stop)
+}
+
+func (c *credis) ZRevRangeByScore(key, max, min string, limit int) *redis.Result {
+	return c.cmd("ZREVRANGEBYSCORE", byScoreArgs(key, max, min, limit, false)...)
+}
+
+func (c *credis) ZRangeWithScores(key string, start, stop int) *redis.Result {
+	return c.cmd("ZRANGE", key, start, stop, "WITHSCORES")
+}
+
+func (c *credis) ZRevRangeWithScores(key string, start, stop int) *redis.Result {
+	return c.cmd("ZREVRANGE", key, start, stop, "WITHSCORES")
+}
+
+func (c *credis) ZRangeByScoreWithScores(key, min, max string, limit int) *redis.Result {
+	return c.cmd("ZRANGEBYSCORE", byScoreArgs(key, min, max, limit, true)...)
+}
+
+func (c *credis) ZRevRangeByScoreWithScores(key, max, min string, limit int) *redis.Result {
+	return c.cmd("ZREVRANGEBYSCORE", byScoreArgs(key, max, min, limit, true)...)
+}
+
+// Returns the number of members of the sorted set
+func (c *credis) ZCard(key string) *redis.Result {
+	return c.cmd("ZCARD", key)
+}
+
+// Returns the number of members with a score between min and max
+func (c *credis) ZCount(key, min, max string) *redis.Result {
+	return c.cmd("ZCOUNT", key, min, max)
+}
+
+// Removes all members with a score between min and max, returns the number of removed members
+func (c *credis) ZRemRangeByScore(key, min, max string) *redis.Result {
+	return c.cmd("ZREMRANGEBYSCORE", key, min, max)
+}
+
+// Removes all members within the given rank range, returns the number of removed members
+func (c *credis) ZRemRangeByRank(key string, start, stop int) *redis.Result {
+	return c.cmd("ZREMRANGEBYRANK", key, start, stop)
+}
+
+// Removes and returns up to count members with the lowest scores, read them with Result.ZSlice
+func (c *credis) ZPopMin(key string, count int) *redis.Result {
+	if count > 0 {
+		return c.cmd("ZPOPMIN", key, count)
+	}
+	return c.cmd("ZPOPMIN", key)
+}
+
+// Removes and returns up to count members with the highest scores, read them with Result.ZSlice
+func (c *credis) ZPopMax(key string, count int) *redis.Result {
+	if count > 0 {
+		return c.cmd("ZPOPMAX", key, count)
+	}
+	return c.cmd("ZPOPMAX", key)
+}
+
+// Stores the union of the sorted sets in destination, returns the number of members stored
+func (c *credis) ZUnionStore(destination string, store redis.ZStore) *redis.Result {
+	args := append([]interface{}{destination}, store.Args()...)
+	return c.cmd("ZUNIONSTORE", args...)
+}
+
+// byScoreArgs builds the arguments of ZRANGEBYSCORE and ZREVRANGEBYSCORE
+func byScoreArgs(key, from, to string, limit int, withScores bool) []interface{} {
+	args := []interface{}{key, from, to}
+	if withScores {
+		args = append(args, "WITHSCORES")
+	}
+	if limit > 0 {
+		args = append(args, "LIMIT", 0, limit)
+	}
+	return args
+}
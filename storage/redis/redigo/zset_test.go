@@ -0,0 +1,38 @@
+package redigo_test
+
+import (
+	"testing"
+
+	"github.com/5112100070/publib/storage/redis"
+	. "github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZSetCommands(t *testing.T) {
+
+	cfg := Config{
+		Endpoint: "null",
+	}
+	c := New(cfg)
+
+	errDial := "dial tcp: address null: missing port in address"
+
+	assert.EqualError(t, c.ZRem("test", "foo").Error, errDial)
+	assert.EqualError(t, c.ZIncrBy("test", 1, "foo").Error, errDial)
+	assert.EqualError(t, c.ZScore("test", "foo").Error, errDial)
+	assert.EqualError(t, c.ZRank("test", "foo").Error, errDial)
+	assert.EqualError(t, c.ZRevRank("test", "foo").Error, errDial)
+	assert.Nil(t, c.ZRevRange("test", 0, -1).StringSlice())
+	assert.Nil(t, c.ZRevRangeByScore("test", "+inf", "-inf", 10).StringSlice())
+	assert.Nil(t, c.ZRangeWithScores("test", 0, -1).ZSlice())
+	assert.Nil(t, c.ZRevRangeWithScores("test", 0, -1).ZSlice())
+	assert.Nil(t, c.ZRangeByScoreWithScores("test", "-inf", "+inf", 0).ZSlice())
+	assert.Nil(t, c.ZRevRangeByScoreWithScores("test", "+inf", "-inf", 0).ZSlice())
+	assert.Equal(t, 0, c.ZCard("test").Int())
+	assert.Equal(t, 0, c.ZCount("test", "-inf", "+inf").Int())
+	assert.EqualError(t, c.ZRemRangeByScore("test", "-inf", "+inf").Error, errDial)
+	assert.EqualError(t, c.ZRemRangeByRank("test", 0, -1).Error, errDial)
+	assert.Nil(t, c.ZPopMin("test", 1).ZSlice())
+	assert.Nil(t, c.ZPopMax("test", 0).ZSlice())
+	assert.EqualError(t, c.ZUnionStore("dest", redis.ZStore{Keys: []string{"a", "b"}}).Error, errDial)
+}
@@ -0,0 +1,55 @@
+// Package redistest offers a conformance suite for redis.Redis implementations.
+//
+// The suite only talks to the implementation through the redis.Redis interface,
+// so any client that is backed by a real keyspace (redigo, decorators, sharded
+// clients, ...) can be checked with it:
+//
+//	func TestConformance(t *testing.T) {
+//		addr := redistest.StartServer(t)
+//		redistest.RunSuite(t, func(t *testing.T) redis.Redis {
+//			return redigo.New(redigo.Config{Endpoint: addr})
+//		})
+//	}
+//
+// Mock based implementations such as dummyrds do not keep any state and are
+// covered by their own tests instead.
+package redistest
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Factory returns the implementation under test.
+// Every test case namespaces its keys with the process and test name, so the
+// returned client may share a server with other test cases and packages, it is
+// closed when the test case completes.
+type Factory func(t *testing.T) redis.Redis
+
+type testCase struct {
+	name string
+	fn   func(t *testing.T, rds redis.Redis)
+}
+
+// RunSuite runs every conformance test case against the implementation built by factory
+func RunSuite(t *testing.T, factory Factory) {
+	for _, tc := range suite {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			rds := factory(t)
+			t.Cleanup(func() { rds.Close() })
+			tc.fn(t, rds)
+		})
+	}
+}
+
+// prefix isolates the keys of concurrently running test binaries
+var prefix = "redistest:" + strconv.Itoa(os.Getpid()) + ":"
+
+// Key namespaces name with the running process and test, for tests sharing a server
+func Key(t testing.TB, name string) string {
+	return prefix + t.Name() + ":" + name
+}
@@ -0,0 +1,152 @@
+package redistest
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	rgo "github.com/gomodule/redigo/redis"
+)
+
+// Environment variables read by StartServer
+const (
+	// EnvServerAddr points the tests to an already running server instead of launching one
+	EnvServerAddr = "REDIS_ADDR"
+	// EnvServerBin overrides the redis-server binary
+	EnvServerBin = "REDIS_SERVER_BIN"
+)
+
+// StartServer launches a throwaway redis-server from a local binary and returns its address.
+// When REDIS_ADDR is set that server is used as is. Otherwise the binary is taken from
+// REDIS_SERVER_BIN or looked up in PATH, and an in-process miniredis is started when
+// none is available, see SkipInProcess.
+// A launched server is killed when the test and all its subtests complete.
+func StartServer(t testing.TB) string {
+	t.Helper()
+
+	if addr := os.Getenv(EnvServerAddr); addr != "" {
+		return addr
+	}
+
+	bin := serverBin()
+	if bin == "" {
+		return startInProcess(t)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		t.Fatalf("redistest: find free port: %v", err)
+	}
+
+	cmd := exec.Command(bin,
+		"--port", strconv.Itoa(port),
+		"--bind", "127.0.0.1",
+		"--save", "",
+		"--appendonly", "no",
+	)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("redistest: start %s: %v", bin, err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+	if err := waitReady(addr, 5*time.Second); err != nil {
+		t.Fatalf("redistest: %s not ready: %v", bin, err)
+	}
+
+	return addr
+}
+
+// SkipInProcess skips t when StartServer emulates the servers in process,
+// for the tests of the commands miniredis does not implement
+func SkipInProcess(t testing.TB, reason string) {
+	t.Helper()
+
+	if os.Getenv(EnvServerAddr) == "" && serverBin() == "" {
+		t.Skip("redistest: not supported by the in-process server, " + reason)
+	}
+}
+
+var (
+	binOnce sync.Once
+	binPath string
+)
+
+// serverBin returns the redis-server binary, empty when none is available
+func serverBin() string {
+	binOnce.Do(func() {
+		if binPath = os.Getenv(EnvServerBin); binPath == "" {
+			binPath, _ = exec.LookPath("redis-server")
+		}
+	})
+
+	return binPath
+}
+
+// startInProcess starts a miniredis whose clock follows the wall clock, so that keys expire
+func startInProcess(t testing.TB) string {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("redistest: start miniredis: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+
+		last := time.Now()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				s.FastForward(now.Sub(last))
+				last = now
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		close(done)
+		s.Close()
+	})
+
+	return s.Addr()
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// waitReady pings addr until the server answers or timeout is reached
+func waitReady(addr string, timeout time.Duration) (err error) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		var conn rgo.Conn
+		conn, err = rgo.Dial("tcp", addr)
+		if err == nil {
+			_, err = conn.Do("PING")
+			conn.Close()
+			if err == nil {
+				return nil
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	return err
+}
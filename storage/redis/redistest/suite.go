@@ -0,0 +1,444 @@
+package redistest
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/5112100070/publib/storage/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var suite = []testCase{
+	{"Ping", testPing},
+	{"Get", testGet},
+	{"MGet", testMGet},
+	{"Setex", testSetex},
+	{"Expire", testExpire},
+	{"Ttl", testTtl},
+	{"Del", testDel},
+	{"Exists", testExists},
+	{"Rename", testRename},
+	{"Set", testSet},
+	{"Incr", testIncr},
+	{"IncrSingle", testIncrSingle},
+	{"Decr", testDecr},
+	{"IncrMultiple", testIncrMultiple},
+	{"IncrBy", testIncrBy},
+	{"SetNX", testSetNX},
+	{"SetWithOptions", testSetWithOptions},
+	{"GetSet", testGetSet},
+	{"MSet", testMSet},
+	{"Append", testAppend},
+	{"HSet", testHSet},
+	{"HMSet", testHMSet},
+	{"HDel", testHDel},
+	{"HExists", testHExists},
+	{"HIncrBy", testHIncrBy},
+	{"HSetNX", testHSetNX},
+	{"HMGet", testHMGet},
+	{"HScan", testHScan},
+	{"ZAdd", testZAdd},
+	{"ZRangeByScore", testZRangeByScore},
+	{"ZRem", testZRem},
+	{"ZIncrBy", testZIncrBy},
+	{"ZRank", testZRank},
+	{"ZRevRange", testZRevRange},
+	{"ZWithScores", testZWithScores},
+	{"ZCount", testZCount},
+	{"ZRemRange", testZRemRange},
+	{"ZPop", testZPop},
+	{"ZUnionStore", testZUnionStore},
+	{"SAdd", testSAdd},
+	{"SIsMember", testSIsMember},
+	{"SPop", testSPop},
+	{"SRandMember", testSRandMember},
+	{"SetOperations", testSetOperations},
+	{"List", testList},
+	{"PushVariadic", testPushVariadic},
+	{"LRange", testLRange},
+	{"LRem", testLRem},
+	{"BLPop", testBLPop},
+	{"BLMove", testBLMove},
+	{"Scan", testScan},
+	{"ScanType", testScanType},
+	{"Unlink", testUnlink},
+	{"DumpRestore", testDumpRestore},
+	{"ByPattern", testByPattern},
+	{"SetBit", testSetBit},
+	{"BitCount", testBitCount},
+	{"BitOp", testBitOp},
+	{"BitField", testBitField},
+	{"Bitmask", testBitmask},
+	{"PFAdd", testPFAdd},
+	{"PFMerge", testPFMerge},
+	{"GeoPos", testGeoPos},
+	{"PExpire", testPExpire},
+	{"ExpireAt", testExpireAt},
+	{"GeoSearch", testGeoSearch},
+	{"Eval", testEval},
+	{"WrongType", testWrongType},
+	{"Status", testStatus},
+	{"Close", testClose},
+}
+
+func testPing(t *testing.T, rds redis.Redis) {
+	res := rds.Ping()
+	require.NoError(t, res.Error)
+	assert.Equal(t, "PONG", res.String())
+}
+
+func testStatus(t *testing.T, rds redis.Redis) {
+	SkipInProcess(t, "ROLE")
+	rds.Ping()
+
+	status := rds.Status()
+	assert.True(t, status.Connected)
+	assert.Equal(t, "master", status.Role)
+	assert.NotEmpty(t, status.LastActive)
+	assert.True(t, status.PingLatency > 0)
+}
+
+func testClose(t *testing.T, rds redis.Redis) {
+	foo := Key(t, "foo")
+	require.NoError(t, rds.Set(foo, "bar").Error)
+
+	require.NoError(t, rds.Close())
+	assert.Equal(t, redis.ErrClosed, rds.Get(foo).Error)
+	assert.Equal(t, redis.ErrClosed, rds.Del(foo))
+	assert.Equal(t, redis.ErrClosed, rds.Incr(foo))
+	assert.False(t, rds.Status().Connected)
+	assert.Equal(t, redis.ErrClosed, rds.Close(), "closing twice")
+}
+
+func testGet(t *testing.T, rds redis.Redis) {
+	foo := Key(t, "foo")
+
+	// nil reply
+	res := rds.Get(foo)
+	require.NoError(t, res.Error)
+	assert.Nil(t, res.Value)
+	assert.Equal(t, "", res.String())
+	assert.Nil(t, res.Bytes())
+
+	require.NoError(t, rds.Set(foo, "bar").Error)
+	assert.Equal(t, "bar", rds.Get(foo).String())
+	assert.Equal(t, []byte("bar"), rds.Get(foo).Bytes())
+}
+
+func testMGet(t *testing.T, rds redis.Redis) {
+	foo, bar, none := Key(t, "foo"), Key(t, "bar"), Key(t, "none")
+
+	require.NoError(t, rds.Set(foo, "1").Error)
+	require.NoError(t, rds.Set(bar, "2").Error)
+
+	res := rds.MGet(foo, none, bar)
+	require.NoError(t, res.Error)
+	assert.Equal(t, []string{"1", "", "2"}, res.StringSlice())
+
+	values := res.ByteSlice()
+	require.Len(t, values, 3)
+	assert.Nil(t, values[1])
+}
+
+func testSetex(t *testing.T, rds redis.Redis) {
+	foo := Key(t, "foo")
+
+	require.NoError(t, rds.Setex(foo, 100, "bar"))
+	assert.Equal(t, "bar", rds.Get(foo).String())
+
+	ttl := rds.Ttl(foo).Int()
+	assert.True(t, ttl > 0 && ttl <= 100, "ttl %d out of range", ttl)
+
+	assert.Error(t, rds.Setex(foo, 0, "bar"), "zero expire time must be rejected")
+}
+
+func testExpire(t *testing.T, rds redis.Redis) {
+	foo := Key(t, "foo")
+
+	// expiring a missing key is not an error
+	assert.NoError(t, rds.Expire(foo, 100))
+	assert.Equal(t, -2, rds.Ttl(foo).Int())
+
+	require.NoError(t, rds.Set(foo, "bar").Error)
+	require.NoError(t, rds.Expire(foo, 100))
+
+	ttl := rds.Ttl(foo).Int()
+	assert.True(t, ttl > 0 && ttl <= 100, "ttl %d out of range", ttl)
+}
+
+func testTtl(t *testing.T, rds redis.Redis) {
+	foo := Key(t, "foo")
+
+	// -2 when the key does not exist
+	res := rds.Ttl(foo)
+	require.NoError(t, res.Error)
+	assert.Equal(t, -2, res.Int())
+
+	// -1 when the key exists without expiry
+	require.NoError(t, rds.Set(foo, "bar").Error)
+	assert.Equal(t, -1, rds.Ttl(foo).Int())
+}
+
+func testDel(t *testing.T, rds redis.Redis) {
+	foo, bar := Key(t, "foo"), Key(t, "bar")
+
+	// deleting missing keys is not an error
+	assert.NoError(t, rds.Del(foo))
+
+	require.NoError(t, rds.Set(foo, "1").Error)
+	require.NoError(t, rds.Set(bar, "2").Error)
+	require.NoError(t, rds.Del(foo, bar))
+
+	assert.Equal(t, 0, rds.Exists(foo).Int())
+	assert.Equal(t, 0, rds.Exists(bar).Int())
+}
+
+func testExists(t *testing.T, rds redis.Redis) {
+	foo := Key(t, "foo")
+
+	res := rds.Exists(foo)
+	require.NoError(t, res.Error)
+	assert.Equal(t, 0, res.Int())
+
+	require.NoError(t, rds.Set(foo, "bar").Error)
+	assert.Equal(t, 1, rds.Exists(foo).Int())
+}
+
+func testRename(t *testing.T, rds redis.Redis) {
+	foo, bar := Key(t, "{rename}foo"), Key(t, "{rename}bar")
+
+	assert.Error(t, rds.Rename(foo, bar).Error, "renaming a missing key must fail")
+
+	require.NoError(t, rds.Set(foo, "1").Error)
+	res := rds.Rename(foo, bar)
+	require.NoError(t, res.Error)
+	assert.Equal(t, "OK", res.String())
+	assert.Equal(t, 0, rds.Exists(foo).Int())
+	assert.Equal(t, "1", rds.Get(bar).String())
+}
+
+func testSet(t *testing.T, rds redis.Redis) {
+	foo := Key(t, "foo")
+
+	res := rds.Set(foo, "bar")
+	require.NoError(t, res.Error)
+	assert.Equal(t, "OK", res.String())
+
+	// NX on an existing key is a nil reply
+	res = rds.Set(foo, "baz", "NX")
+	require.NoError(t, res.Error)
+	assert.Nil(t, res.Value)
+	assert.Equal(t, "bar", rds.Get(foo).String())
+
+	require.NoError(t, rds.Set(foo, "baz", "EX", 100).Error)
+	assert.Equal(t, "baz", rds.Get(foo).String())
+	ttl := rds.Ttl(foo).Int()
+	assert.True(t, ttl > 0 && ttl <= 100, "ttl %d out of range", ttl)
+
+	// a plain SET clears the expiry
+	require.NoError(t, rds.Set(foo, "bar").Error)
+	assert.Equal(t, -1, rds.Ttl(foo).Int())
+}
+
+func testIncr(t *testing.T, rds redis.Redis) {
+	foo := Key(t, "foo")
+
+	require.NoError(t, rds.Incr(foo))
+	require.NoError(t, rds.Incr(foo))
+	assert.Equal(t, 2, rds.Get(foo).Int())
+
+	require.NoError(t, rds.Set(foo, "bar").Error)
+	assert.Error(t, rds.Incr(foo), "incrementing a non integer must fail")
+}
+
+func testIncrSingle(t *testing.T, rds redis.Redis) {
+	foo := Key(t, "foo")
+
+	val, err := rds.IncrSingle(foo)
+	require.NoError(t, err)
+	assert.Equal(t, 1, val)
+
+	val, err = rds.IncrSingle(foo)
+	require.NoError(t, err)
+	assert.Equal(t, 2, val)
+
+	require.NoError(t, rds.Set(foo, "bar").Error)
+	val, err = rds.IncrSingle(foo)
+	assert.Error(t, err, "incrementing a non integer must fail")
+	assert.Equal(t, 0, val)
+}
+
+func testDecr(t *testing.T, rds redis.Redis) {
+	foo := Key(t, "foo")
+
+	require.NoError(t, rds.Decr(foo))
+	assert.Equal(t, -1, rds.Get(foo).Int())
+
+	require.NoError(t, rds.Set(foo, "bar").Error)
+	assert.Error(t, rds.Decr(foo), "decrementing a non integer must fail")
+}
+
+func testHSet(t *testing.T, rds redis.Redis) {
+	hash := Key(t, "hash")
+
+	// nil reply on missing hash and field
+	res := rds.HGet(hash, "foo")
+	require.NoError(t, res.Error)
+	assert.Nil(t, res.Value)
+
+	require.NoError(t, rds.HSet(hash, "foo", "1"))
+	require.NoError(t, rds.HSet(hash, "bar", 2))
+
+	assert.Equal(t, "1", rds.HGet(hash, "foo").String())
+	assert.Equal(t, 2, rds.HGet(hash, "bar").Int())
+	assert.Nil(t, rds.HGet(hash, "none").Value)
+
+	assert.ElementsMatch(t, []string{"foo", "bar"}, rds.HKeys(hash).StringSlice())
+	assert.ElementsMatch(t, []string{"1", "2"}, rds.HVals(hash).StringSlice())
+	assert.Equal(t, map[string]string{"foo": "1", "bar": "2"}, pairs(rds.HGetAll(hash).StringSlice()))
+}
+
+func testHMSet(t *testing.T, rds redis.Redis) {
+	hash := Key(t, "hash")
+
+	require.NoError(t, rds.HMSet(hash, map[string]interface{}{"foo": "1", "bar": 2}))
+	assert.Equal(t, map[string]string{"foo": "1", "bar": "2"}, pairs(rds.HGetAll(hash).StringSlice()))
+
+	// empty replies on a missing hash
+	none := Key(t, "none")
+	assert.Empty(t, rds.HKeys(none).StringSlice())
+	assert.Empty(t, rds.HVals(none).StringSlice())
+	assert.Empty(t, rds.HGetAll(none).StringSlice())
+}
+
+func testHDel(t *testing.T, rds redis.Redis) {
+	hash := Key(t, "hash")
+
+	require.NoError(t, rds.HMSet(hash, map[string]interface{}{"foo": "1", "bar": "2", "baz": "3"}))
+
+	require.NoError(t, rds.HDel(hash, "foo", "bar", "none"))
+	assert.Equal(t, []string{"baz"}, rds.HKeys(hash).StringSlice())
+
+	require.NoError(t, rds.HDelSingle(hash, "baz"))
+	assert.Equal(t, 0, rds.Exists(hash).Int())
+}
+
+func testHExists(t *testing.T, rds redis.Redis) {
+	hash := Key(t, "hash")
+
+	assert.Equal(t, 0, rds.HExists(hash, "foo").Int())
+
+	require.NoError(t, rds.HSet(hash, "foo", "1"))
+	assert.Equal(t, 1, rds.HExists(hash, "foo").Int())
+	assert.Equal(t, 0, rds.HExists(hash, "bar").Int())
+}
+
+func testZAdd(t *testing.T, rds redis.Redis) {
+	zset := Key(t, "zset")
+
+	assert.Empty(t, rds.ZRange(zset, 0, -1).StringSlice())
+
+	require.NoError(t, rds.ZAdd(zset,
+		redis.Z{Score: 3, Member: "c"},
+		redis.Z{Score: 1, Member: "a"},
+		redis.Z{Score: 2.5, Member: "b"},
+	))
+
+	assert.Equal(t, []string{"a", "b", "c"}, rds.ZRange(zset, 0, -1).StringSlice())
+	assert.Equal(t, []string{"b", "c"}, rds.ZRange(zset, 1, 2).StringSlice())
+
+	// updating a score reorders the member
+	require.NoError(t, rds.ZAdd(zset, redis.Z{Score: 0, Member: "c"}))
+	assert.Equal(t, []string{"c", "a", "b"}, rds.ZRange(zset, 0, -1).StringSlice())
+}
+
+func testZRangeByScore(t *testing.T, rds redis.Redis) {
+	zset := Key(t, "zset")
+
+	require.NoError(t, rds.ZAdd(zset,
+		redis.Z{Score: 1, Member: "a"},
+		redis.Z{Score: 2, Member: "b"},
+		redis.Z{Score: 3, Member: "c"},
+		redis.Z{Score: 4, Member: "d"},
+	))
+
+	assert.Equal(t, []string{"a", "b", "c", "d"}, rds.ZRangeByScore(zset, "-inf", "+inf", 0).StringSlice())
+	assert.Equal(t, []string{"b", "c"}, rds.ZRangeByScore(zset, "2", "3", 0).StringSlice())
+	assert.Equal(t, []string{"c"}, rds.ZRangeByScore(zset, "(2", "3", 0).StringSlice())
+	assert.Equal(t, []string{"a", "b"}, rds.ZRangeByScore(zset, "-inf", "+inf", 2).StringSlice())
+	assert.Empty(t, rds.ZRangeByScore(zset, "5", "+inf", 0).StringSlice())
+
+	res := rds.ZRangeByScore(zset, "foo", "bar", 0)
+	assert.Error(t, res.Error, "invalid score range must fail")
+}
+
+func testList(t *testing.T, rds redis.Redis) {
+	list := Key(t, "list")
+
+	// nil reply on empty list
+	res := rds.LPop(list)
+	require.NoError(t, res.Error)
+	assert.Nil(t, res.Value)
+	assert.Equal(t, 0, rds.LLen(list).Int())
+
+	require.NoError(t, rds.RPush(list, "b"))
+	require.NoError(t, rds.RPush(list, "c"))
+	require.NoError(t, rds.LPush(list, "a"))
+	assert.Equal(t, 3, rds.LLen(list).Int())
+
+	assert.Equal(t, "a", rds.LPop(list).String())
+	assert.Equal(t, "b", rds.LPop(list).String())
+	assert.Equal(t, "c", rds.LPop(list).String())
+	assert.Nil(t, rds.LPop(list).Value)
+	assert.Equal(t, 0, rds.Exists(list).Int())
+}
+
+func testScan(t *testing.T, rds redis.Redis) {
+	want := []string{Key(t, "a"), Key(t, "b"), Key(t, "c")}
+	for _, k := range want {
+		require.NoError(t, rds.Set(k, "1").Error)
+	}
+	require.NoError(t, rds.Set(Key(t, "")+"other:d", "1").Error)
+
+	var got []string
+	cursor := 0
+	for {
+		next, keys, err := rds.Scan(cursor, Key(t, "?"), 100).ScanResult()
+		require.NoError(t, err)
+		got = append(got, keys...)
+
+		if next == 0 {
+			break
+		}
+		cursor = next
+	}
+
+	sort.Strings(got)
+	assert.Equal(t, want, got)
+}
+
+func testWrongType(t *testing.T, rds redis.Redis) {
+	str := Key(t, "str")
+	require.NoError(t, rds.Set(str, "bar").Error)
+
+	assert.Error(t, rds.HGet(str, "foo").Error)
+	assert.Error(t, rds.HSet(str, "foo", "1"))
+	assert.Error(t, rds.LPush(str, "foo"))
+	assert.Error(t, rds.LPop(str).Error)
+	assert.Error(t, rds.ZAdd(str, redis.Z{Score: 1, Member: "foo"}))
+	assert.Error(t, rds.ZRange(str, 0, -1).Error)
+
+	// wrong type errors leave the value untouched
+	assert.Equal(t, "bar", rds.Get(str).String())
+}
+
+// pairs converts a flat field/value reply into a map
+func pairs(values []string) map[string]string {
+	res := make(map[string]string, len(values)/2)
+	for i := 0; i+1 < len(values); i += 2 {
+		res[values[i]] = values[i+1]
+	}
+
+	return res
+}
@@ -0,0 +1,110 @@
+package redistest
+
+import (
+	"testing"
+
+	"github.com/5112100070/publib/encoding"
+	"github.com/5112100070/publib/storage/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSetBit(t *testing.T, rds redis.Redis) {
+	bits := Key(t, "bits")
+
+	res := rds.SetBit(bits, 7, 1)
+	require.NoError(t, res.Error)
+	assert.Equal(t, 0, res.Int())
+	assert.Equal(t, 1, rds.SetBit(bits, 7, 1).Int())
+
+	assert.Equal(t, 1, rds.GetBit(bits, 7).Int())
+	assert.Equal(t, 0, rds.GetBit(bits, 6).Int())
+	assert.Equal(t, 0, rds.GetBit(bits, 1000).Int())
+	assert.Equal(t, 0, rds.GetBit(Key(t, "none"), 0).Int())
+	assert.Equal(t, []byte{0x01}, rds.Get(bits).Bytes())
+
+	assert.Error(t, rds.SetBit(bits, 0, 2).Error, "a bit is either 0 or 1")
+}
+
+func testBitCount(t *testing.T, rds redis.Redis) {
+	bits := Key(t, "bits")
+	require.NoError(t, rds.Set(bits, []byte{0x0f, 0x00, 0xff}).Error)
+
+	assert.Equal(t, 12, rds.BitCount(bits).Int())
+	assert.Equal(t, 4, rds.BitCountRange(bits, 0, 1).Int())
+	assert.Equal(t, 8, rds.BitCountRange(bits, -1, -1).Int())
+	assert.Equal(t, 0, rds.BitCount(Key(t, "none")).Int())
+
+	assert.Equal(t, 4, rds.BitPos(bits, 1).Int())
+	assert.Equal(t, 0, rds.BitPos(bits, 0).Int())
+	assert.Equal(t, 16, rds.BitPosRange(bits, 1, 1, 2).Int())
+	assert.Equal(t, -1, rds.BitPosRange(bits, 1, 1, 1).Int())
+}
+
+func testBitOp(t *testing.T, rds redis.Redis) {
+	foo, bar, dst := Key(t, "{bits}foo"), Key(t, "{bits}bar"), Key(t, "{bits}dst")
+	require.NoError(t, rds.Set(foo, []byte{0x0f, 0xf0}).Error)
+	require.NoError(t, rds.Set(bar, []byte{0xff}).Error)
+
+	res := rds.BitOp(redis.BitAnd, dst, foo, bar)
+	require.NoError(t, res.Error)
+	assert.Equal(t, 2, res.Int())
+	assert.Equal(t, []byte{0x0f, 0x00}, rds.Get(dst).Bytes())
+
+	require.NoError(t, rds.BitOp(redis.BitOr, dst, foo, bar).Error)
+	assert.Equal(t, []byte{0xff, 0xf0}, rds.Get(dst).Bytes())
+
+	require.NoError(t, rds.BitOp(redis.BitXor, dst, foo, bar).Error)
+	assert.Equal(t, []byte{0xf0, 0xf0}, rds.Get(dst).Bytes())
+
+	require.NoError(t, rds.BitOp(redis.BitNot, dst, foo).Error)
+	assert.Equal(t, []byte{0xf0, 0x0f}, rds.Get(dst).Bytes())
+}
+
+func testBitField(t *testing.T, rds redis.Redis) {
+	SkipInProcess(t, "BITFIELD")
+	bits := Key(t, "bits")
+
+	res := rds.BitField(bits,
+		redis.BitFieldSet("u8", 0, 200),
+		redis.BitFieldIncrBy("u8", 0, 100),
+		redis.BitFieldOverflow("SAT"),
+		redis.BitFieldIncrBy("u8", 0, 100),
+		redis.BitFieldGet("u8", 0),
+		redis.BitFieldGet("i16", 8),
+	)
+	require.NoError(t, res.Error)
+	assert.Equal(t, []int64{0, 44, 144, 144, 0}, res.Int64Slice())
+
+	assert.Error(t, rds.BitField(bits, redis.BitFieldGet("x8", 0)).Error, "invalid type must fail")
+}
+
+func testBitmask(t *testing.T, rds redis.Redis) {
+	SkipInProcess(t, "BITFIELD")
+	perm := Key(t, "perm")
+	bitmask := encoding.Generate([]int64{1, 7, 8, 70})
+
+	require.NoError(t, redis.SetBitmask(rds, perm, bitmask))
+
+	got, err := redis.GetBitmask(rds, perm)
+	require.NoError(t, err)
+	assert.Equal(t, bitmask, got)
+
+	for _, bit := range []int64{0, 1, 2, 7, 8, 9, 70, 71, 500} {
+		ok, err := redis.HasBit(rds, perm, bit)
+		require.NoError(t, err)
+		assert.Equal(t, encoding.Has(bitmask, bit), ok, "bit %d", bit)
+	}
+
+	require.NoError(t, redis.ToggleBits(rds, perm, []int64{1, 2}))
+	require.NoError(t, redis.SetBits(rds, perm, []int64{3, 7}))
+	require.NoError(t, redis.ClearBits(rds, perm, []int64{8, 9}))
+
+	want := encoding.Toggle(bitmask, []int64{1, 2})
+	want = encoding.Clear(encoding.Toggle(want, []int64{3}), []int64{8, 9})
+
+	got, err = redis.GetBitmask(rds, perm)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.Equal(t, 3, rds.BitCount(perm).Int())
+}
@@ -0,0 +1,91 @@
+package redistest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPExpire(t *testing.T, rds redis.Redis) {
+	foo := Key(t, "foo")
+
+	_, err := rds.PTTL(foo)
+	assert.Equal(t, redis.ErrKeyNotExist, err)
+
+	ok, err := rds.PExpire(foo, time.Second)
+	require.NoError(t, err)
+	assert.False(t, ok, "missing key")
+
+	require.NoError(t, rds.Set(foo, "bar").Error)
+	_, err = rds.PTTL(foo)
+	assert.Equal(t, redis.ErrNoExpire, err)
+
+	ok, err = rds.PExpire(foo, 1500*time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ttl, err := rds.PTTL(foo)
+	require.NoError(t, err)
+	assert.True(t, ttl > time.Second && ttl <= 1500*time.Millisecond, "ttl %v out of range", ttl)
+
+	ok, err = rds.Persist(foo)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	_, err = rds.PTTL(foo)
+	assert.Equal(t, redis.ErrNoExpire, err)
+
+	ok, _ = rds.Persist(foo)
+	assert.False(t, ok, "no timeout to remove")
+
+	// a timeout that is not positive would delete the key right away
+	_, err = rds.PExpire(foo, 0)
+	assert.Equal(t, redis.ErrInvalidTTL, err)
+	_, err = rds.PExpire(foo, -time.Second)
+	assert.Equal(t, redis.ErrInvalidTTL, err)
+	assert.Equal(t, redis.ErrInvalidTTL, rds.PSetEx(foo, -time.Second, "bar"))
+	assert.Equal(t, 1, rds.Exists(foo).Int())
+
+	ok, err = rds.PExpire(foo, time.Microsecond)
+	require.NoError(t, err)
+	assert.True(t, ok, "rounded up to a millisecond")
+
+	// sub-second timeouts
+	require.NoError(t, rds.PSetEx(foo, 50*time.Millisecond, "bar"))
+	assert.Equal(t, "bar", rds.Get(foo).String())
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 0, rds.Exists(foo).Int())
+}
+
+func testExpireAt(t *testing.T, rds redis.Redis) {
+	foo, bar := Key(t, "foo"), Key(t, "bar")
+	require.NoError(t, rds.Set(foo, "bar").Error)
+	require.NoError(t, rds.Set(bar, "bar").Error)
+
+	ok, err := rds.ExpireAt(foo, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ttl, err := rds.PTTL(foo)
+	require.NoError(t, err)
+	assert.InDelta(t, float64(time.Hour), float64(ttl), float64(2*time.Second))
+
+	ok, err = rds.PExpireAt(bar, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ttl, err = rds.PTTL(bar)
+	require.NoError(t, err)
+	assert.InDelta(t, float64(time.Minute), float64(ttl), float64(time.Second))
+
+	// a time in the past deletes the key
+	ok, err = rds.PExpireAt(bar, time.Now().Add(-time.Second))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 0, rds.Exists(bar).Int())
+
+	ok, _ = rds.ExpireAt(Key(t, "none"), time.Now().Add(time.Hour))
+	assert.False(t, ok)
+}
@@ -0,0 +1,81 @@
+package redistest
+
+import (
+	"testing"
+
+	"github.com/5112100070/publib/storage/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// warehouses around Jakarta, Bandung is about 120 km away from the others
+var warehouses = []redis.GeoLocation{
+	{Name: "cakung", Longitude: 106.9500, Latitude: -6.1800},
+	{Name: "cikarang", Longitude: 107.1500, Latitude: -6.3000},
+	{Name: "tangerang", Longitude: 106.6300, Latitude: -6.1800},
+	{Name: "bandung", Longitude: 107.6100, Latitude: -6.9200},
+}
+
+func testGeoPos(t *testing.T, rds redis.Redis) {
+	geo := Key(t, "geo")
+
+	res := rds.GeoAdd(geo, warehouses...)
+	require.NoError(t, res.Error)
+	assert.Equal(t, 4, res.Int())
+	assert.Equal(t, 0, rds.GeoAdd(geo, warehouses[0]).Int(), "members are updated, not added")
+
+	res = rds.GeoPos(geo, "cakung", "none", "bandung")
+	require.NoError(t, res.Error)
+	locations := res.GeoLocations()
+	require.Len(t, locations, 2, "missing members are left out")
+	assert.Equal(t, "cakung", locations[0].Name)
+	assert.InDelta(t, 106.95, locations[0].Longitude, 0.0001)
+	assert.InDelta(t, -6.18, locations[0].Latitude, 0.0001)
+	assert.Equal(t, "bandung", locations[1].Name)
+
+	dist := rds.GeoDist(geo, "cakung", "bandung", redis.GeoKilometers)
+	require.NoError(t, dist.Error)
+	assert.InDelta(t, 114, dist.Float64(), 5)
+	assert.InDelta(t, dist.Float64()*1000, rds.GeoDist(geo, "cakung", "bandung", "").Float64(), 1)
+
+	_, err := rds.GeoDist(geo, "cakung", "none", "").StringResult()
+	assert.Equal(t, redis.ErrNil, err)
+}
+
+func testGeoSearch(t *testing.T, rds redis.Redis) {
+	SkipInProcess(t, "GEOSEARCH")
+	geo := Key(t, "geo")
+	require.NoError(t, rds.GeoAdd(geo, warehouses...).Error)
+
+	// nearest warehouses from Monas
+	res := rds.GeoSearch(geo, redis.GeoSearchQuery{
+		Longitude: 106.8272,
+		Latitude:  -6.1754,
+		Radius:    50,
+		Unit:      redis.GeoKilometers,
+		Sort:      "ASC",
+		WithCoord: true,
+		WithDist:  true,
+	})
+	require.NoError(t, res.Error)
+	nearest := res.GeoLocations()
+	require.Len(t, nearest, 3)
+	assert.Equal(t, "cakung", nearest[0].Name)
+	assert.InDelta(t, 13.6, nearest[0].Dist, 1)
+	assert.InDelta(t, 106.95, nearest[0].Longitude, 0.0001)
+	assert.Equal(t, "tangerang", nearest[1].Name)
+	assert.Equal(t, "cikarang", nearest[2].Name)
+
+	res = rds.GeoSearch(geo, redis.GeoSearchQuery{
+		Member: "cakung",
+		Width:  100,
+		Height: 100,
+		Unit:   redis.GeoKilometers,
+		Sort:   "DESC",
+		Count:  1,
+	})
+	require.NoError(t, res.Error)
+	assert.Equal(t, []redis.GeoLocation{{Name: "tangerang"}}, res.GeoLocations())
+
+	assert.Empty(t, rds.GeoSearch(Key(t, "none"), redis.GeoSearchQuery{Radius: 1}).GeoLocations())
+}
@@ -0,0 +1,89 @@
+package redistest
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/5112100070/publib/storage/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testHIncrBy(t *testing.T, rds redis.Redis) {
+	hash := Key(t, "hash")
+
+	res := rds.HIncrBy(hash, "foo", 5)
+	require.NoError(t, res.Error)
+	assert.Equal(t, int64(5), res.Int64())
+	assert.Equal(t, int64(2), rds.HIncrBy(hash, "foo", -3).Int64())
+
+	res = rds.HIncrByFloat(hash, "bar", 1.5)
+	require.NoError(t, res.Error)
+	assert.Equal(t, 1.5, res.Float64())
+	assert.Equal(t, 1.25, rds.HIncrByFloat(hash, "bar", -0.25).Float64())
+
+	require.NoError(t, rds.HSet(hash, "str", "bar"))
+	assert.Error(t, rds.HIncrBy(hash, "str", 1).Error, "incrementing a non integer must fail")
+	assert.Error(t, rds.HIncrBy(hash, "bar", 1).Error, "incrementing a float by an integer must fail")
+	assert.Error(t, rds.HIncrByFloat(hash, "str", 1).Error, "incrementing a non number must fail")
+}
+
+func testHSetNX(t *testing.T, rds redis.Redis) {
+	hash := Key(t, "hash")
+
+	res := rds.HSetNX(hash, "foo", "1")
+	require.NoError(t, res.Error)
+	assert.True(t, res.Bool())
+
+	res = rds.HSetNX(hash, "foo", "2")
+	require.NoError(t, res.Error)
+	assert.False(t, res.Bool())
+	assert.Equal(t, "1", rds.HGet(hash, "foo").String())
+}
+
+func testHMGet(t *testing.T, rds redis.Redis) {
+	hash := Key(t, "hash")
+	require.NoError(t, rds.HMSet(hash, map[string]interface{}{"foo": "1", "bar": "hello"}))
+
+	assert.Equal(t, 2, rds.HLen(hash).Int())
+	assert.Equal(t, 0, rds.HLen(Key(t, "none")).Int())
+
+	assert.Equal(t, 5, rds.HStrLen(hash, "bar").Int())
+	assert.Equal(t, 0, rds.HStrLen(hash, "none").Int())
+
+	res := rds.HMGet(hash, "foo", "none", "bar")
+	require.NoError(t, res.Error)
+	assert.Equal(t, []string{"1", "", "hello"}, res.StringSlice())
+
+	// nil for missing fields
+	values := res.ByteSlice()
+	require.Len(t, values, 3)
+	assert.Nil(t, values[1])
+}
+
+func testHScan(t *testing.T, rds redis.Redis) {
+	hash := Key(t, "hash")
+
+	want := map[string]string{}
+	values := map[string]interface{}{}
+	for i := 0; i < 300; i++ {
+		field := "field:" + strconv.Itoa(i)
+		want[field] = strconv.Itoa(i)
+		values[field] = i
+	}
+	values["other"] = "x"
+	require.NoError(t, rds.HMSet(hash, values))
+
+	got := map[string]string{}
+	it := redis.NewHScanIterator(rds, hash, "field:*", 50)
+	for it.Next() {
+		got[it.Field()] = it.Value()
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, want, got)
+
+	// a missing hash has no fields
+	it = redis.NewHScanIterator(rds, Key(t, "none"), "", 0)
+	assert.False(t, it.Next())
+	assert.NoError(t, it.Err())
+}
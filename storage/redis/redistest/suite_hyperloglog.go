@@ -0,0 +1,38 @@
+package redistest
+
+import (
+	"testing"
+
+	"github.com/5112100070/publib/storage/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPFAdd(t *testing.T, rds redis.Redis) {
+	hll := Key(t, "hll")
+
+	res := rds.PFAdd(hll, "a", "b", "c")
+	require.NoError(t, res.Error)
+	assert.True(t, res.Bool())
+	assert.False(t, rds.PFAdd(hll, "a", "b").Bool(), "estimation does not change")
+
+	assert.Equal(t, int64(3), rds.PFCount(hll).Int64())
+	assert.Equal(t, int64(0), rds.PFCount(Key(t, "none")).Int64())
+
+	require.NoError(t, rds.Set(Key(t, "str"), "foo").Error)
+	assert.Error(t, rds.PFAdd(Key(t, "str"), "a").Error)
+}
+
+func testPFMerge(t *testing.T, rds redis.Redis) {
+	SkipInProcess(t, "PFCOUNT of several keys")
+	foo, bar, dst := Key(t, "{hll}foo"), Key(t, "{hll}bar"), Key(t, "{hll}dst")
+	require.NoError(t, rds.PFAdd(foo, "a", "b", "c").Error)
+	require.NoError(t, rds.PFAdd(bar, "c", "d").Error)
+
+	// counting several keys merges them on the fly
+	assert.Equal(t, int64(4), rds.PFCount(foo, bar).Int64())
+
+	require.NoError(t, rds.PFMerge(dst, foo, bar))
+	assert.Equal(t, int64(4), rds.PFCount(dst).Int64())
+	assert.Equal(t, int64(3), rds.PFCount(foo).Int64(), "sources are kept")
+}
@@ -0,0 +1,114 @@
+package redistest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPushVariadic(t *testing.T, rds redis.Redis) {
+	list := Key(t, "list")
+
+	require.NoError(t, rds.RPush(list, "c", "d"))
+	require.NoError(t, rds.LPush(list, "b", "a"))
+	assert.Equal(t, []string{"a", "b", "c", "d"}, rds.LRange(list, 0, -1).StringSlice())
+
+	assert.Equal(t, "d", rds.RPop(list).String())
+	assert.Equal(t, "a", rds.LPop(list).String())
+
+	// nil reply on an empty list
+	res := rds.RPop(Key(t, "none"))
+	require.NoError(t, res.Error)
+	assert.Nil(t, res.Value)
+}
+
+func testLRange(t *testing.T, rds redis.Redis) {
+	list := Key(t, "list")
+	require.NoError(t, rds.RPush(list, "a", "b", "c", "d", "e"))
+
+	assert.Equal(t, []string{"b", "c"}, rds.LRange(list, 1, 2).StringSlice())
+	assert.Equal(t, []string{"d", "e"}, rds.LRange(list, -2, -1).StringSlice())
+	assert.Empty(t, rds.LRange(list, 10, 20).StringSlice())
+	assert.Empty(t, rds.LRange(Key(t, "none"), 0, -1).StringSlice())
+
+	assert.Equal(t, "a", rds.LIndex(list, 0).String())
+	assert.Equal(t, "e", rds.LIndex(list, -1).String())
+
+	// nil reply out of range
+	res := rds.LIndex(list, 10)
+	require.NoError(t, res.Error)
+	assert.Nil(t, res.Value)
+
+	require.NoError(t, rds.LTrim(list, 1, 3))
+	assert.Equal(t, []string{"b", "c", "d"}, rds.LRange(list, 0, -1).StringSlice())
+
+	// trimming everything removes the key
+	require.NoError(t, rds.LTrim(list, 1, 0))
+	assert.Equal(t, 0, rds.Exists(list).Int())
+}
+
+func testLRem(t *testing.T, rds redis.Redis) {
+	list := Key(t, "list")
+	require.NoError(t, rds.RPush(list, "a", "b", "a", "c", "a"))
+
+	// from the head
+	res := rds.LRem(list, 1, "a")
+	require.NoError(t, res.Error)
+	assert.Equal(t, 1, res.Int())
+	assert.Equal(t, []string{"b", "a", "c", "a"}, rds.LRange(list, 0, -1).StringSlice())
+
+	// from the tail
+	assert.Equal(t, 1, rds.LRem(list, -1, "a").Int())
+	assert.Equal(t, []string{"b", "a", "c"}, rds.LRange(list, 0, -1).StringSlice())
+
+	require.NoError(t, rds.RPush(list, "a"))
+	assert.Equal(t, 2, rds.LRem(list, 0, "a").Int())
+	assert.Equal(t, []string{"b", "c"}, rds.LRange(list, 0, -1).StringSlice())
+
+	assert.Equal(t, 0, rds.LRem(list, 0, "none").Int())
+}
+
+func testBLPop(t *testing.T, rds redis.Redis) {
+	foo, bar := Key(t, "{list}foo"), Key(t, "{list}bar")
+	require.NoError(t, rds.RPush(bar, "a", "b"))
+
+	// the first non empty list is popped
+	assert.Equal(t, []string{bar, "a"}, rds.BLPop(time.Second, foo, bar).StringSlice())
+	assert.Equal(t, []string{bar, "b"}, rds.BRPop(time.Second, foo, bar).StringSlice())
+
+	// nil reply on timeout
+	start := time.Now()
+	res := rds.BLPop(100*time.Millisecond, foo, bar)
+	require.NoError(t, res.Error)
+	assert.Nil(t, res.Value)
+	assert.True(t, time.Since(start) >= 100*time.Millisecond, "returned before the timeout")
+
+	res = rds.BRPop(100*time.Millisecond, foo)
+	require.NoError(t, res.Error)
+	assert.Nil(t, res.Value)
+
+	// an element pushed while blocking is received
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		rds.RPush(foo, "c")
+	}()
+	assert.Equal(t, []string{foo, "c"}, rds.BLPop(2*time.Second, foo).StringSlice())
+}
+
+func testBLMove(t *testing.T, rds redis.Redis) {
+	src, dst := Key(t, "{list}src"), Key(t, "{list}dst")
+	require.NoError(t, rds.RPush(src, "a", "b", "c"))
+
+	assert.Equal(t, "c", rds.BLMove(src, dst, redis.Right, redis.Left, time.Second).String())
+	assert.Equal(t, "a", rds.BLMove(src, dst, redis.Left, redis.Left, time.Second).String())
+	assert.Equal(t, "b", rds.BLMove(src, dst, redis.Left, redis.Right, time.Second).String())
+	assert.Equal(t, []string{"a", "c", "b"}, rds.LRange(dst, 0, -1).StringSlice())
+
+	// nil reply on timeout
+	res := rds.BLMove(src, dst, redis.Right, redis.Left, 100*time.Millisecond)
+	require.NoError(t, res.Error)
+	assert.Nil(t, res.Value)
+}
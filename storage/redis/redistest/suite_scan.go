@@ -0,0 +1,117 @@
+package redistest
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testScanType(t *testing.T, rds redis.Redis) {
+	str, hash, zset := Key(t, "str"), Key(t, "hash"), Key(t, "zset")
+	require.NoError(t, rds.Set(str, "1").Error)
+	require.NoError(t, rds.HSet(hash, "foo", "1"))
+	require.NoError(t, rds.ZAdd(zset, redis.Z{Score: 1, Member: "foo"}))
+
+	opts := redis.ScanOptions{Match: Key(t, "*"), Count: 100}
+
+	var got []string
+	it := redis.NewScanIterator(rds, opts)
+	for it.Next() {
+		got = append(got, it.Key())
+	}
+	require.NoError(t, it.Err())
+	assert.ElementsMatch(t, []string{str, hash, zset}, got)
+
+	for keyType, want := range map[string]string{"string": str, "hash": hash, "zset": zset} {
+		opts.Type = keyType
+
+		got = nil
+		err := redis.ScanAll(context.Background(), rds, opts, func(key string) error {
+			got = append(got, key)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{want}, got, keyType)
+	}
+}
+
+func testUnlink(t *testing.T, rds redis.Redis) {
+	foo, bar := Key(t, "{unlink}foo"), Key(t, "{unlink}bar")
+	require.NoError(t, rds.Set(foo, "1").Error)
+	require.NoError(t, rds.HSet(bar, "foo", "1"))
+
+	res := rds.Unlink(foo, bar, Key(t, "{unlink}none"))
+	require.NoError(t, res.Error)
+	assert.Equal(t, 2, res.Int())
+	assert.Equal(t, 0, rds.Exists(foo).Int())
+	assert.Equal(t, 0, rds.Exists(bar).Int())
+}
+
+func testDumpRestore(t *testing.T, rds redis.Redis) {
+	foo, bar, baz := Key(t, "foo"), Key(t, "bar"), Key(t, "baz")
+	require.NoError(t, rds.Set(foo, "value").Error)
+
+	value := rds.Dump(foo).Bytes()
+	require.NotEmpty(t, value)
+	assert.Nil(t, rds.Dump(Key(t, "none")).Value)
+
+	require.NoError(t, rds.Restore(bar, time.Minute, value, false))
+	assert.Equal(t, "value", rds.Get(bar).String())
+	ttl, err := rds.PTTL(bar)
+	require.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(5*time.Second))
+
+	assert.Equal(t, redis.ErrKeyExists, rds.Restore(bar, 0, value, false))
+	require.NoError(t, rds.Set(baz, "old").Error)
+	require.NoError(t, rds.Restore(baz, 0, value, true))
+	assert.Equal(t, "value", rds.Get(baz).String())
+	_, err = rds.PTTL(baz)
+	assert.Equal(t, redis.ErrNoExpire, err)
+
+	// a ttl under a millisecond is not sent as zero, which would persist the key
+	require.NoError(t, rds.Restore(baz, time.Microsecond, value, true))
+	_, err = rds.PTTL(baz)
+	assert.NotEqual(t, redis.ErrNoExpire, err)
+}
+
+func testByPattern(t *testing.T, rds redis.Redis) {
+	ctx := context.Background()
+
+	var users []string
+	for i := 0; i < 250; i++ {
+		user := Key(t, "user:"+strconv.Itoa(i))
+		users = append(users, user)
+		require.NoError(t, rds.Set(user, i).Error)
+	}
+	other := Key(t, "other")
+	require.NoError(t, rds.Set(other, "1").Error)
+
+	match := Key(t, "user:*")
+
+	n, err := redis.CountByPattern(ctx, rds, match, 50)
+	require.NoError(t, err)
+	assert.Equal(t, int64(250), n)
+
+	n, err = redis.ExpireByPattern(ctx, rds, match, 100*time.Second, 50)
+	require.NoError(t, err)
+	assert.Equal(t, int64(250), n)
+	sort.Strings(users)
+	ttl := rds.Ttl(users[0]).Int()
+	assert.True(t, ttl > 0 && ttl <= 100, "ttl %d out of range", ttl)
+	assert.Equal(t, -1, rds.Ttl(other).Int())
+
+	n, err = redis.DeleteByPattern(ctx, rds, match, 50)
+	require.NoError(t, err)
+	assert.Equal(t, int64(250), n)
+
+	n, err = redis.CountByPattern(ctx, rds, match, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+	assert.Equal(t, 1, rds.Exists(other).Int())
+}
@@ -0,0 +1,35 @@
+package redistest
+
+import (
+	"testing"
+
+	"github.com/5112100070/publib/storage/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEval(t *testing.T, rds redis.Redis) {
+	foo, bar := Key(t, "{eval}foo"), Key(t, "{eval}bar")
+
+	// moves the value of one key to another atomically
+	script := `
+local v = redis.call('GET', KEYS[1])
+if not v then return false end
+redis.call('SET', KEYS[2], v .. ARGV[1])
+redis.call('DEL', KEYS[1])
+return v`
+
+	require.NoError(t, rds.Set(foo, "a").Error)
+	res := rds.Eval(script, []string{foo, bar}, "b")
+	require.NoError(t, res.Error)
+	assert.Equal(t, "a", res.String())
+	assert.Equal(t, "ab", rds.Get(bar).String())
+	assert.Nil(t, rds.Get(foo).Value)
+
+	res = rds.Eval(script, []string{foo, bar}, "b")
+	require.NoError(t, res.Error)
+	assert.Nil(t, res.Value, "false is replied as nil")
+
+	assert.Equal(t, []string{"x", "y"}, rds.Eval("return ARGV", nil, "x", "y").StringSlice())
+	assert.Error(t, rds.Eval("return redis.call('NOPE')", nil).Error)
+}
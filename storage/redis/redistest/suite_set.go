@@ -0,0 +1,116 @@
+package redistest
+
+import (
+	"testing"
+
+	"github.com/5112100070/publib/storage/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// members builds the expected reply of StringSet
+func members(values ...string) map[string]struct{} {
+	res := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		res[v] = struct{}{}
+	}
+
+	return res
+}
+
+func testSAdd(t *testing.T, rds redis.Redis) {
+	set := Key(t, "set")
+
+	res := rds.SAdd(set, "a", "b", "a")
+	require.NoError(t, res.Error)
+	assert.Equal(t, 2, res.Int())
+	assert.Equal(t, 1, rds.SAdd(set, "b", "c").Int())
+	assert.Equal(t, 3, rds.SCard(set).Int())
+	assert.Equal(t, members("a", "b", "c"), rds.SMembers(set).StringSet())
+
+	res = rds.SRem(set, "a", "none")
+	require.NoError(t, res.Error)
+	assert.Equal(t, 1, res.Int())
+	assert.ElementsMatch(t, []string{"b", "c"}, rds.SMembers(set).StringSlice())
+
+	// empty replies on a missing set
+	none := Key(t, "none")
+	assert.Equal(t, 0, rds.SCard(none).Int())
+	assert.Equal(t, members(), rds.SMembers(none).StringSet())
+}
+
+func testSIsMember(t *testing.T, rds redis.Redis) {
+	set := Key(t, "set")
+	require.NoError(t, rds.SAdd(set, "a", "b").Error)
+
+	assert.True(t, rds.SIsMember(set, "a").Bool())
+	assert.False(t, rds.SIsMember(set, "c").Bool())
+	assert.False(t, rds.SIsMember(Key(t, "none"), "a").Bool())
+
+	res := rds.SMIsMember(set, "a", "c", "b")
+	require.NoError(t, res.Error)
+	assert.Equal(t, []bool{true, false, true}, res.BoolSlice())
+}
+
+func testSPop(t *testing.T, rds redis.Redis) {
+	set := Key(t, "set")
+	require.NoError(t, rds.SAdd(set, "a", "b", "c").Error)
+
+	popped := rds.SPop(set, 0).String()
+	assert.Contains(t, []string{"a", "b", "c"}, popped)
+	assert.False(t, rds.SIsMember(set, popped).Bool())
+
+	rest := rds.SPop(set, 5).StringSlice()
+	assert.Len(t, rest, 2)
+	assert.NotContains(t, rest, popped)
+	assert.Equal(t, 0, rds.Exists(set).Int())
+
+	// nil reply on an empty set
+	res := rds.SPop(set, 0)
+	require.NoError(t, res.Error)
+	assert.Nil(t, res.Value)
+}
+
+func testSRandMember(t *testing.T, rds redis.Redis) {
+	set := Key(t, "set")
+	require.NoError(t, rds.SAdd(set, "a", "b", "c").Error)
+
+	assert.Contains(t, []string{"a", "b", "c"}, rds.SRandMember(set, 0).String())
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, rds.SRandMember(set, 10).StringSlice())
+
+	// negative count allows the same member multiple times
+	assert.Len(t, rds.SRandMember(set, -10).StringSlice(), 10)
+
+	// members are left in place
+	assert.Equal(t, 3, rds.SCard(set).Int())
+
+	res := rds.SRandMember(Key(t, "none"), 0)
+	require.NoError(t, res.Error)
+	assert.Nil(t, res.Value)
+}
+
+func testSetOperations(t *testing.T, rds redis.Redis) {
+	foo, bar, dst := Key(t, "{set}foo"), Key(t, "{set}bar"), Key(t, "{set}dst")
+	require.NoError(t, rds.SAdd(foo, "a", "b", "c").Error)
+	require.NoError(t, rds.SAdd(bar, "b", "c", "d").Error)
+
+	assert.Equal(t, members("b", "c"), rds.SInter(foo, bar).StringSet())
+	assert.Equal(t, members("a", "b", "c", "d"), rds.SUnion(foo, bar).StringSet())
+	assert.Equal(t, members("a"), rds.SDiff(foo, bar).StringSet())
+
+	assert.Equal(t, 2, rds.SInterStore(dst, foo, bar).Int())
+	assert.Equal(t, members("b", "c"), rds.SMembers(dst).StringSet())
+
+	assert.Equal(t, 4, rds.SUnionStore(dst, foo, bar).Int())
+	assert.Equal(t, members("a", "b", "c", "d"), rds.SMembers(dst).StringSet())
+
+	assert.Equal(t, 1, rds.SDiffStore(dst, foo, bar).Int())
+	assert.Equal(t, members("a"), rds.SMembers(dst).StringSet())
+
+	// missing sets are empty
+	assert.Equal(t, members(), rds.SInter(foo, Key(t, "{set}none")).StringSet())
+
+	str := Key(t, "{set}str")
+	require.NoError(t, rds.Set(str, "bar").Error)
+	assert.Error(t, rds.SUnion(foo, str).Error)
+}
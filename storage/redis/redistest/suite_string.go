@@ -0,0 +1,208 @@
+package redistest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testIncrMultiple(t *testing.T, rds redis.Redis) {
+	foo, bar, str := Key(t, "foo"), Key(t, "bar"), Key(t, "str")
+
+	require.NoError(t, rds.Incr(foo, bar, foo))
+	assert.Equal(t, 2, rds.Get(foo).Int())
+	assert.Equal(t, 1, rds.Get(bar).Int())
+
+	require.NoError(t, rds.Decr(foo, bar))
+	assert.Equal(t, 1, rds.Get(foo).Int())
+	assert.Equal(t, 0, rds.Get(bar).Int())
+
+	// the other keys are still applied when one of them fails
+	require.NoError(t, rds.Set(str, "bar").Error)
+	assert.Error(t, rds.Incr(foo, str, bar))
+	assert.Equal(t, 2, rds.Get(foo).Int())
+	assert.Equal(t, 1, rds.Get(bar).Int())
+}
+
+func testIncrBy(t *testing.T, rds redis.Redis) {
+	foo, bar := Key(t, "foo"), Key(t, "bar")
+
+	val, err := rds.IncrBy(foo, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), val)
+
+	val, err = rds.DecrBy(foo, 15)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-5), val)
+
+	f, err := rds.IncrByFloat(bar, 1.5)
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, f)
+
+	f, err = rds.IncrByFloat(bar, -0.25)
+	require.NoError(t, err)
+	assert.Equal(t, 1.25, f)
+
+	_, err = rds.IncrBy(bar, 1)
+	assert.Error(t, err, "incrementing a float by an integer must fail")
+}
+
+func testSetNX(t *testing.T, rds redis.Redis) {
+	foo := Key(t, "foo")
+
+	ok, err := rds.SetNX(foo, "1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = rds.SetNX(foo, "2")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "1", rds.Get(foo).String())
+}
+
+func testGetSet(t *testing.T, rds redis.Redis) {
+	foo := Key(t, "foo")
+
+	_, err := rds.GetSet(foo, "1")
+	assert.Equal(t, redis.ErrNil, err)
+
+	old, err := rds.GetSet(foo, "2")
+	require.NoError(t, err)
+	assert.Equal(t, "1", old)
+
+	// GETEX
+	val, err := rds.GetEx(foo, 100*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "2", val)
+	ttl := rds.Ttl(foo).Int()
+	assert.True(t, ttl > 0 && ttl <= 100, "ttl %d out of range", ttl)
+
+	_, err = rds.GetEx(foo, 0)
+	require.NoError(t, err)
+	assert.NotEqual(t, -1, rds.Ttl(foo).Int(), "zero expiration must keep the ttl")
+
+	_, err = rds.GetEx(foo, -1)
+	require.NoError(t, err)
+	assert.Equal(t, -1, rds.Ttl(foo).Int())
+
+	_, err = rds.GetEx(Key(t, "none"), time.Second)
+	assert.Equal(t, redis.ErrNil, err)
+
+	// sub-millisecond expirations are rounded up instead of sent as PX 0
+	bar := Key(t, "bar")
+	require.NoError(t, rds.Set(bar, "1").Error)
+	val, err = rds.GetEx(bar, time.Microsecond)
+	require.NoError(t, err)
+	assert.Equal(t, "1", val)
+
+	// GETDEL
+	val, err = rds.GetDel(foo)
+	require.NoError(t, err)
+	assert.Equal(t, "2", val)
+	assert.Equal(t, 0, rds.Exists(foo).Int())
+
+	_, err = rds.GetDel(foo)
+	assert.Equal(t, redis.ErrNil, err)
+}
+
+func testMSet(t *testing.T, rds redis.Redis) {
+	foo, bar, baz := Key(t, "{mset}foo"), Key(t, "{mset}bar"), Key(t, "{mset}baz")
+
+	require.NoError(t, rds.MSet(map[string]interface{}{foo: "1", bar: 2}))
+	assert.Equal(t, []string{"1", "2"}, rds.MGet(foo, bar).StringSlice())
+
+	// nothing is set when one of the keys exists
+	ok, err := rds.MSetNX(map[string]interface{}{foo: "3", baz: "3"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "1", rds.Get(foo).String())
+	assert.Equal(t, 0, rds.Exists(baz).Int())
+
+	ok, err = rds.MSetNX(map[string]interface{}{baz: "3"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "3", rds.Get(baz).String())
+}
+
+func testAppend(t *testing.T, rds redis.Redis) {
+	foo := Key(t, "foo")
+
+	n, err := rds.StrLen(foo)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	n, err = rds.Append(foo, "hello")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+
+	n, err = rds.Append(foo, " world")
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), n)
+
+	n, err = rds.StrLen(foo)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), n)
+
+	s, err := rds.GetRange(foo, 0, 4)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", s)
+
+	s, err = rds.GetRange(foo, -5, -1)
+	require.NoError(t, err)
+	assert.Equal(t, "world", s)
+
+	n, err = rds.SetRange(foo, 6, "redis")
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), n)
+	assert.Equal(t, "hello redis", rds.Get(foo).String())
+
+	// empty string for a missing key
+	s, err = rds.GetRange(Key(t, "none"), 0, -1)
+	require.NoError(t, err)
+	assert.Equal(t, "", s)
+}
+
+func testSetWithOptions(t *testing.T, rds redis.Redis) {
+	foo := Key(t, "foo")
+
+	written, _, err := rds.SetWithOptions(foo, "1", redis.SetOptions{XX: true})
+	require.NoError(t, err)
+	assert.False(t, written, "XX on a missing key")
+
+	written, _, err = rds.SetWithOptions(foo, "1", redis.SetOptions{NX: true, TTL: 1500 * time.Millisecond})
+	require.NoError(t, err)
+	assert.True(t, written)
+	ttl, err := rds.PTTL(foo)
+	require.NoError(t, err)
+	assert.True(t, ttl > time.Second && ttl <= 1500*time.Millisecond, "ttl %v out of range", ttl)
+
+	written, _, err = rds.SetWithOptions(foo, "2", redis.SetOptions{NX: true})
+	require.NoError(t, err)
+	assert.False(t, written, "NX on an existing key")
+	assert.Equal(t, "1", rds.Get(foo).String())
+
+	written, previous, err := rds.SetWithOptions(foo, "3", redis.SetOptions{XX: true, KeepTTL: true, Get: true})
+	require.NoError(t, err)
+	assert.True(t, written)
+	assert.Equal(t, "1", previous)
+	ttl, err = rds.PTTL(foo)
+	require.NoError(t, err)
+	assert.True(t, ttl > 0, "KEEPTTL keeps the timeout")
+
+	at := time.Now().Add(time.Hour).Truncate(time.Second)
+	written, previous, err = rds.SetWithOptions(foo, "4", redis.SetOptions{ExpireAt: at, Get: true})
+	require.NoError(t, err)
+	assert.True(t, written)
+	assert.Equal(t, "3", previous)
+	ttl, err = rds.PTTL(foo)
+	require.NoError(t, err)
+	assert.InDelta(t, float64(time.Until(at)), float64(ttl), float64(time.Second))
+
+	written, previous, err = rds.SetWithOptions(Key(t, "none"), "1", redis.SetOptions{Get: true, TTL: time.Minute})
+	require.NoError(t, err)
+	assert.True(t, written)
+	assert.Empty(t, previous)
+}
@@ -0,0 +1,148 @@
+package redistest
+
+import (
+	"testing"
+
+	"github.com/5112100070/publib/storage/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// abcd fills a sorted set with members a, b, c, d scored 1 to 4
+func abcd(t *testing.T, rds redis.Redis) string {
+	zset := Key(t, "zset")
+	require.NoError(t, rds.ZAdd(zset,
+		redis.Z{Score: 1, Member: "a"},
+		redis.Z{Score: 2, Member: "b"},
+		redis.Z{Score: 3, Member: "c"},
+		redis.Z{Score: 4, Member: "d"},
+	))
+
+	return zset
+}
+
+func testZRem(t *testing.T, rds redis.Redis) {
+	zset := abcd(t, rds)
+
+	res := rds.ZRem(zset, "a", "c", "none")
+	require.NoError(t, res.Error)
+	assert.Equal(t, 2, res.Int())
+	assert.Equal(t, []string{"b", "d"}, rds.ZRange(zset, 0, -1).StringSlice())
+
+	assert.Equal(t, 0, rds.ZRem(Key(t, "none"), "a").Int())
+}
+
+func testZIncrBy(t *testing.T, rds redis.Redis) {
+	zset := Key(t, "zset")
+
+	res := rds.ZIncrBy(zset, 2.5, "a")
+	require.NoError(t, res.Error)
+	assert.Equal(t, 2.5, res.Float64())
+	assert.Equal(t, -1.0, rds.ZIncrBy(zset, -3.5, "a").Float64())
+	assert.Equal(t, -1.0, rds.ZScore(zset, "a").Float64())
+
+	// nil reply for a missing member
+	res = rds.ZScore(zset, "none")
+	require.NoError(t, res.Error)
+	assert.Nil(t, res.Value)
+}
+
+func testZRank(t *testing.T, rds redis.Redis) {
+	zset := abcd(t, rds)
+
+	assert.Equal(t, 0, rds.ZRank(zset, "a").Int())
+	assert.Equal(t, 3, rds.ZRank(zset, "d").Int())
+	assert.Equal(t, 3, rds.ZRevRank(zset, "a").Int())
+	assert.Equal(t, 0, rds.ZRevRank(zset, "d").Int())
+
+	// nil reply for a missing member
+	res := rds.ZRank(zset, "none")
+	require.NoError(t, res.Error)
+	assert.Nil(t, res.Value)
+
+	res = rds.ZRevRank(zset, "none")
+	require.NoError(t, res.Error)
+	assert.Nil(t, res.Value)
+}
+
+func testZRevRange(t *testing.T, rds redis.Redis) {
+	zset := abcd(t, rds)
+
+	assert.Equal(t, []string{"d", "c", "b", "a"}, rds.ZRevRange(zset, 0, -1).StringSlice())
+	assert.Equal(t, []string{"c", "b"}, rds.ZRevRange(zset, 1, 2).StringSlice())
+	assert.Equal(t, []string{"c", "b"}, rds.ZRevRangeByScore(zset, "3", "2", 0).StringSlice())
+	assert.Equal(t, []string{"d", "c"}, rds.ZRevRangeByScore(zset, "+inf", "-inf", 2).StringSlice())
+	assert.Empty(t, rds.ZRevRange(Key(t, "none"), 0, -1).StringSlice())
+}
+
+func testZWithScores(t *testing.T, rds redis.Redis) {
+	zset := abcd(t, rds)
+
+	a, b, c, d := redis.Z{Score: 1, Member: "a"}, redis.Z{Score: 2, Member: "b"}, redis.Z{Score: 3, Member: "c"}, redis.Z{Score: 4, Member: "d"}
+
+	assert.Equal(t, []redis.Z{a, b, c, d}, rds.ZRangeWithScores(zset, 0, -1).ZSlice())
+	assert.Equal(t, []redis.Z{d, c}, rds.ZRevRangeWithScores(zset, 0, 1).ZSlice())
+	assert.Equal(t, []redis.Z{b, c}, rds.ZRangeByScoreWithScores(zset, "2", "3", 0).ZSlice())
+	assert.Equal(t, []redis.Z{a}, rds.ZRangeByScoreWithScores(zset, "-inf", "+inf", 1).ZSlice())
+	assert.Equal(t, []redis.Z{c, b}, rds.ZRevRangeByScoreWithScores(zset, "3", "(1", 0).ZSlice())
+	assert.Equal(t, []redis.Z{}, rds.ZRangeWithScores(Key(t, "none"), 0, -1).ZSlice())
+}
+
+func testZCount(t *testing.T, rds redis.Redis) {
+	zset := abcd(t, rds)
+
+	assert.Equal(t, 4, rds.ZCard(zset).Int())
+	assert.Equal(t, 0, rds.ZCard(Key(t, "none")).Int())
+	assert.Equal(t, 2, rds.ZCount(zset, "2", "3").Int())
+	assert.Equal(t, 1, rds.ZCount(zset, "(2", "3").Int())
+	assert.Equal(t, 4, rds.ZCount(zset, "-inf", "+inf").Int())
+	assert.Error(t, rds.ZCount(zset, "foo", "bar").Error)
+}
+
+func testZRemRange(t *testing.T, rds redis.Redis) {
+	zset := abcd(t, rds)
+
+	res := rds.ZRemRangeByScore(zset, "-inf", "(2")
+	require.NoError(t, res.Error)
+	assert.Equal(t, 1, res.Int())
+	assert.Equal(t, []string{"b", "c", "d"}, rds.ZRange(zset, 0, -1).StringSlice())
+
+	res = rds.ZRemRangeByRank(zset, -2, -1)
+	require.NoError(t, res.Error)
+	assert.Equal(t, 2, res.Int())
+	assert.Equal(t, []string{"b"}, rds.ZRange(zset, 0, -1).StringSlice())
+}
+
+func testZPop(t *testing.T, rds redis.Redis) {
+	zset := abcd(t, rds)
+
+	assert.Equal(t, []redis.Z{{Score: 1, Member: "a"}}, rds.ZPopMin(zset, 0).ZSlice())
+	assert.Equal(t, []redis.Z{{Score: 4, Member: "d"}, {Score: 3, Member: "c"}}, rds.ZPopMax(zset, 2).ZSlice())
+	assert.Equal(t, []redis.Z{{Score: 2, Member: "b"}}, rds.ZPopMin(zset, 5).ZSlice())
+
+	// popping an empty set is an empty reply
+	res := rds.ZPopMin(zset, 1)
+	require.NoError(t, res.Error)
+	assert.Empty(t, res.ZSlice())
+}
+
+func testZUnionStore(t *testing.T, rds redis.Redis) {
+	foo, bar, dst := Key(t, "foo"), Key(t, "bar"), Key(t, "dst")
+	require.NoError(t, rds.ZAdd(foo, redis.Z{Score: 1, Member: "a"}, redis.Z{Score: 2, Member: "b"}))
+	require.NoError(t, rds.ZAdd(bar, redis.Z{Score: 3, Member: "b"}, redis.Z{Score: 4, Member: "c"}))
+
+	res := rds.ZUnionStore(dst, redis.ZStore{Keys: []string{foo, bar, Key(t, "none")}})
+	require.NoError(t, res.Error)
+	assert.Equal(t, 3, res.Int())
+	assert.Equal(t, []redis.Z{{Score: 1, Member: "a"}, {Score: 4, Member: "c"}, {Score: 5, Member: "b"}},
+		rds.ZRangeWithScores(dst, 0, -1).ZSlice())
+
+	// the destination is replaced
+	res = rds.ZUnionStore(dst, redis.ZStore{Keys: []string{foo, bar}, Weights: []float64{2, 1}, Aggregate: redis.AggregateMax})
+	require.NoError(t, res.Error)
+	assert.Equal(t, []redis.Z{{Score: 2, Member: "a"}, {Score: 4, Member: "b"}, {Score: 4, Member: "c"}},
+		rds.ZRangeWithScores(dst, 0, -1).ZSlice())
+
+	assert.Equal(t, 0, rds.ZUnionStore(dst, redis.ZStore{Keys: []string{Key(t, "none")}}).Int())
+	assert.Equal(t, 0, rds.ZCard(dst).Int(), "an empty union deletes the destination")
+}
@@ -1,6 +1,8 @@
 package redis
 
 import (
+	"strconv"
+
 	"github.com/5112100070/publib/convert"
 	rgo "github.com/gomodule/redigo/redis"
 )
@@ -34,6 +36,19 @@ func (r *Result) String() string {
 
 }
 
+// StringResult returns the string value, ErrNil when redis replied nil
+func (r *Result) StringResult() (string, error) {
+	if r.Error != nil {
+		return "", r.Error
+	}
+
+	if r.Value == nil {
+		return "", ErrNil
+	}
+
+	return convert.ToString(r.Value), nil
+}
+
 // Bytes result convertion type
 func (r *Result) Bytes() []byte {
 	if r.Error != nil {
@@ -82,6 +97,159 @@ func (r *Result) Float64() float64 {
 	return convert.ToFloat64(r.Value)
 }
 
+// Int64Slice result convertion type, nil elements are converted to zero
+func (r *Result) Int64Slice() []int64 {
+	if r.Error != nil {
+		return nil
+	}
+
+	if val, ok := r.Value.([]int64); ok {
+		return val
+	}
+
+	values, err := rgo.Values(r.Value, nil)
+	if err != nil {
+		return nil
+	}
+
+	res := make([]int64, len(values))
+	for i, v := range values {
+		res[i] = convert.ToInt64(v)
+	}
+
+	return res
+}
+
+// Bool result convertion type
+func (r *Result) Bool() bool {
+	if r.Error != nil {
+		return false
+	}
+
+	switch v := r.Value.(type) {
+	case bool:
+		return v
+	case int:
+		return v != 0
+	case string:
+		val, _ := strconv.ParseBool(v)
+		return val
+	}
+
+	val, _ := rgo.Bool(r.Value, nil)
+	return val
+}
+
+// BoolSlice result convertion type
+func (r *Result) BoolSlice() []bool {
+	if r.Error != nil {
+		return nil
+	}
+
+	if val, ok := r.Value.([]bool); ok {
+		return val
+	}
+
+	values, err := rgo.Ints(r.Value, nil)
+	if err != nil {
+		return nil
+	}
+
+	res := make([]bool, len(values))
+	for i, v := range values {
+		res[i] = v != 0
+	}
+
+	return res
+}
+
+// StringSet result convertion type, duplicated members are merged
+func (r *Result) StringSet() map[string]struct{} {
+	values := r.StringSlice()
+	if values == nil {
+		return nil
+	}
+
+	res := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		res[v] = struct{}{}
+	}
+
+	return res
+}
+
+// ZSlice result convertion type for sorted set replies with scores
+func (r *Result) ZSlice() []Z {
+	if r.Error != nil {
+		return nil
+	}
+
+	if val, ok := r.Value.([]Z); ok {
+		return val
+	}
+
+	values := r.StringSlice()
+	if values == nil || len(values)%2 != 0 {
+		return nil
+	}
+
+	res := make([]Z, 0, len(values)/2)
+	for i := 0; i < len(values); i += 2 {
+		res = append(res, Z{
+			Member: values[i],
+			Score:  convert.ToFloat64(values[i+1]),
+		})
+	}
+
+	return res
+}
+
+// GeoLocations result convertion type for GeoPos and GeoSearch replies,
+// members missing from the index are left out
+func (r *Result) GeoLocations() []GeoLocation {
+	if r.Error != nil {
+		return nil
+	}
+
+	if val, ok := r.Value.([]GeoLocation); ok {
+		return val
+	}
+
+	items, err := rgo.Values(r.Value, nil)
+	if err != nil {
+		return nil
+	}
+
+	res := make([]GeoLocation, 0, len(items))
+	for _, item := range items {
+		switch item := item.(type) {
+		case []byte:
+			res = append(res, GeoLocation{Name: string(item)})
+		case []interface{}:
+			if len(item) == 0 {
+				continue
+			}
+
+			// name followed by the optional distance, hash and coordinates
+			loc := GeoLocation{Name: convert.ToString(item[0])}
+			for _, v := range item[1:] {
+				switch v := v.(type) {
+				case []byte:
+					loc.Dist = convert.ToFloat64(v)
+				case []interface{}:
+					if len(v) == 2 {
+						loc.Longitude = convert.ToFloat64(v[0])
+						loc.Latitude = convert.ToFloat64(v[1])
+					}
+				}
+			}
+			res = append(res, loc)
+		}
+	}
+
+	return res
+}
+
 // ScanResult result of scan
 func (r *Result) ScanResult() (nextCursor int, keys []string, err error) {
 	reply, err := rgo.Values(r.Value, r.Error)
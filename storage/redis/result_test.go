@@ -2,6 +2,7 @@ package redis_test
 
 import (
 	"errors"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -159,6 +160,9 @@ func TestFloat64(t *testing.T) {
 	r.Value = []byte("bar bar")
 	assert.Equal(t, 0.0, r.Float64())
 
+	r.Value = []byte("2.5")
+	assert.Equal(t, 2.5, r.Float64())
+
 }
 
 func TestScanStruct(t *testing.T) {
@@ -171,3 +175,154 @@ func TestScanStruct(t *testing.T) {
 	assert.Equal(t, []string(nil), keys)
 	assert.NotNil(t, err)
 }
+
+func TestZSlice(t *testing.T) {
+	r := &Result{
+		Error: errors.New("test"),
+	}
+
+	assert.Nil(t, r.ZSlice())
+
+	r.Error = nil
+	r.Value = []interface{}{[]byte("a"), []byte("1"), []byte("b"), []byte("2.5")}
+	assert.Equal(t, []Z{{Member: "a", Score: 1}, {Member: "b", Score: 2.5}}, r.ZSlice())
+
+	r.Value = []interface{}{[]byte("a"), []byte("inf")}
+	assert.True(t, math.IsInf(r.ZSlice()[0].Score, 1))
+
+	r.Value = []interface{}{}
+	assert.Equal(t, []Z{}, r.ZSlice())
+
+	r.Value = []interface{}{[]byte("a")}
+	assert.Nil(t, r.ZSlice())
+
+	r.Value = "foo bar"
+	assert.Nil(t, r.ZSlice())
+}
+
+func TestBool(t *testing.T) {
+	r := &Result{
+		Error: errors.New("test"),
+		Value: int64(1),
+	}
+
+	assert.False(t, r.Bool())
+
+	r.Error = nil
+	assert.True(t, r.Bool())
+
+	r.Value = int64(0)
+	assert.False(t, r.Bool())
+
+	r.Value = []byte("1")
+	assert.True(t, r.Bool())
+
+	r.Value = "true"
+	assert.True(t, r.Bool())
+
+	r.Value = true
+	assert.True(t, r.Bool())
+
+	r.Value = nil
+	assert.False(t, r.Bool())
+}
+
+func TestBoolSlice(t *testing.T) {
+	r := &Result{
+		Error: errors.New("test"),
+	}
+
+	assert.Nil(t, r.BoolSlice())
+
+	r.Error = nil
+	r.Value = []interface{}{int64(1), int64(0)}
+	assert.Equal(t, []bool{true, false}, r.BoolSlice())
+
+	r.Value = "foo bar"
+	assert.Nil(t, r.BoolSlice())
+}
+
+func TestStringSet(t *testing.T) {
+	r := &Result{
+		Error: errors.New("test"),
+	}
+
+	assert.Nil(t, r.StringSet())
+
+	r.Error = nil
+	r.Value = []interface{}{[]byte("a"), []byte("b"), []byte("a")}
+	assert.Equal(t, map[string]struct{}{"a": {}, "b": {}}, r.StringSet())
+
+	r.Value = []interface{}{}
+	assert.Equal(t, map[string]struct{}{}, r.StringSet())
+}
+
+func TestStringResult(t *testing.T) {
+	r := &Result{
+		Error: errors.New("test"),
+	}
+
+	_, err := r.StringResult()
+	assert.EqualError(t, err, "test")
+
+	r.Error = nil
+	_, err = r.StringResult()
+	assert.Equal(t, ErrNil, err)
+
+	r.Value = []byte("")
+	val, err := r.StringResult()
+	assert.Nil(t, err)
+	assert.Equal(t, "", val)
+
+	r.Value = []byte("bar")
+	val, err = r.StringResult()
+	assert.Nil(t, err)
+	assert.Equal(t, "bar", val)
+}
+
+func TestInt64Slice(t *testing.T) {
+	r := &Result{
+		Error: errors.New("test"),
+	}
+
+	assert.Nil(t, r.Int64Slice())
+
+	r.Error = nil
+	r.Value = []interface{}{int64(1), nil, []byte("3")}
+	assert.Equal(t, []int64{1, 0, 3}, r.Int64Slice())
+
+	r.Value = []int64{4}
+	assert.Equal(t, []int64{4}, r.Int64Slice())
+
+	r.Value = "foo bar"
+	assert.Nil(t, r.Int64Slice())
+}
+
+func TestGeoLocations(t *testing.T) {
+	r := &Result{
+		Error: errors.New("test"),
+	}
+
+	assert.Nil(t, r.GeoLocations())
+
+	r.Error = nil
+	r.Value = []interface{}{[]byte("a"), []byte("b")}
+	assert.Equal(t, []GeoLocation{{Name: "a"}, {Name: "b"}}, r.GeoLocations())
+
+	// WITHDIST WITHHASH WITHCOORD
+	r.Value = []interface{}{
+		[]interface{}{[]byte("a"), []byte("1.5"), int64(3471579339700058), []interface{}{[]byte("106.8"), []byte("-6.2")}},
+		[]interface{}{[]byte("b"), []interface{}{[]byte("107"), []byte("-6.9")}},
+	}
+	assert.Equal(t, []GeoLocation{
+		{Name: "a", Dist: 1.5, Longitude: 106.8, Latitude: -6.2},
+		{Name: "b", Longitude: 107, Latitude: -6.9},
+	}, r.GeoLocations())
+
+	want := []GeoLocation{{Name: "a", Longitude: 1, Latitude: 2}}
+	r.Value = want
+	assert.Equal(t, want, r.GeoLocations())
+
+	r.Value = "foo"
+	assert.Nil(t, r.GeoLocations())
+}
@@ -0,0 +1,83 @@
+package session
+
+import (
+	"context"
+	"net/http"
+)
+
+type contextKey struct{}
+
+// Middleware loads the session of the request into its context, read it with FromContext.
+// The session is saved right before the response header is written, so changes made
+// after the handler starts writing the body are lost
+func (s *Store) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sess, err := s.Load(r)
+		if err != nil {
+			s.onError(w, r, err)
+			return
+		}
+
+		r = r.WithContext(context.WithValue(r.Context(), contextKey{}, sess))
+		sw := &writer{ResponseWriter: w, store: s, session: sess, request: r}
+		next.ServeHTTP(sw, r)
+		sw.save()
+	})
+}
+
+// FromContext returns the session loaded by Middleware, nil without the middleware
+func FromContext(ctx context.Context) *Session {
+	sess, _ := ctx.Value(contextKey{}).(*Session)
+	return sess
+}
+
+// writer saves the session before the response header is written. When saving
+// fails the response of OnError is written instead of the handler response
+type writer struct {
+	http.ResponseWriter
+	store   *Store
+	session *Session
+	request *http.Request
+	saved   bool
+	failed  bool
+}
+
+func (w *writer) WriteHeader(code int) {
+	if w.save() {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	if !w.save() {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *writer) Flush() {
+	if !w.save() {
+		return
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the original writer for http.ResponseController
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// save saves the session once, it reports whether the handler response may be written
+func (w *writer) save() bool {
+	if !w.saved {
+		w.saved = true
+		if err := w.store.Save(w.ResponseWriter, w.session); err != nil {
+			w.failed = true
+			w.store.onError(w.ResponseWriter, w.request, err)
+		}
+	}
+
+	return !w.failed
+}
@@ -0,0 +1,98 @@
+package session_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/encoding"
+	"github.com/5112100070/publib/storage/redis/dummyrds"
+	"github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/5112100070/publib/storage/redis/redistest"
+	. "github.com/5112100070/publib/storage/redis/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	store := newStore(t, rds, time.Minute)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/visit", func(w http.ResponseWriter, r *http.Request) {
+		sess := FromContext(r.Context())
+		visits := sess.Get("visits") + "x"
+		sess.Set("visits", visits)
+		fmt.Fprint(w, visits)
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, FromContext(r.Context()).SetUser(r.URL.Query().Get("user")))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/me", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, FromContext(r.Context()).UserID())
+	})
+
+	server := httptest.NewServer(store.Middleware(mux))
+	defer server.Close()
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	client := &http.Client{Jar: jar}
+
+	get := func(path string) string {
+		res, err := client.Get(server.URL + path)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		var body string
+		fmt.Fscan(res.Body, &body)
+		return body
+	}
+
+	assert.Equal(t, "x", get("/visit"))
+	assert.Equal(t, "xx", get("/visit"))
+	assert.Equal(t, "", get("/me"))
+
+	get("/login?user=42")
+	assert.Equal(t, "42", get("/me"))
+	assert.Equal(t, "xxx", get("/visit"))
+
+	require.NoError(t, store.RevokeUser("42"))
+	assert.Equal(t, "", get("/me"))
+	assert.Equal(t, "x", get("/visit"), "a revoked client starts a new session")
+}
+
+func TestMiddleware_Error(t *testing.T) {
+	m := dummyrds.Mocker{}
+	m.AddMock("HGETALL session:broken", "failed", true)
+
+	store, err := New(dummyrds.New(dummyrds.Config{MockingMap: m}), Config{Secret: "secret"})
+	require.NoError(t, err)
+
+	called := false
+	handler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		FromContext(r.Context()).Set("cart", "1")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, "created")
+	}))
+
+	// the session cannot be saved, HMSET is not mocked
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "created")
+
+	// the session cannot be loaded
+	called = false
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: "session_id", Value: "broken." + encoding.HMACSign("secret", []byte("broken"))})
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.False(t, called)
+}
@@ -0,0 +1,420 @@
+// Package session stores HTTP sessions in redis hashes.
+//
+// The session ID sent in the cookie is signed with the HMAC of encoding, so
+// forged IDs are rejected without a redis round trip. Every request extends the
+// session by the TTL, and the sessions of a user can be revoked together, e.g.
+// after a password change.
+//
+//	store, err := session.New(rds, session.Config{Secret: secret})
+//	http.Handle("/", store.Middleware(handler))
+//
+//	func handler(w http.ResponseWriter, r *http.Request) {
+//		sess := session.FromContext(r.Context())
+//		sess.Set("cart", cartID)
+//	}
+package session
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/5112100070/publib/encoding"
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// userField is the hash field holding the user of a session
+const userField = "_user"
+
+// New session store
+func New(rds redis.Redis, config Config) (*Store, error) {
+	if config.Secret == "" {
+		return nil, fmt.Errorf("%w: secret is required", ErrInvalidConfig)
+	}
+
+	if config.Prefix == "" {
+		config.Prefix = "session:"
+	}
+
+	// Set default 30 minutes sliding expiration
+	if config.TTL <= 0 {
+		config.TTL = 30 * time.Minute
+	}
+
+	if config.CookieName == "" {
+		config.CookieName = "session_id"
+	}
+
+	if config.Path == "" {
+		config.Path = "/"
+	}
+
+	if config.OnError == nil {
+		config.OnError = func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}
+
+	return &Store{
+		rds:    rds,
+		secret: config.Secret,
+		prefix: config.Prefix,
+		ttl:    config.TTL,
+		cookie: http.Cookie{
+			Name:     config.CookieName,
+			Path:     config.Path,
+			Domain:   config.Domain,
+			Secure:   config.Secure,
+			HttpOnly: true,
+			SameSite: config.SameSite,
+		},
+		onError: config.OnError,
+	}, nil
+}
+
+// Load returns the session of the request cookie, or a new session when the
+// cookie is missing, forged or its session expired
+func (s *Store) Load(r *http.Request) (*Session, error) {
+	cookie, err := r.Cookie(s.cookie.Name)
+	if err != nil {
+		return s.New()
+	}
+
+	sess, err := s.Get(cookie.Value)
+	switch err {
+	case nil:
+		return sess, nil
+	case ErrInvalidSignature, ErrNotFound:
+		return s.New()
+	}
+
+	return nil, err
+}
+
+// New returns a new session, it is stored by Save once it holds a value or a user
+func (s *Store) New() (*Session, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Session{
+		id:      id,
+		values:  make(map[string]string),
+		changed: make(map[string]struct{}),
+		deleted: make(map[string]struct{}),
+		isNew:   true,
+	}, nil
+}
+
+// Get returns the session of a signed ID and extends it by the TTL.
+// ErrInvalidSignature is returned for a forged ID, ErrNotFound for a missing session
+func (s *Store) Get(signedID string) (*Session, error) {
+	id, ok := s.verify(signedID)
+	if !ok {
+		return nil, ErrInvalidSignature
+	}
+
+	key := s.key(id)
+	res := s.rds.HGetAll(key)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	pairs := res.StringSlice()
+	if len(pairs) == 0 {
+		return nil, ErrNotFound
+	}
+
+	sess := &Session{
+		id:      id,
+		values:  make(map[string]string, len(pairs)/2),
+		changed: make(map[string]struct{}),
+		deleted: make(map[string]struct{}),
+	}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i] == userField {
+			sess.userID = pairs[i+1]
+		}
+		if !reserved(pairs[i]) {
+			sess.values[pairs[i]] = pairs[i+1]
+		}
+	}
+
+	// Sliding expiration, the user index lives as long as its latest session
+	if _, err := s.rds.PExpire(key, s.ttl); err != nil {
+		return nil, err
+	}
+
+	if sess.userID != "" {
+		if _, err := s.rds.PExpire(s.userKey(sess.userID), s.ttl); err != nil {
+			return nil, err
+		}
+	}
+
+	return sess, nil
+}
+
+// Save writes the changes of the session and sets the cookie when the session is new
+// or its ID was renewed. A new session without values nor user is not stored
+func (s *Store) Save(w http.ResponseWriter, sess *Session) error {
+	if sess.isNew && len(sess.values) == 0 && sess.userID == "" {
+		return nil
+	}
+
+	// Loading the session already extended it
+	if !sess.isNew && sess.oldID == "" && !sess.userChanged && len(sess.changed) == 0 && len(sess.deleted) == 0 {
+		return nil
+	}
+
+	key := s.key(sess.id)
+
+	// A renewed session is written whole under its new ID
+	if sess.oldID != "" {
+		if err := s.rds.Del(s.key(sess.oldID)); err != nil {
+			return err
+		}
+
+		if sess.oldUserID != "" {
+			if err := s.rds.SRem(s.userKey(sess.oldUserID), sess.oldID).Error; err != nil {
+				return err
+			}
+		}
+
+		for field := range sess.values {
+			sess.changed[field] = struct{}{}
+		}
+		sess.deleted = make(map[string]struct{})
+		sess.userChanged = sess.userID != ""
+	}
+
+	values := make(map[string]interface{}, len(sess.changed)+1)
+	for field := range sess.changed {
+		values[field] = sess.values[field]
+	}
+	if sess.userChanged {
+		values[userField] = sess.userID
+	}
+
+	if len(values) > 0 {
+		if err := s.rds.HMSet(key, values); err != nil {
+			return err
+		}
+	}
+
+	if len(sess.deleted) > 0 {
+		fields := make([]string, 0, len(sess.deleted))
+		for field := range sess.deleted {
+			fields = append(fields, field)
+		}
+		if err := s.rds.HDel(key, fields...); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.rds.PExpire(key, s.ttl); err != nil {
+		return err
+	}
+
+	if sess.userChanged && sess.userID != "" {
+		userKey := s.userKey(sess.userID)
+		if err := s.rds.SAdd(userKey, sess.id).Error; err != nil {
+			return err
+		}
+		if _, err := s.rds.PExpire(userKey, s.ttl); err != nil {
+			return err
+		}
+	}
+
+	if sess.isNew || sess.oldID != "" {
+		cookie := s.cookie
+		cookie.Value = s.sign(sess.id)
+		http.SetCookie(w, &cookie)
+	}
+
+	sess.changed = make(map[string]struct{})
+	sess.deleted = make(map[string]struct{})
+	sess.userChanged, sess.isNew = false, false
+	sess.oldID, sess.oldUserID = "", ""
+
+	return nil
+}
+
+// Destroy deletes the session and expires its cookie, the session is emptied
+// and gets a new ID
+func (s *Store) Destroy(w http.ResponseWriter, sess *Session) error {
+	for _, id := range []string{sess.id, sess.oldID} {
+		if id == "" {
+			continue
+		}
+
+		if err := s.rds.Del(s.key(id)); err != nil {
+			return err
+		}
+	}
+
+	if sess.userID != "" {
+		if err := s.rds.SRem(s.userKey(sess.userID), sess.id).Error; err != nil {
+			return err
+		}
+	}
+
+	// The ID renewed since the last Save is still in the index of its user
+	if sess.oldID != "" && sess.oldUserID != "" {
+		if err := s.rds.SRem(s.userKey(sess.oldUserID), sess.oldID).Error; err != nil {
+			return err
+		}
+	}
+
+	cookie := s.cookie
+	cookie.MaxAge = -1
+	http.SetCookie(w, &cookie)
+
+	fresh, err := s.New()
+	if err != nil {
+		return err
+	}
+
+	*sess = *fresh
+	return nil
+}
+
+// RevokeUser deletes every session of the user
+func (s *Store) RevokeUser(userID string) error {
+	userKey := s.userKey(userID)
+	res := s.rds.SMembers(userKey)
+	if res.Error != nil {
+		return res.Error
+	}
+
+	ids := res.StringSlice()
+	keys := make([]string, 0, len(ids)+1)
+	for _, id := range ids {
+		keys = append(keys, s.key(id))
+	}
+
+	return s.rds.Del(append(keys, userKey)...)
+}
+
+// sign returns the cookie value of id
+func (s *Store) sign(id string) string {
+	return id + "." + encoding.HMACSign(s.secret, []byte(id))
+}
+
+// verify returns the ID of a cookie value signed by sign
+func (s *Store) verify(signedID string) (string, bool) {
+	i := strings.IndexByte(signedID, '.')
+	if i <= 0 {
+		return "", false
+	}
+
+	id := signedID[:i]
+	return id, encoding.HMACVerify(s.secret, []byte(id), signedID[i+1:])
+}
+
+func (s *Store) key(id string) string {
+	return s.prefix + id
+}
+
+func (s *Store) userKey(userID string) string {
+	return s.prefix + "user:" + userID
+}
+
+// newID returns a random session ID
+func newID() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("session: generate id: %v", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// ID of the session
+func (sess *Session) ID() string {
+	return sess.id
+}
+
+// IsNew reports whether the session was not saved yet
+func (sess *Session) IsNew() bool {
+	return sess.isNew
+}
+
+// UserID of the session, empty when it has no user
+func (sess *Session) UserID() string {
+	return sess.userID
+}
+
+// SetUser binds the session to a user, e.g. on login. The session ID is renewed
+// so an ID known before the login cannot be reused
+func (sess *Session) SetUser(userID string) error {
+	if err := sess.Renew(); err != nil {
+		return err
+	}
+
+	sess.userID = userID
+	sess.userChanged = true
+	return nil
+}
+
+// Renew gives the session a new ID on Save, the values are kept
+func (sess *Session) Renew() error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	// Only the ID stored in redis needs to be replaced
+	if sess.oldID == "" && !sess.isNew {
+		sess.oldID, sess.oldUserID = sess.id, sess.userID
+	}
+	sess.id = id
+	return nil
+}
+
+// Get returns the value of field, empty when it is not set
+func (sess *Session) Get(field string) string {
+	return sess.values[field]
+}
+
+// Set sets the value of field. Fields starting with an underscore are reserved
+// for the store, they return ErrReservedField
+func (sess *Session) Set(field, value string) error {
+	if reserved(field) {
+		return fmt.Errorf("%w: %q", ErrReservedField, field)
+	}
+
+	sess.values[field] = value
+	sess.changed[field] = struct{}{}
+	delete(sess.deleted, field)
+	return nil
+}
+
+// Delete removes field, reserved fields return ErrReservedField
+func (sess *Session) Delete(field string) error {
+	if reserved(field) {
+		return fmt.Errorf("%w: %q", ErrReservedField, field)
+	}
+
+	delete(sess.values, field)
+	delete(sess.changed, field)
+	sess.deleted[field] = struct{}{}
+	return nil
+}
+
+// reserved reports whether field is kept for the store, such as the user ID
+func reserved(field string) bool {
+	return strings.HasPrefix(field, "_")
+}
+
+// Values returns a copy of the fields
+func (sess *Session) Values() map[string]string {
+	values := make(map[string]string, len(sess.values))
+	for k, v := range sess.values {
+		values[k] = v
+	}
+	return values
+}
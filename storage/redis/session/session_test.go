@@ -0,0 +1,185 @@
+package session_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+	"github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/5112100070/publib/storage/redis/redistest"
+	. "github.com/5112100070/publib/storage/redis/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStore(t *testing.T, rds redis.Redis, ttl time.Duration) *Store {
+	store, err := New(rds, Config{
+		Secret: "secret",
+		Prefix: redistest.Key(t, "session:"),
+		TTL:    ttl,
+	})
+	require.NoError(t, err)
+	return store
+}
+
+// signedID returns the cookie value set by Save
+func signedID(t *testing.T, w *httptest.ResponseRecorder) string {
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	return cookies[0].Value
+}
+
+func TestStore(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	store := newStore(t, rds, time.Minute)
+
+	sess, err := store.New()
+	require.NoError(t, err)
+
+	// an empty session is not stored
+	w := httptest.NewRecorder()
+	require.NoError(t, store.Save(w, sess))
+	assert.Empty(t, w.Result().Cookies())
+
+	require.NoError(t, sess.Set("cart", "1"))
+	require.NoError(t, sess.Set("lang", "id"))
+
+	// the fields of the store can not be written, e.g. to impersonate a user
+	assert.True(t, errors.Is(sess.Set("_user", "victim"), ErrReservedField))
+	assert.True(t, errors.Is(sess.Delete("_user"), ErrReservedField))
+
+	w = httptest.NewRecorder()
+	require.NoError(t, store.Save(w, sess))
+	assert.False(t, sess.IsNew())
+	signed := signedID(t, w)
+
+	loaded, err := store.Get(signed)
+	require.NoError(t, err)
+	assert.Equal(t, sess.ID(), loaded.ID())
+	assert.Empty(t, loaded.UserID())
+	assert.Equal(t, map[string]string{"cart": "1", "lang": "id"}, loaded.Values())
+
+	loaded.Set("cart", "2")
+	loaded.Delete("lang")
+	w = httptest.NewRecorder()
+	require.NoError(t, store.Save(w, loaded))
+	assert.Empty(t, w.Result().Cookies(), "the cookie is kept")
+
+	loaded, err = store.Get(signed)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"cart": "2"}, loaded.Values())
+
+	// forged IDs are rejected
+	_, err = store.Get(sess.ID() + ".forged")
+	assert.Equal(t, ErrInvalidSignature, err)
+	_, err = store.Get(sess.ID())
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	other := newStore(t, rds, time.Minute)
+	_, err = other.Get(signed[:len(signed)-1] + "A")
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	w = httptest.NewRecorder()
+	require.NoError(t, store.Destroy(w, loaded))
+	assert.Equal(t, -1, w.Result().Cookies()[0].MaxAge)
+	assert.Empty(t, loaded.Values())
+	_, err = store.Get(signed)
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestStore_SlidingExpiration(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	store := newStore(t, rds, 300*time.Millisecond)
+
+	sess, err := store.New()
+	require.NoError(t, err)
+	sess.Set("cart", "1")
+	w := httptest.NewRecorder()
+	require.NoError(t, store.Save(w, sess))
+	signed := signedID(t, w)
+
+	// every read extends the session
+	for i := 0; i < 3; i++ {
+		time.Sleep(200 * time.Millisecond)
+		_, err = store.Get(signed)
+		require.NoError(t, err)
+	}
+
+	time.Sleep(400 * time.Millisecond)
+	_, err = store.Get(signed)
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestStore_User(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	store := newStore(t, rds, time.Minute)
+
+	login := func(userID string) (*Session, string) {
+		sess, err := store.New()
+		require.NoError(t, err)
+		sess.Set("cart", "1")
+		w := httptest.NewRecorder()
+		require.NoError(t, store.Save(w, sess))
+		before := signedID(t, w)
+
+		require.NoError(t, sess.SetUser(userID))
+		w = httptest.NewRecorder()
+		require.NoError(t, store.Save(w, sess))
+		after := signedID(t, w)
+
+		// the ID known before the login is dropped
+		assert.NotEqual(t, before, after)
+		_, err = store.Get(before)
+		assert.Equal(t, ErrNotFound, err)
+
+		return sess, after
+	}
+
+	_, phone := login("42")
+	_, laptop := login("42")
+	_, other := login("7")
+
+	sess, err := store.Get(laptop)
+	require.NoError(t, err)
+	assert.Equal(t, "42", sess.UserID())
+	assert.Equal(t, map[string]string{"cart": "1"}, sess.Values(), "the values survive the renewal")
+
+	require.NoError(t, store.RevokeUser("42"))
+	for _, signed := range []string{phone, laptop} {
+		_, err = store.Get(signed)
+		assert.Equal(t, ErrNotFound, err)
+	}
+
+	_, err = store.Get(other)
+	assert.NoError(t, err)
+	assert.NoError(t, store.RevokeUser("none"))
+}
+
+func TestStore_RenewDestroy(t *testing.T) {
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	prefix := redistest.Key(t, "session:")
+	store, err := New(rds, Config{Secret: "secret", Prefix: prefix, TTL: time.Minute})
+	require.NoError(t, err)
+
+	sess, err := store.New()
+	require.NoError(t, err)
+	require.NoError(t, sess.SetUser("42"))
+	w := httptest.NewRecorder()
+	require.NoError(t, store.Save(w, sess))
+
+	loaded, err := store.Get(signedID(t, w))
+	require.NoError(t, err)
+	assert.Equal(t, []string{loaded.ID()}, rds.SMembers(prefix+"user:42").StringSlice())
+
+	// destroyed before the renewed ID was saved
+	require.NoError(t, loaded.Renew())
+	require.NoError(t, store.Destroy(httptest.NewRecorder(), loaded))
+	assert.Empty(t, rds.SMembers(prefix+"user:42").StringSlice(), "no ID is left in the user index")
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	_, err := New(redigo.New(redigo.Config{Endpoint: "null"}), Config{})
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+}
@@ -0,0 +1,72 @@
+package session
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Error list
+var (
+	// ErrInvalidConfig is returned by New when the secret is missing
+	ErrInvalidConfig = errors.New("session: invalid config")
+	// ErrInvalidSignature is returned by Get when the signed ID was not signed with the secret
+	ErrInvalidSignature = errors.New("session: invalid signature")
+	// ErrReservedField is returned by Set and Delete for the fields starting with an underscore
+	ErrReservedField = errors.New("session: reserved field")
+	// ErrNotFound is returned by Get when the session expired, was destroyed or revoked
+	ErrNotFound = errors.New("session: not found")
+)
+
+// Config of a session store
+type Config struct {
+	// Secret signs the session IDs, required
+	Secret string
+	// Prefix of the session keys, default is "session:"
+	Prefix string
+	// TTL is how long a session lives after its last request, default is 30 minutes
+	TTL time.Duration
+
+	// CookieName is the name of the session cookie, default is "session_id".
+	// The cookie is HttpOnly and lasts until the browser is closed
+	CookieName string
+	Path       string
+	Domain     string
+	Secure     bool
+	SameSite   http.SameSite
+
+	// OnError writes the response when the middleware fails to load or save a
+	// session, default is 500 Internal Server Error
+	OnError func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// Store of sessions, each session is a hash and the sessions of a user are
+// listed in a set so they can be revoked together
+type Store struct {
+	rds     redis.Redis
+	secret  string
+	prefix  string
+	ttl     time.Duration
+	cookie  http.Cookie
+	onError func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// Session of a client, its changes are written by Store.Save.
+// A session is not safe for concurrent use
+type Session struct {
+	id     string
+	userID string
+	values map[string]string
+
+	// changes since the session was loaded
+	changed     map[string]struct{}
+	deleted     map[string]struct{}
+	userChanged bool
+	isNew       bool
+
+	// oldID and oldUserID are set when the ID was renewed
+	oldID     string
+	oldUserID string
+}
@@ -0,0 +1,373 @@
+package sharded
+
+import (
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Single key commands run on the shard owning the key
+
+func (c *Client) Get(key string) *redis.Result {
+	return c.shard(key).Get(key)
+}
+
+func (c *Client) Setex(key string, expireTime int, value interface{}) error {
+	return c.shard(key).Setex(key, expireTime, value)
+}
+
+func (c *Client) Expire(key string, seconds int) error {
+	return c.shard(key).Expire(key, seconds)
+}
+
+func (c *Client) HDel(key string, fields ...string) error {
+	return c.shard(key).HDel(key, fields...)
+}
+
+func (c *Client) HDelSingle(key, field string) error {
+	return c.shard(key).HDelSingle(key, field)
+}
+
+func (c *Client) HSet(key, field string, value interface{}) error {
+	return c.shard(key).HSet(key, field, value)
+}
+
+func (c *Client) HMSet(key string, values map[string]interface{}) error {
+	return c.shard(key).HMSet(key, values)
+}
+
+func (c *Client) HGet(key, field string) *redis.Result {
+	return c.shard(key).HGet(key, field)
+}
+
+func (c *Client) HKeys(key string) *redis.Result {
+	return c.shard(key).HKeys(key)
+}
+
+func (c *Client) HVals(key string) *redis.Result {
+	return c.shard(key).HVals(key)
+}
+
+func (c *Client) HGetAll(key string) *redis.Result {
+	return c.shard(key).HGetAll(key)
+}
+
+func (c *Client) HExists(key, field string) *redis.Result {
+	return c.shard(key).HExists(key, field)
+}
+
+func (c *Client) HIncrBy(key, field string, increment int64) *redis.Result {
+	return c.shard(key).HIncrBy(key, field, increment)
+}
+
+func (c *Client) HIncrByFloat(key, field string, increment float64) *redis.Result {
+	return c.shard(key).HIncrByFloat(key, field, increment)
+}
+
+func (c *Client) HSetNX(key, field string, value interface{}) *redis.Result {
+	return c.shard(key).HSetNX(key, field, value)
+}
+
+func (c *Client) HLen(key string) *redis.Result {
+	return c.shard(key).HLen(key)
+}
+
+func (c *Client) HMGet(key string, fields ...string) *redis.Result {
+	return c.shard(key).HMGet(key, fields...)
+}
+
+func (c *Client) HStrLen(key, field string) *redis.Result {
+	return c.shard(key).HStrLen(key, field)
+}
+
+func (c *Client) HScan(key string, cursor int, match string, count int) *redis.Result {
+	return c.shard(key).HScan(key, cursor, match, count)
+}
+
+func (c *Client) IncrSingle(key string) (int, error) {
+	return c.shard(key).IncrSingle(key)
+}
+
+func (c *Client) IncrBy(key string, increment int64) (int64, error) {
+	return c.shard(key).IncrBy(key, increment)
+}
+
+func (c *Client) DecrBy(key string, decrement int64) (int64, error) {
+	return c.shard(key).DecrBy(key, decrement)
+}
+
+func (c *Client) IncrByFloat(key string, increment float64) (float64, error) {
+	return c.shard(key).IncrByFloat(key, increment)
+}
+
+func (c *Client) SetNX(key string, value interface{}) (bool, error) {
+	return c.shard(key).SetNX(key, value)
+}
+
+func (c *Client) GetSet(key string, value interface{}) (string, error) {
+	return c.shard(key).GetSet(key, value)
+}
+
+func (c *Client) GetDel(key string) (string, error) {
+	return c.shard(key).GetDel(key)
+}
+
+func (c *Client) GetEx(key string, expiration time.Duration) (string, error) {
+	return c.shard(key).GetEx(key, expiration)
+}
+
+func (c *Client) Append(key string, value interface{}) (int64, error) {
+	return c.shard(key).Append(key, value)
+}
+
+func (c *Client) StrLen(key string) (int64, error) {
+	return c.shard(key).StrLen(key)
+}
+
+func (c *Client) GetRange(key string, start, end int) (string, error) {
+	return c.shard(key).GetRange(key, start, end)
+}
+
+func (c *Client) SetRange(key string, offset int, value interface{}) (int64, error) {
+	return c.shard(key).SetRange(key, offset, value)
+}
+
+func (c *Client) ZAdd(key string, members ...redis.Z) error {
+	return c.shard(key).ZAdd(key, members...)
+}
+
+func (c *Client) ZRange(key string, start, stop int) *redis.Result {
+	return c.shard(key).ZRange(key, start, stop)
+}
+
+func (c *Client) ZRangeByScore(key, min, max string, limit int) *redis.Result {
+	return c.shard(key).ZRangeByScore(key, min, max, limit)
+}
+
+func (c *Client) ZRem(key string, members ...interface{}) *redis.Result {
+	return c.shard(key).ZRem(key, members...)
+}
+
+func (c *Client) ZIncrBy(key string, increment float64, member interface{}) *redis.Result {
+	return c.shard(key).ZIncrBy(key, increment, member)
+}
+
+func (c *Client) ZScore(key string, member interface{}) *redis.Result {
+	return c.shard(key).ZScore(key, member)
+}
+
+func (c *Client) ZRank(key string, member interface{}) *redis.Result {
+	return c.shard(key).ZRank(key, member)
+}
+
+func (c *Client) ZRevRank(key string, member interface{}) *redis.Result {
+	return c.shard(key).ZRevRank(key, member)
+}
+
+func (c *Client) ZRevRange(key string, start, stop int) *redis.Result {
+	return c.shard(key).ZRevRange(key, start, stop)
+}
+
+func (c *Client) ZRevRangeByScore(key, max, min string, limit int) *redis.Result {
+	return c.shard(key).ZRevRangeByScore(key, max, min, limit)
+}
+
+func (c *Client) ZRangeWithScores(key string, start, stop int) *redis.Result {
+	return c.shard(key).ZRangeWithScores(key, start, stop)
+}
+
+func (c *Client) ZRevRangeWithScores(key string, start, stop int) *redis.Result {
+	return c.shard(key).ZRevRangeWithScores(key, start, stop)
+}
+
+func (c *Client) ZRangeByScoreWithScores(key, min, max string, limit int) *redis.Result {
+	return c.shard(key).ZRangeByScoreWithScores(key, min, max, limit)
+}
+
+func (c *Client) ZRevRangeByScoreWithScores(key, max, min string, limit int) *redis.Result {
+	return c.shard(key).ZRevRangeByScoreWithScores(key, max, min, limit)
+}
+
+func (c *Client) ZCard(key string) *redis.Result {
+	return c.shard(key).ZCard(key)
+}
+
+func (c *Client) ZCount(key, min, max string) *redis.Result {
+	return c.shard(key).ZCount(key, min, max)
+}
+
+func (c *Client) ZRemRangeByScore(key, min, max string) *redis.Result {
+	return c.shard(key).ZRemRangeByScore(key, min, max)
+}
+
+func (c *Client) ZRemRangeByRank(key string, start, stop int) *redis.Result {
+	return c.shard(key).ZRemRangeByRank(key, start, stop)
+}
+
+func (c *Client) ZPopMin(key string, count int) *redis.Result {
+	return c.shard(key).ZPopMin(key, count)
+}
+
+func (c *Client) ZPopMax(key string, count int) *redis.Result {
+	return c.shard(key).ZPopMax(key, count)
+}
+
+func (c *Client) SAdd(key string, members ...interface{}) *redis.Result {
+	return c.shard(key).SAdd(key, members...)
+}
+
+func (c *Client) SRem(key string, members ...interface{}) *redis.Result {
+	return c.shard(key).SRem(key, members...)
+}
+
+func (c *Client) SMembers(key string) *redis.Result {
+	return c.shard(key).SMembers(key)
+}
+
+func (c *Client) SIsMember(key string, member interface{}) *redis.Result {
+	return c.shard(key).SIsMember(key, member)
+}
+
+func (c *Client) SMIsMember(key string, members ...interface{}) *redis.Result {
+	return c.shard(key).SMIsMember(key, members...)
+}
+
+func (c *Client) SCard(key string) *redis.Result {
+	return c.shard(key).SCard(key)
+}
+
+func (c *Client) SPop(key string, count int) *redis.Result {
+	return c.shard(key).SPop(key, count)
+}
+
+func (c *Client) SRandMember(key string, count int) *redis.Result {
+	return c.shard(key).SRandMember(key, count)
+}
+
+func (c *Client) Ttl(key string) *redis.Result {
+	return c.shard(key).Ttl(key)
+}
+
+func (c *Client) PSetEx(key string, ttl time.Duration, value interface{}) error {
+	return c.shard(key).PSetEx(key, ttl, value)
+}
+
+func (c *Client) PExpire(key string, ttl time.Duration) (bool, error) {
+	return c.shard(key).PExpire(key, ttl)
+}
+
+func (c *Client) ExpireAt(key string, at time.Time) (bool, error) {
+	return c.shard(key).ExpireAt(key, at)
+}
+
+func (c *Client) PExpireAt(key string, at time.Time) (bool, error) {
+	return c.shard(key).PExpireAt(key, at)
+}
+
+func (c *Client) Persist(key string) (bool, error) {
+	return c.shard(key).Persist(key)
+}
+
+func (c *Client) PTTL(key string) (time.Duration, error) {
+	return c.shard(key).PTTL(key)
+}
+
+func (c *Client) Dump(key string) *redis.Result {
+	return c.shard(key).Dump(key)
+}
+
+func (c *Client) Restore(key string, ttl time.Duration, value []byte, replace bool) error {
+	return c.shard(key).Restore(key, ttl, value, replace)
+}
+
+func (c *Client) Exists(key string) *redis.Result {
+	return c.shard(key).Exists(key)
+}
+
+func (c *Client) SetWithOptions(key string, value interface{}, opts redis.SetOptions) (bool, string, error) {
+	return c.shard(key).SetWithOptions(key, value, opts)
+}
+
+func (c *Client) LPush(key string, values ...interface{}) error {
+	return c.shard(key).LPush(key, values...)
+}
+
+func (c *Client) RPush(key string, values ...interface{}) error {
+	return c.shard(key).RPush(key, values...)
+}
+
+func (c *Client) LPop(key string) *redis.Result {
+	return c.shard(key).LPop(key)
+}
+
+func (c *Client) RPop(key string) *redis.Result {
+	return c.shard(key).RPop(key)
+}
+
+func (c *Client) LLen(key string) *redis.Result {
+	return c.shard(key).LLen(key)
+}
+
+func (c *Client) LRange(key string, start, stop int) *redis.Result {
+	return c.shard(key).LRange(key, start, stop)
+}
+
+func (c *Client) LTrim(key string, start, stop int) error {
+	return c.shard(key).LTrim(key, start, stop)
+}
+
+func (c *Client) LRem(key string, count int, value interface{}) *redis.Result {
+	return c.shard(key).LRem(key, count, value)
+}
+
+func (c *Client) LIndex(key string, index int) *redis.Result {
+	return c.shard(key).LIndex(key, index)
+}
+
+func (c *Client) SetBit(key string, offset int64, value int) *redis.Result {
+	return c.shard(key).SetBit(key, offset, value)
+}
+
+func (c *Client) GetBit(key string, offset int64) *redis.Result {
+	return c.shard(key).GetBit(key, offset)
+}
+
+func (c *Client) BitCount(key string) *redis.Result {
+	return c.shard(key).BitCount(key)
+}
+
+func (c *Client) BitCountRange(key string, start, end int64) *redis.Result {
+	return c.shard(key).BitCountRange(key, start, end)
+}
+
+func (c *Client) BitPos(key string, bit int) *redis.Result {
+	return c.shard(key).BitPos(key, bit)
+}
+
+func (c *Client) BitPosRange(key string, bit int, start, end int64) *redis.Result {
+	return c.shard(key).BitPosRange(key, bit, start, end)
+}
+
+func (c *Client) BitField(key string, ops ...redis.BitFieldOp) *redis.Result {
+	return c.shard(key).BitField(key, ops...)
+}
+
+func (c *Client) PFAdd(key string, elements ...interface{}) *redis.Result {
+	return c.shard(key).PFAdd(key, elements...)
+}
+
+func (c *Client) GeoAdd(key string, locations ...redis.GeoLocation) *redis.Result {
+	return c.shard(key).GeoAdd(key, locations...)
+}
+
+func (c *Client) GeoPos(key string, members ...string) *redis.Result {
+	return c.shard(key).GeoPos(key, members...)
+}
+
+func (c *Client) GeoDist(key, member1, member2, unit string) *redis.Result {
+	return c.shard(key).GeoDist(key, member1, member2, unit)
+}
+
+func (c *Client) GeoSearch(key string, query redis.GeoSearchQuery) *redis.Result {
+	return c.shard(key).GeoSearch(key, query)
+}
@@ -0,0 +1,269 @@
+package sharded
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Ping pings every shard, returns the first failure
+func (c *Client) Ping() *redis.Result {
+	var res *redis.Result
+	for _, s := range c.snapshot() {
+		if res = s.rds.Ping(); res.Error != nil {
+			return res
+		}
+	}
+
+	return res
+}
+
+// Set runs on the shard owning key, routed by the bytes sent for it, see redis.FormatArg
+func (c *Client) Set(key, value interface{}, args ...interface{}) *redis.Result {
+	return c.shard(string(redis.FormatArg(key))).Set(key, value, args...)
+}
+
+// MGet fans out to the shards and returns the values in the order of keys
+func (c *Client) MGet(keys ...string) *redis.Result {
+	values := make([]interface{}, len(keys))
+	for _, g := range c.group(keys) {
+		res := g.rds.MGet(g.keys...)
+		if res.Error != nil {
+			return res
+		}
+
+		reply, err := replyValues(res)
+		if err != nil {
+			return &redis.Result{Error: err}
+		}
+		for i, v := range reply {
+			if i < len(g.index) {
+				values[g.index[i]] = v
+			}
+		}
+	}
+
+	return &redis.Result{Value: values}
+}
+
+// MSet fans out to the shards, the keys of one shard are set atomically.
+// A failing shard does not stop the others, returns the first error
+func (c *Client) MSet(values map[string]interface{}) error {
+	shards := c.snapshot()
+	byShard := make(map[int]map[string]interface{})
+	for key, value := range values {
+		n := pick(shards, key)
+		if byShard[n] == nil {
+			byShard[n] = make(map[string]interface{})
+		}
+		byShard[n][key] = value
+	}
+
+	var firstErr error
+	for n, s := range shards {
+		if pairs, ok := byShard[n]; ok {
+			if err := s.rds.MSet(pairs); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// MSetNX requires all keys on one shard, otherwise ErrCrossShard
+func (c *Client) MSetNX(values map[string]interface{}) (bool, error) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	rds, err := c.sameShard(keys...)
+	if err != nil {
+		return false, err
+	}
+
+	return rds.MSetNX(values)
+}
+
+// Del fans out to the shards, returns the first error
+func (c *Client) Del(keys ...string) error {
+	return c.fanOut(keys, redis.Redis.Del)
+}
+
+// Incr fans out to the shards, returns the first error
+func (c *Client) Incr(keys ...string) error {
+	return c.fanOut(keys, redis.Redis.Incr)
+}
+
+// Decr fans out to the shards, returns the first error
+func (c *Client) Decr(keys ...string) error {
+	return c.fanOut(keys, redis.Redis.Decr)
+}
+
+// Unlink fans out to the shards, returns the total number of unlinked keys
+func (c *Client) Unlink(keys ...string) *redis.Result {
+	var total int64
+	for _, g := range c.group(keys) {
+		res := g.rds.Unlink(g.keys...)
+		if res.Error != nil {
+			return res
+		}
+		total += res.Int64()
+	}
+
+	return &redis.Result{Value: total}
+}
+
+// Rename requires both keys on one shard, otherwise ErrCrossShard
+func (c *Client) Rename(key, newKey string) *redis.Result {
+	rds, err := c.sameShard(key, newKey)
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+
+	return rds.Rename(key, newKey)
+}
+
+// BLPop requires all keys on one shard, otherwise ErrCrossShard
+func (c *Client) BLPop(timeout time.Duration, keys ...string) *redis.Result {
+	rds, err := c.sameShard(keys...)
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+
+	return rds.BLPop(timeout, keys...)
+}
+
+// BRPop requires all keys on one shard, otherwise ErrCrossShard
+func (c *Client) BRPop(timeout time.Duration, keys ...string) *redis.Result {
+	rds, err := c.sameShard(keys...)
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+
+	return rds.BRPop(timeout, keys...)
+}
+
+// BLMove requires both lists on one shard, otherwise ErrCrossShard
+func (c *Client) BLMove(source, destination, srcSide, destSide string, timeout time.Duration) *redis.Result {
+	rds, err := c.sameShard(source, destination)
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+
+	return rds.BLMove(source, destination, srcSide, destSide, timeout)
+}
+
+// BitOp requires all keys on one shard, otherwise ErrCrossShard
+func (c *Client) BitOp(op, destination string, keys ...string) *redis.Result {
+	rds, err := c.sameShard(append([]string{destination}, keys...)...)
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+
+	return rds.BitOp(op, destination, keys...)
+}
+
+// PFCount requires all keys on one shard, otherwise ErrCrossShard
+func (c *Client) PFCount(keys ...string) *redis.Result {
+	rds, err := c.sameShard(keys...)
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+
+	return rds.PFCount(keys...)
+}
+
+// PFMerge requires all keys on one shard, otherwise ErrCrossShard
+func (c *Client) PFMerge(destination string, keys ...string) error {
+	rds, err := c.sameShard(append([]string{destination}, keys...)...)
+	if err != nil {
+		return err
+	}
+
+	return rds.PFMerge(destination, keys...)
+}
+
+// Scan iterates the shards one after the other. The cursor holds the shard
+// index, so it is only valid while no shard is added
+func (c *Client) Scan(cursor int, match string, count int) *redis.Result {
+	return c.scan(cursor, func(rds redis.Redis, cursor int) *redis.Result {
+		return rds.Scan(cursor, match, count)
+	})
+}
+
+// ScanType iterates the shards one after the other, like Scan
+func (c *Client) ScanType(cursor int, match string, count int, keyType string) *redis.Result {
+	return c.scan(cursor, func(rds redis.Redis, cursor int) *redis.Result {
+		return rds.ScanType(cursor, match, count, keyType)
+	})
+}
+
+func (c *Client) scan(cursor int, fn func(rds redis.Redis, cursor int) *redis.Result) *redis.Result {
+	shards := c.snapshot()
+	n := len(shards)
+
+	// the shard index is the remainder of the cursor
+	index, shardCursor := cursor%n, cursor/n
+	next, keys, err := fn(shards[index].rds, shardCursor).ScanResult()
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+
+	switch {
+	case next != 0:
+		next = next*n + index
+	case index+1 < n:
+		next = index + 1
+	}
+
+	reply := make([]interface{}, len(keys))
+	for i, key := range keys {
+		reply[i] = []byte(key)
+	}
+
+	return &redis.Result{Value: []interface{}{[]byte(strconv.Itoa(next)), reply}}
+}
+
+// fanOut runs fn on every shard with its keys, a failing shard does not stop
+// the others. Returns the first error
+func (c *Client) fanOut(keys []string, fn func(rds redis.Redis, keys ...string) error) error {
+	var firstErr error
+	for _, g := range c.group(keys) {
+		if err := fn(g.rds, g.keys...); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// replyValues reads an array reply of redigo or of a mock
+func replyValues(res *redis.Result) ([]interface{}, error) {
+	switch val := res.Value.(type) {
+	case []interface{}:
+		return val, nil
+	case []string:
+		values := make([]interface{}, len(val))
+		for i, v := range val {
+			values[i] = v
+		}
+		return values, nil
+	}
+
+	return nil, fmt.Errorf("sharded: unexpected reply type %T", res.Value)
+}
+
+// Eval requires all keys on one shard, otherwise ErrCrossShard. A script
+// without keys runs on the shard of the empty key
+func (c *Client) Eval(script string, keys []string, args ...interface{}) *redis.Result {
+	rds, err := c.sameShard(keys...)
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+
+	return rds.Eval(script, keys, args...)
+}
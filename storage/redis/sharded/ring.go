@@ -0,0 +1,53 @@
+package sharded
+
+import (
+	"hash/fnv"
+	"strings"
+)
+
+// HashTag returns the part of key that is hashed, the content of the first
+// non-empty {...} like redis cluster does, otherwise the whole key
+func HashTag(key string) string {
+	start := strings.IndexByte(key, '{')
+	if start < 0 {
+		return key
+	}
+
+	end := strings.IndexByte(key[start+1:], '}')
+	if end <= 0 {
+		return key
+	}
+
+	return key[start+1 : start+1+end]
+}
+
+// pick returns the index of the shard with the highest score for key.
+// Adding a shard only moves the keys the new shard wins, about 1/n of them
+func pick(shards []shard, key string) int {
+	h := hash(HashTag(key))
+
+	best, bestScore := 0, uint64(0)
+	for i, s := range shards {
+		if score := mix(h ^ s.seed); i == 0 || score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+
+	return best
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// mix is the splitmix64 finalizer, it spreads the fnv hashes of similar keys
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,68 @@
+package sharded_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/5112100070/publib/storage/redis"
+	"github.com/5112100070/publib/storage/redis/dummyrds"
+	. "github.com/5112100070/publib/storage/redis/sharded"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashTag(t *testing.T) {
+	assert.Equal(t, "user:1", HashTag("{user:1}:cart"))
+	assert.Equal(t, "user:1", HashTag("cart:{user:1}"))
+	assert.Equal(t, "a", HashTag("{a}{b}"))
+	assert.Equal(t, "foo{}bar", HashTag("foo{}bar"), "empty tags hash the whole key")
+	assert.Equal(t, "foo{bar", HashTag("foo{bar"))
+	assert.Equal(t, "foo", HashTag("foo"))
+}
+
+func TestShardName(t *testing.T) {
+	c := NewWithClients(clients("a", "b", "c"))
+	assert.Equal(t, []string{"a", "b", "c"}, c.Shards())
+
+	// keys sharing a tag share a shard
+	assert.Equal(t, c.ShardName("{user:1}:cart"), c.ShardName("{user:1}:orders"))
+	assert.Equal(t, c.ShardName("user:1"), c.ShardName("{user:1}:orders"))
+
+	assert.Equal(t, ErrDuplicateShard, c.AddClient("b", dummyrds.New(dummyrds.Config{})))
+}
+
+func TestDistribution(t *testing.T) {
+	c := NewWithClients(clients("a", "b", "c"))
+
+	const keys = 30000
+	before := make([]string, keys)
+	count := map[string]int{}
+	for i := range before {
+		before[i] = c.ShardName("key:" + strconv.Itoa(i))
+		count[before[i]]++
+	}
+
+	for name, n := range count {
+		assert.InDelta(t, keys/3, n, keys/30, "shard %s owns %d keys", name, n)
+	}
+
+	// only the keys won by the new shard move
+	require.NoError(t, c.AddClient("d", dummyrds.New(dummyrds.Config{})))
+	moved := 0
+	for i, was := range before {
+		now := c.ShardName("key:" + strconv.Itoa(i))
+		if now != was {
+			assert.Equal(t, "d", now)
+			moved++
+		}
+	}
+	assert.InDelta(t, keys/4, moved, keys/40)
+}
+
+func clients(names ...string) map[string]redis.Redis {
+	m := make(map[string]redis.Redis, len(names))
+	for _, name := range names {
+		m[name] = dummyrds.New(dummyrds.Config{})
+	}
+	return m
+}
@@ -0,0 +1,177 @@
+package sharded
+
+import (
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// SInter intersects the sets, on the owning shard when they share one,
+// otherwise with the intersection of every shard computed on the client
+func (c *Client) SInter(keys ...string) *redis.Result {
+	members, err := c.sinter(keys)
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+
+	return &redis.Result{Value: members}
+}
+
+// SUnion unions the sets, merging the union of every shard on the client
+func (c *Client) SUnion(keys ...string) *redis.Result {
+	members, err := c.sunion(keys)
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+
+	return &redis.Result{Value: members}
+}
+
+// SDiff subtracts the other sets from the first one, on the client when they are spread
+func (c *Client) SDiff(keys ...string) *redis.Result {
+	members, err := c.sdiff(keys)
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+
+	return &redis.Result{Value: members}
+}
+
+// SInterStore stores SInter in destination, atomically only when all keys share a shard
+func (c *Client) SInterStore(destination string, keys ...string) *redis.Result {
+	if rds, err := c.sameShard(append([]string{destination}, keys...)...); err == nil {
+		return rds.SInterStore(destination, keys...)
+	}
+
+	return c.store(destination, c.sinter, keys)
+}
+
+// SUnionStore stores SUnion in destination, atomically only when all keys share a shard
+func (c *Client) SUnionStore(destination string, keys ...string) *redis.Result {
+	if rds, err := c.sameShard(append([]string{destination}, keys...)...); err == nil {
+		return rds.SUnionStore(destination, keys...)
+	}
+
+	return c.store(destination, c.sunion, keys)
+}
+
+// SDiffStore stores SDiff in destination, atomically only when all keys share a shard
+func (c *Client) SDiffStore(destination string, keys ...string) *redis.Result {
+	if rds, err := c.sameShard(append([]string{destination}, keys...)...); err == nil {
+		return rds.SDiffStore(destination, keys...)
+	}
+
+	return c.store(destination, c.sdiff, keys)
+}
+
+func (c *Client) sinter(keys []string) ([]string, error) {
+	groups := c.group(keys)
+	if len(groups) == 1 {
+		res := groups[0].rds.SInter(keys...)
+		return res.StringSlice(), res.Error
+	}
+
+	var members []string
+	for i, g := range groups {
+		res := g.rds.SInter(g.keys...)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+
+		if i == 0 {
+			members = res.StringSlice()
+			continue
+		}
+
+		in := res.StringSet()
+		kept := members[:0]
+		for _, m := range members {
+			if _, ok := in[m]; ok {
+				kept = append(kept, m)
+			}
+		}
+		members = kept
+	}
+
+	return members, nil
+}
+
+func (c *Client) sunion(keys []string) ([]string, error) {
+	groups := c.group(keys)
+	if len(groups) == 1 {
+		res := groups[0].rds.SUnion(keys...)
+		return res.StringSlice(), res.Error
+	}
+
+	seen := make(map[string]struct{})
+	members := []string{}
+	for _, g := range groups {
+		res := g.rds.SUnion(g.keys...)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+
+		for _, m := range res.StringSlice() {
+			if _, ok := seen[m]; !ok {
+				seen[m] = struct{}{}
+				members = append(members, m)
+			}
+		}
+	}
+
+	return members, nil
+}
+
+func (c *Client) sdiff(keys []string) ([]string, error) {
+	if rds, err := c.sameShard(keys...); err == nil {
+		res := rds.SDiff(keys...)
+		return res.StringSlice(), res.Error
+	}
+
+	res := c.shard(keys[0]).SMembers(keys[0])
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	others, err := c.sunion(keys[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	exclude := make(map[string]struct{}, len(others))
+	for _, m := range others {
+		exclude[m] = struct{}{}
+	}
+
+	members := []string{}
+	for _, m := range res.StringSlice() {
+		if _, ok := exclude[m]; !ok {
+			members = append(members, m)
+		}
+	}
+
+	return members, nil
+}
+
+// store replaces destination with the members computed by fn, returns their number
+func (c *Client) store(destination string, fn func(keys []string) ([]string, error), keys []string) *redis.Result {
+	members, err := fn(keys)
+	if err != nil {
+		return &redis.Result{Error: err}
+	}
+
+	rds := c.shard(destination)
+	if err := rds.Del(destination); err != nil {
+		return &redis.Result{Error: err}
+	}
+
+	if len(members) > 0 {
+		values := make([]interface{}, len(members))
+		for i, m := range members {
+			values[i] = m
+		}
+		if res := rds.SAdd(destination, values...); res.Error != nil {
+			return res
+		}
+	}
+
+	return &redis.Result{Value: int64(len(members))}
+}
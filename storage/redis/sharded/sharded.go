@@ -0,0 +1,256 @@
+// Package sharded spreads keys over several standalone redis instances without redis cluster.
+//
+// Keys are placed with rendezvous hashing on the shard names, so adding a shard only moves
+// the keys it wins. Like redis cluster only the content of a {hash tag} is hashed, which keeps
+// related keys on the same shard for the multi-key commands that can not be split.
+//
+//	rds := sharded.New(
+//		redigo.Config{Endpoint: "10.0.0.1:6379"},
+//		redigo.Config{Endpoint: "10.0.0.2:6379"},
+//	)
+//	rds.Set("{user:1}:cart", "...")
+package sharded
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+	"github.com/5112100070/publib/storage/redis/redigo"
+)
+
+var _ redis.Redis = (*Client)(nil)
+
+// New sharded redis over redigo clients, every shard is named after its endpoint.
+// It panics without any config
+func New(configs ...redigo.Config) *Client {
+	if len(configs) == 0 {
+		panic("sharded: at least one shard is required")
+	}
+
+	c := &Client{}
+	for _, config := range configs {
+		c.shards = append(c.shards, newShard(config.Endpoint, redigo.New(config)))
+	}
+
+	return c
+}
+
+// NewWithClients sharded redis over any implementation, e.g. decorated clients.
+// The names place the keys, keep them when a shard moves to another endpoint.
+// It panics without any client
+func NewWithClients(clients map[string]redis.Redis) *Client {
+	if len(clients) == 0 {
+		panic("sharded: at least one shard is required")
+	}
+
+	names := make([]string, 0, len(clients))
+	for name := range clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	c := &Client{}
+	for _, name := range names {
+		c.shards = append(c.shards, newShard(name, clients[name]))
+	}
+
+	return c
+}
+
+// AddShard adds a redigo client named after its endpoint.
+// The keys now owned by the new shard are not moved, see ShardName
+func (c *Client) AddShard(config redigo.Config) error {
+	return c.AddClient(config.Endpoint, redigo.New(config))
+}
+
+// AddClient adds a shard under name.
+// The keys now owned by the new shard are not moved, see ShardName
+func (c *Client) AddClient(name string, rds redis.Redis) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, s := range c.shards {
+		if s.name == name {
+			return ErrDuplicateShard
+		}
+	}
+
+	// copy on write, running scans and fan outs keep their snapshot
+	shards := make([]shard, len(c.shards), len(c.shards)+1)
+	copy(shards, c.shards)
+	c.shards = append(shards, newShard(name, rds))
+
+	return nil
+}
+
+// Shards returns the shard names in scan order
+func (c *Client) Shards() []string {
+	shards := c.snapshot()
+
+	names := make([]string, len(shards))
+	for i, s := range shards {
+		names[i] = s.name
+	}
+
+	return names
+}
+
+// ShardName returns the name of the shard owning key, e.g. to move the keys
+// that changed owner after adding a shard
+func (c *Client) ShardName(key string) string {
+	shards := c.snapshot()
+	return shards[pick(shards, key)].name
+}
+
+func newShard(name string, rds redis.Redis) shard {
+	return shard{
+		name: name,
+		seed: hash(name),
+		rds:  rds,
+	}
+}
+
+func (c *Client) snapshot() []shard {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.shards
+}
+
+// shard returns the client owning key
+func (c *Client) shard(key string) redis.Redis {
+	shards := c.snapshot()
+	return shards[pick(shards, key)].rds
+}
+
+// group splits keys by owning shard, in shard order
+func (c *Client) group(keys []string) []group {
+	shards := c.snapshot()
+
+	byShard := make(map[int]*group)
+	for i, key := range keys {
+		n := pick(shards, key)
+		g, ok := byShard[n]
+		if !ok {
+			g = &group{rds: shards[n].rds}
+			byShard[n] = g
+		}
+		g.keys = append(g.keys, key)
+		g.index = append(g.index, i)
+	}
+
+	groups := make([]group, 0, len(byShard))
+	for n := range shards {
+		if g, ok := byShard[n]; ok {
+			groups = append(groups, *g)
+		}
+	}
+
+	return groups
+}
+
+// sameShard returns the client owning all keys, ErrCrossShard when they are spread
+func (c *Client) sameShard(keys ...string) (redis.Redis, error) {
+	groups := c.group(keys)
+	if len(groups) == 0 {
+		return c.shard(""), nil
+	}
+	if len(groups) > 1 {
+		return nil, ErrCrossShard
+	}
+
+	return groups[0].rds, nil
+}
+
+// Status sums the pool counts of the shards. It is connected when every shard is,
+// active since the least recently active shard, the latency is the slowest ping and
+// the error is the first shard error prefixed with its name. See ShardStatus for the
+// status of every shard
+func (c *Client) Status() redis.Status {
+	shards := c.snapshot()
+
+	names := make([]string, len(shards))
+	status := redis.Status{Connected: true}
+	for i, s := range shards {
+		st := s.rds.Status()
+		names[i] = s.name
+
+		status.Connected = status.Connected && st.Connected
+		if i == 0 || st.Role == status.Role {
+			status.Role = st.Role
+		} else {
+			status.Role = "mixed"
+		}
+		if i == 0 || activeBefore(st.LastActive, status.LastActive) {
+			status.LastActive = st.LastActive
+		}
+		if st.LastError != "" && status.LastError == "" {
+			status.LastError = s.name + ": " + st.LastError
+		}
+		if st.PingLatency > status.PingLatency {
+			status.PingLatency = st.PingLatency
+		}
+		status.ActiveCount += st.ActiveCount
+		status.IdleCount += st.IdleCount
+		status.WaitCount += st.WaitCount
+		status.WaitDuration += st.WaitDuration
+	}
+	status.Name = strings.Join(names, ",")
+
+	return status
+}
+
+// activeBefore tells whether a is less recent than b, never active is the least recent
+func activeBefore(a, b string) bool {
+	ta, err := time.Parse(time.RFC1123, a)
+	if err != nil {
+		return true
+	}
+
+	tb, err := time.Parse(time.RFC1123, b)
+	if err != nil {
+		return false
+	}
+
+	return ta.Before(tb)
+}
+
+// ShardStatus returns the status of every shard by name
+func (c *Client) ShardStatus() map[string]redis.Status {
+	shards := c.snapshot()
+
+	status := make(map[string]redis.Status, len(shards))
+	for _, s := range shards {
+		status[s.name] = s.rds.Status()
+	}
+
+	return status
+}
+
+// DoHeartBeat starts the heartbeat of every shard
+func (c *Client) DoHeartBeat() {
+	for _, s := range c.snapshot() {
+		s.rds.DoHeartBeat()
+	}
+}
+
+// StopBeat stops the heartbeat of every shard
+func (c *Client) StopBeat() {
+	for _, s := range c.snapshot() {
+		s.rds.StopBeat()
+	}
+}
+
+// Close closes every shard, a failing shard does not stop the others. Returns the first error
+func (c *Client) Close() error {
+	var firstErr error
+	for _, s := range c.snapshot() {
+		if err := s.rds.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
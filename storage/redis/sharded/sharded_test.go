@@ -0,0 +1,188 @@
+package sharded_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis"
+	"github.com/5112100070/publib/storage/redis/dummyrds"
+	"github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/5112100070/publib/storage/redis/redistest"
+	. "github.com/5112100070/publib/storage/redis/sharded"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConformance(t *testing.T) {
+	addrs := []string{redistest.StartServer(t), redistest.StartServer(t)}
+	if addrs[0] == addrs[1] {
+		t.Skip("sharded: needs two servers, unset " + redistest.EnvServerAddr + " to launch them")
+	}
+
+	redistest.RunSuite(t, func(t *testing.T) redis.Redis {
+		return New(redigo.Config{Endpoint: addrs[0]}, redigo.Config{Endpoint: addrs[1]})
+	})
+}
+
+// mocked returns a client over two mocked shards and a key owned by each of them
+func mocked(t *testing.T, a, b dummyrds.Mocker) (c *Client, keyA, keyB string) {
+	c = NewWithClients(map[string]redis.Redis{
+		"a": dummyrds.New(dummyrds.Config{MockingMap: a}),
+		"b": dummyrds.New(dummyrds.Config{MockingMap: b}),
+	})
+
+	for _, key := range []string{"k0", "k1", "k2", "k3", "k4", "k5", "k6", "k7"} {
+		switch c.ShardName(key) {
+		case "a":
+			keyA = key
+		case "b":
+			keyB = key
+		}
+	}
+	require.NotEmpty(t, keyA)
+	require.NotEmpty(t, keyB)
+
+	return c, keyA, keyB
+}
+
+func TestRouting(t *testing.T) {
+	a, b := dummyrds.Mocker{}, dummyrds.Mocker{}
+	c, keyA, keyB := mocked(t, a, b)
+
+	a.AddMock("GET "+keyA, "from a", false)
+	b.AddMock("GET "+keyB, "from b", false)
+	a.AddMock("PING", "PONG", false)
+	b.AddMock("PING", "PONG", false)
+
+	assert.Equal(t, "from a", c.Get(keyA).String())
+	assert.Equal(t, "from b", c.Get(keyB).String())
+	assert.Equal(t, "PONG", c.Ping().String())
+}
+
+func TestRouting_Set(t *testing.T) {
+	addrs := []string{redistest.StartServer(t), redistest.StartServer(t)}
+	if addrs[0] == addrs[1] {
+		t.Skip("sharded: needs two servers, unset " + redistest.EnvServerAddr + " to launch them")
+	}
+	c := New(redigo.Config{Endpoint: addrs[0]}, redigo.Config{Endpoint: addrs[1]})
+
+	// keys of other types are routed by the bytes redigo sends for them
+	for i := 0; i < 50; i++ {
+		key := redistest.Key(t, "bk"+strconv.Itoa(i))
+		require.NoError(t, c.Set([]byte(key), i).Error)
+		assert.Equal(t, i, c.Get(key).Int(), key)
+	}
+
+	require.NoError(t, c.Set(1234567, "number").Error)
+	assert.Equal(t, "number", c.Get("1234567").String())
+	require.NoError(t, c.Del("1234567"))
+}
+
+func TestFanOut(t *testing.T) {
+	a, b := dummyrds.Mocker{}, dummyrds.Mocker{}
+	c, keyA, keyB := mocked(t, a, b)
+
+	a.AddMock("MGET "+keyA, []interface{}{[]byte("1")}, false)
+	b.AddMock("MGET "+keyB, []interface{}{nil}, false)
+	a.AddMock("DEL "+keyA, 1, false)
+	b.AddMock("DEL "+keyB, "failed", true)
+	a.AddMock("UNLINK "+keyA, 1, false)
+	b.AddMock("UNLINK "+keyB, 1, false)
+
+	assert.Equal(t, []string{"", "1"}, c.MGet(keyB, keyA).StringSlice())
+	assert.EqualError(t, c.Del(keyA, keyB), "failed")
+	assert.Equal(t, 2, c.Unlink(keyA, keyB).Int())
+
+	assert.Equal(t, ErrCrossShard, c.Rename(keyA, keyB).Error)
+	assert.Equal(t, ErrCrossShard, c.PFCount(keyA, keyB).Error)
+	assert.Equal(t, ErrCrossShard, c.Eval("return 1", []string{keyA, keyB}).Error)
+	_, err := c.MSetNX(map[string]interface{}{keyA: 1, keyB: 2})
+	assert.Equal(t, ErrCrossShard, err)
+}
+
+func TestSUnion(t *testing.T) {
+	a, b := dummyrds.Mocker{}, dummyrds.Mocker{}
+	c, keyA, keyB := mocked(t, a, b)
+
+	a.AddMock("SUNION "+keyA, []string{"x", "y"}, false)
+	b.AddMock("SUNION "+keyB, []string{"y", "z"}, false)
+	a.AddMock("SINTER "+keyA, []string{"x", "y"}, false)
+	b.AddMock("SINTER "+keyB, []string{"y", "z"}, false)
+
+	assert.Equal(t, []string{"x", "y", "z"}, c.SUnion(keyA, keyB).StringSlice())
+	assert.Equal(t, []string{"y"}, c.SInter(keyA, keyB).StringSlice())
+}
+
+func TestScan(t *testing.T) {
+	a, b := dummyrds.Mocker{}, dummyrds.Mocker{}
+	c, _, _ := mocked(t, a, b)
+
+	a.AddMock("SCAN 0 * 10", []interface{}{[]byte("7"), []interface{}{[]byte("a1")}}, false)
+	a.AddMock("SCAN 7 * 10", []interface{}{[]byte("0"), []interface{}{[]byte("a2")}}, false)
+	b.AddMock("SCAN 0 * 10", []interface{}{[]byte("0"), []interface{}{[]byte("b1")}}, false)
+
+	var keys []string
+	cursor := 0
+	for {
+		next, page, err := c.Scan(cursor, "*", 10).ScanResult()
+		require.NoError(t, err)
+		keys = append(keys, page...)
+		if next == 0 {
+			break
+		}
+		cursor = next
+	}
+
+	assert.Equal(t, []string{"a1", "a2", "b1"}, keys)
+}
+
+func TestStatus(t *testing.T) {
+	c := NewWithClients(map[string]redis.Redis{
+		"a": dummyrds.New(dummyrds.Config{Status: redis.Status{
+			Connected:   true,
+			LastActive:  "Mon, 19 Oct 2026 10:00:00 WIB",
+			Role:        "master",
+			PingLatency: time.Millisecond,
+			ActiveCount: 2,
+		}}),
+		"b": dummyrds.New(dummyrds.Config{Status: redis.Status{
+			LastActive:  "Mon, 19 Oct 2026 09:00:00 WIB",
+			Role:        "master",
+			LastError:   "connection refused",
+			PingLatency: 3 * time.Millisecond,
+			ActiveCount: 1,
+		}}),
+	})
+
+	assert.Equal(t, redis.Status{
+		Name:        "a,b",
+		Role:        "master",
+		LastActive:  "Mon, 19 Oct 2026 09:00:00 WIB",
+		LastError:   "b: connection refused",
+		PingLatency: 3 * time.Millisecond,
+		ActiveCount: 3,
+	}, c.Status())
+
+	shards := c.ShardStatus()
+	assert.True(t, shards["a"].Connected)
+	assert.False(t, shards["b"].Connected)
+}
+
+func TestZUnionStore(t *testing.T) {
+	a, b := dummyrds.Mocker{}, dummyrds.Mocker{}
+	c, keyA, keyB := mocked(t, a, b)
+
+	a.AddMock("ZRANGE "+keyA+" 0 -1 WITHSCORES", []string{"x", "1", "y", "2"}, false)
+	b.AddMock("ZRANGE "+keyB+" 0 -1 WITHSCORES", []string{"y", "3", "z", "4"}, false)
+	a.AddMock("DEL "+keyA, 1, false)
+	a.AddMock("ZADD "+keyA+" 1 x 3 y 4 z", 3, false)
+
+	// the destination is written to its own shard
+	res := c.ZUnionStore(keyA, redis.ZStore{Keys: []string{keyA, keyB}, Aggregate: redis.AggregateMax})
+	require.NoError(t, res.Error)
+	assert.Equal(t, 3, res.Int())
+
+	a.AddMock("ZRANGE "+keyA+" 0 -1 WITHSCORES", "failed", true)
+	assert.EqualError(t, c.ZUnionStore(keyA, redis.ZStore{Keys: []string{keyA, keyB}}).Error, "failed")
+}
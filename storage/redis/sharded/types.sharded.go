@@ -0,0 +1,37 @@
+package sharded
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// Error list
+var (
+	// ErrCrossShard is returned by multi-key commands that can not be split when their keys
+	// live on different shards, group such keys with a hash tag, e.g. "{user:1}:cart"
+	ErrCrossShard = errors.New("sharded: keys belong to different shards")
+	// ErrDuplicateShard is returned when adding a shard under a name that is already used
+	ErrDuplicateShard = errors.New("sharded: duplicate shard name")
+)
+
+// Client spreads keys over standalone redis instances with rendezvous hashing
+type Client struct {
+	mu     sync.RWMutex
+	shards []shard
+}
+
+type shard struct {
+	name string
+	seed uint64
+	rds  redis.Redis
+}
+
+// group is the subset of a multi-key command owned by one shard,
+// index holds the position of every key in the original command
+type group struct {
+	rds   redis.Redis
+	keys  []string
+	index []int
+}
@@ -0,0 +1,80 @@
+package sharded
+
+import (
+	"sort"
+
+	"github.com/5112100070/publib/convert"
+	"github.com/5112100070/publib/storage/redis"
+)
+
+// ZUnionStore stores the union in destination, atomically only when all keys share a shard.
+// Otherwise the sets are read from their shards and combined client-side
+func (c *Client) ZUnionStore(destination string, store redis.ZStore) *redis.Result {
+	if rds, err := c.sameShard(append([]string{destination}, store.Keys...)...); err == nil {
+		return rds.ZUnionStore(destination, store)
+	}
+
+	scores := make(map[string]float64)
+	for i, key := range store.Keys {
+		res := c.shard(key).ZRangeWithScores(key, 0, -1)
+		if res.Error != nil {
+			return res
+		}
+
+		weight := 1.0
+		if i < len(store.Weights) {
+			weight = store.Weights[i]
+		}
+
+		for _, z := range res.ZSlice() {
+			member, score := convert.ToString(z.Member), z.Score*weight
+			prev, ok := scores[member]
+			if ok {
+				score = aggregate(store.Aggregate, prev, score)
+			}
+			scores[member] = score
+		}
+	}
+
+	rds := c.shard(destination)
+	if err := rds.Del(destination); err != nil {
+		return &redis.Result{Error: err}
+	}
+
+	if len(scores) > 0 {
+		members := make([]string, 0, len(scores))
+		for member := range scores {
+			members = append(members, member)
+		}
+		sort.Strings(members)
+
+		values := make([]redis.Z, len(members))
+		for i, member := range members {
+			values[i] = redis.Z{Score: scores[member], Member: member}
+		}
+
+		if err := rds.ZAdd(destination, values...); err != nil {
+			return &redis.Result{Error: err}
+		}
+	}
+
+	return &redis.Result{Value: int64(len(scores))}
+}
+
+// aggregate combines two scores of a member the way ZUNIONSTORE does
+func aggregate(op string, a, b float64) float64 {
+	switch op {
+	case redis.AggregateMin:
+		if b < a {
+			return b
+		}
+		return a
+	case redis.AggregateMax:
+		if b > a {
+			return b
+		}
+		return a
+	}
+
+	return a + b
+}
@@ -1,8 +1,76 @@
 package redis
 
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	rgo "github.com/gomodule/redigo/redis"
+)
+
+// Error list
+var (
+	// ErrNil is returned by typed commands when redis replies nil, e.g. a missing key
+	ErrNil = errors.New("redis: nil reply")
+	// ErrNoExpire is returned by PTTL when the key exists without a timeout
+	ErrNoExpire = errors.New("redis: key has no expire")
+	// ErrKeyNotExist is returned by PTTL when the key does not exist
+	ErrKeyNotExist = errors.New("redis: key does not exist")
+	// ErrInvalidTTL is returned for a negative or zero time to live where a positive one is required
+	ErrInvalidTTL = errors.New("redis: ttl must be positive")
+	// ErrMatchAll is returned by the bulk helpers for a pattern matching every key
+	ErrMatchAll = errors.New("redis: pattern matches every key")
+	// ErrKeyExists is returned by Restore when the key exists and replace is false
+	ErrKeyExists = errors.New("redis: key already exists")
+	// ErrClosed is returned by the commands of a closed client
+	ErrClosed = errors.New("redis: client is closed")
+	// ErrCloseTimeout is returned by Close when in-flight commands did not drain in time
+	ErrCloseTimeout = errors.New("redis: close timeout, in-flight commands did not drain")
+	// ErrConvert is wrapped by the errors of As, SliceOf and MapOf when a reply cannot be converted
+	ErrConvert = errors.New("redis: cannot convert reply")
+)
+
+// Operations of BitOp
+const (
+	BitAnd = "AND"
+	BitOr  = "OR"
+	BitXor = "XOR"
+	BitNot = "NOT"
+)
+
+// List sides of BLMove
+const (
+	Left  = "LEFT"
+	Right = "RIGHT"
+)
+
+// Aggregates of ZUnionStore
+const (
+	AggregateSum = "SUM"
+	AggregateMin = "MIN"
+	AggregateMax = "MAX"
+)
+
+// Distance units of the geo commands
+const (
+	GeoMeters     = "m"
+	GeoKilometers = "km"
+	GeoMiles      = "mi"
+	GeoFeet       = "ft"
+)
+
 // A Redis offers a standard interface for caching mechanism
 type Redis interface {
 	Ping() *Result
+	// Status returns the health of the client, it pings first unless the heartbeat runs
+	Status() Status
+	// DoHeartBeat will automatically spawn a goroutine to ping redis every two seconds, use this carefully
+	DoHeartBeat()
+	// StopBeat will stop heartbeat, exit from goroutine
+	StopBeat()
+	// Close rejects new commands with ErrClosed, drains the in-flight ones and releases the connections
+	Close() error
 	Get(string) *Result
 	MGet(...string) *Result
 	Setex(string, int, interface{}) error
@@ -17,21 +85,124 @@ type Redis interface {
 	HVals(hash string) *Result
 	HGetAll(hash string) *Result
 	HExists(key string, fieldKey string) *Result
+	HIncrBy(key, field string, increment int64) *Result
+	HIncrByFloat(key, field string, increment float64) *Result
+	HSetNX(key, field string, value interface{}) *Result
+	HLen(key string) *Result
+	HMGet(key string, fields ...string) *Result
+	HStrLen(key, field string) *Result
+	HScan(key string, cursor int, match string, count int) *Result
 	Incr(...string) error
 	IncrSingle(keys string) (int, error)
 	Decr(...string) error
+	IncrBy(key string, increment int64) (int64, error)
+	DecrBy(key string, decrement int64) (int64, error)
+	IncrByFloat(key string, increment float64) (float64, error)
+	SetNX(key string, value interface{}) (bool, error)
+	GetSet(key string, value interface{}) (string, error)
+	GetDel(key string) (string, error)
+	GetEx(key string, expiration time.Duration) (string, error)
+	MSet(values map[string]interface{}) error
+	MSetNX(values map[string]interface{}) (bool, error)
+	Append(key string, value interface{}) (int64, error)
+	StrLen(key string) (int64, error)
+	GetRange(key string, start, end int) (string, error)
+	SetRange(key string, offset int, value interface{}) (int64, error)
 	ZAdd(string, ...Z) error
 	ZRange(string, int, int) *Result
 	ZRangeByScore(string, string, string, int) *Result
+	ZRem(key string, members ...interface{}) *Result
+	ZIncrBy(key string, increment float64, member interface{}) *Result
+	ZScore(key string, member interface{}) *Result
+	ZRank(key string, member interface{}) *Result
+	ZRevRank(key string, member interface{}) *Result
+	ZRevRange(key string, start, stop int) *Result
+	ZRevRangeByScore(key, max, min string, limit int) *Result
+	ZRangeWithScores(key string, start, stop int) *Result
+	ZRevRangeWithScores(key string, start, stop int) *Result
+	ZRangeByScoreWithScores(key, min, max string, limit int) *Result
+	ZRevRangeByScoreWithScores(key, max, min string, limit int) *Result
+	ZCard(key string) *Result
+	ZCount(key, min, max string) *Result
+	ZRemRangeByScore(key, min, max string) *Result
+	ZRemRangeByRank(key string, start, stop int) *Result
+	ZPopMin(key string, count int) *Result
+	ZPopMax(key string, count int) *Result
+	ZUnionStore(destination string, store ZStore) *Result
+	SAdd(key string, members ...interface{}) *Result
+	SRem(key string, members ...interface{}) *Result
+	SMembers(key string) *Result
+	SIsMember(key string, member interface{}) *Result
+	SMIsMember(key string, members ...interface{}) *Result
+	SCard(key string) *Result
+	SPop(key string, count int) *Result
+	SRandMember(key string, count int) *Result
+	SInter(keys ...string) *Result
+	SUnion(keys ...string) *Result
+	SDiff(keys ...string) *Result
+	SInterStore(destination string, keys ...string) *Result
+	SUnionStore(destination string, keys ...string) *Result
+	SDiffStore(destination string, keys ...string) *Result
 	Ttl(string) *Result
+	PSetEx(key string, ttl time.Duration, value interface{}) error
+	PExpire(key string, ttl time.Duration) (bool, error)
+	ExpireAt(key string, at time.Time) (bool, error)
+	PExpireAt(key string, at time.Time) (bool, error)
+	Persist(key string) (bool, error)
+	PTTL(key string) (time.Duration, error)
 	Exists(string) *Result
 	Rename(string, string) *Result
 	Set(key, value interface{}, args ...interface{}) *Result
-	LPush(key string, value interface{}) error
-	RPush(key string, value interface{}) error
+	SetWithOptions(key string, value interface{}, opts SetOptions) (written bool, previous string, err error)
+	LPush(key string, values ...interface{}) error
+	RPush(key string, values ...interface{}) error
 	LPop(key string) *Result
+	RPop(key string) *Result
 	LLen(key string) *Result
+	LRange(key string, start, stop int) *Result
+	LTrim(key string, start, stop int) error
+	LRem(key string, count int, value interface{}) *Result
+	LIndex(key string, index int) *Result
+	BLPop(timeout time.Duration, keys ...string) *Result
+	BRPop(timeout time.Duration, keys ...string) *Result
+	BLMove(source, destination, srcSide, destSide string, timeout time.Duration) *Result
 	Scan(cursor int, match string, count int) *Result
+	ScanType(cursor int, match string, count int, keyType string) *Result
+	Unlink(keys ...string) *Result
+	Dump(key string) *Result
+	Restore(key string, ttl time.Duration, value []byte, replace bool) error
+	SetBit(key string, offset int64, value int) *Result
+	GetBit(key string, offset int64) *Result
+	BitCount(key string) *Result
+	BitCountRange(key string, start, end int64) *Result
+	BitPos(key string, bit int) *Result
+	BitPosRange(key string, bit int, start, end int64) *Result
+	BitOp(op, destination string, keys ...string) *Result
+	BitField(key string, ops ...BitFieldOp) *Result
+	PFAdd(key string, elements ...interface{}) *Result
+	PFCount(keys ...string) *Result
+	PFMerge(destination string, keys ...string) error
+	GeoAdd(key string, locations ...GeoLocation) *Result
+	GeoPos(key string, members ...string) *Result
+	GeoDist(key, member1, member2, unit string) *Result
+	GeoSearch(key string, query GeoSearchQuery) *Result
+	Eval(script string, keys []string, args ...interface{}) *Result
+}
+
+// Status for status response
+type Status struct {
+	Name       string `json:"name"`
+	Connected  bool   `json:"connected"`
+	Role       string `json:"role"`
+	LastActive string `json:"last_active"`
+	// LastError is the last connection error, kept after the client reconnects
+	LastError   string        `json:"last_error"`
+	PingLatency time.Duration `json:"ping_latency"`
+	// connection pool
+	ActiveCount  int           `json:"active_count"`
+	IdleCount    int           `json:"idle_count"`
+	WaitCount    int64         `json:"wait_count"`
+	WaitDuration time.Duration `json:"wait_duration"`
 }
 
 // Result struct
@@ -39,7 +210,271 @@ type Result struct {
 	Value interface{}
 	Error error
 }
+
+// Codec decodes the structs of As, SliceOf and MapOf, JSONCodec by default
+type Codec interface {
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// CodecFunc adapts an unmarshal function such as json.Unmarshal to a Codec
+type CodecFunc func(data []byte, v interface{}) error
+
+// Unmarshal calls f
+func (f CodecFunc) Unmarshal(data []byte, v interface{}) error {
+	return f(data, v)
+}
+
+// Z is a sorted set member with its score
 type Z struct {
 	Score  float64
 	Member interface{}
 }
+
+// ZStore are the sorted sets combined by ZUnionStore
+type ZStore struct {
+	Keys []string
+	// Weights multiply the scores of the key at the same index, default 1
+	Weights []float64
+	// Aggregate combines the scores of a member found in several keys, default AggregateSum
+	Aggregate string
+}
+
+// Args returns the ZUNIONSTORE arguments of the store, after the destination
+func (s ZStore) Args() []interface{} {
+	args := make([]interface{}, 0, 3+2*len(s.Keys))
+	args = append(args, len(s.Keys))
+	for _, key := range s.Keys {
+		args = append(args, key)
+	}
+
+	if len(s.Weights) > 0 {
+		args = append(args, "WEIGHTS")
+		for _, w := range s.Weights {
+			args = append(args, formatFloat(w))
+		}
+	}
+
+	if s.Aggregate != "" {
+		args = append(args, "AGGREGATE", s.Aggregate)
+	}
+
+	return args
+}
+
+// BitFieldOp is a BITFIELD subcommand with its arguments, build it with
+// BitFieldGet, BitFieldSet, BitFieldIncrBy or BitFieldOverflow
+type BitFieldOp []interface{}
+
+// BitFieldGet reads the integer of type typ, e.g. "u8" or "i16", at offset
+func BitFieldGet(typ string, offset int64) BitFieldOp {
+	return BitFieldOp{"GET", typ, offset}
+}
+
+// BitFieldSet writes value as an integer of type typ at offset, replies the previous value
+func BitFieldSet(typ string, offset int64, value int64) BitFieldOp {
+	return BitFieldOp{"SET", typ, offset, value}
+}
+
+// BitFieldIncrBy increments the integer of type typ at offset, replies the new value
+func BitFieldIncrBy(typ string, offset int64, increment int64) BitFieldOp {
+	return BitFieldOp{"INCRBY", typ, offset, increment}
+}
+
+// BitFieldOverflow sets the overflow behavior of the following SET and INCRBY: "WRAP", "SAT" or "FAIL"
+func BitFieldOverflow(behavior string) BitFieldOp {
+	return BitFieldOp{"OVERFLOW", behavior}
+}
+
+// GeoLocation is a member of a geospatial index, read it with Result.GeoLocations
+type GeoLocation struct {
+	Name      string
+	Longitude float64
+	Latitude  float64
+	// Dist is the distance from the search center, only replied by GeoSearch WithDist
+	Dist float64
+}
+
+// GeoSearchQuery searches the members of a geospatial index within an area
+type GeoSearchQuery struct {
+	// Center of the area, the position of Member when set, otherwise Longitude and Latitude
+	Member    string
+	Longitude float64
+	Latitude  float64
+
+	// Radius searches a circle, otherwise a box of Width and Height
+	Radius float64
+	Width  float64
+	Height float64
+	// Unit of the area and the replied distances, default GeoMeters
+	Unit string
+
+	// Sort is "ASC" for nearest first or "DESC", unsorted when empty
+	Sort string
+	// Count limits the number of members, 0 for no limit
+	Count     int
+	WithCoord bool
+	WithDist  bool
+}
+
+// Args returns the GEOSEARCH arguments of the query, after the key
+func (q GeoSearchQuery) Args() []interface{} {
+	unit := q.Unit
+	if unit == "" {
+		unit = GeoMeters
+	}
+
+	var args []interface{}
+	if q.Member != "" {
+		args = append(args, "FROMMEMBER", q.Member)
+	} else {
+		args = append(args, "FROMLONLAT", formatFloat(q.Longitude), formatFloat(q.Latitude))
+	}
+
+	if q.Radius > 0 {
+		args = append(args, "BYRADIUS", formatFloat(q.Radius), unit)
+	} else {
+		args = append(args, "BYBOX", formatFloat(q.Width), formatFloat(q.Height), unit)
+	}
+
+	if q.Sort != "" {
+		args = append(args, q.Sort)
+	}
+	if q.Count > 0 {
+		args = append(args, "COUNT", q.Count)
+	}
+	if q.WithCoord {
+		args = append(args, "WITHCOORD")
+	}
+	if q.WithDist {
+		args = append(args, "WITHDIST")
+	}
+
+	return args
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// SetOptions of SetWithOptions, conflicting options such as NX with XX or TTL
+// with KeepTTL are rejected by redis
+type SetOptions struct {
+	// NX only sets a missing key, XX only sets an existing key
+	NX bool
+	XX bool
+
+	// TTL expires the key after EX seconds, or PX milliseconds when it is not whole seconds
+	TTL time.Duration
+	// ExpireAt expires the key at EXAT seconds, or PXAT milliseconds when it is not whole seconds
+	ExpireAt time.Time
+	// KeepTTL retains the timeout of the existing key
+	KeepTTL bool
+
+	// Get replies the previous value of the key
+	Get bool
+}
+
+// Args returns the SET arguments of the options, after the key and value
+func (o SetOptions) Args() []interface{} {
+	var args []interface{}
+	if o.NX {
+		args = append(args, "NX")
+	}
+	if o.XX {
+		args = append(args, "XX")
+	}
+
+	if o.TTL > 0 {
+		if o.TTL%time.Second == 0 {
+			args = append(args, "EX", int64(o.TTL/time.Second))
+		} else {
+			args = append(args, "PX", Milliseconds(o.TTL))
+		}
+	}
+	if !o.ExpireAt.IsZero() {
+		if o.ExpireAt.Nanosecond() == 0 {
+			args = append(args, "EXAT", o.ExpireAt.Unix())
+		} else {
+			args = append(args, "PXAT", o.ExpireAt.UnixNano()/int64(time.Millisecond))
+		}
+	}
+	if o.KeepTTL {
+		args = append(args, "KEEPTTL")
+	}
+
+	if o.Get {
+		args = append(args, "GET")
+	}
+
+	return args
+}
+
+// Milliseconds converts ttl for the millisecond commands such as PX, a positive ttl
+// under a millisecond is rounded up as redis rejects or deletes on zero
+func Milliseconds(ttl time.Duration) int64 {
+	if ttl > 0 && ttl < time.Millisecond {
+		return 1
+	}
+	return int64(ttl / time.Millisecond)
+}
+
+// FormatArg returns the bytes redigo sends for a command argument: strings and []byte
+// as is, int, int64 and float64 in decimal, bool as 1 or 0, nil as empty, an
+// rgo.Argument by its RedisArg and the other types with fmt.Print
+func FormatArg(arg interface{}) []byte {
+	return formatArg(arg, true)
+}
+
+func formatArg(arg interface{}, argumentOK bool) []byte {
+	switch v := arg.(type) {
+	case string:
+		return []byte(v)
+	case []byte:
+		return v
+	case int:
+		return strconv.AppendInt(nil, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(nil, v, 10)
+	case float64:
+		return strconv.AppendFloat(nil, v, 'g', -1, 64)
+	case bool:
+		if v {
+			return []byte("1")
+		}
+		return []byte("0")
+	case nil:
+		return []byte{}
+	case rgo.Argument:
+		// Like redigo, the value of RedisArg is not unwrapped again
+		if argumentOK {
+			return formatArg(v.RedisArg(), false)
+		}
+	}
+	return []byte(fmt.Sprint(arg))
+}
+
+// ParseReply tells from the SET reply whether the value was written and the previous value.
+// Without Get redis replies OK or nil when NX or XX prevent the write. With Get it replies
+// the previous value, so the write happened unless NX found or XX missed a previous value
+func (o SetOptions) ParseReply(res *Result) (written bool, previous string, err error) {
+	if res.Error != nil {
+		return false, "", res.Error
+	}
+
+	if !o.Get {
+		return res.Value != nil, "", nil
+	}
+
+	exists := res.Value != nil
+	if exists {
+		previous = res.String()
+	}
+
+	switch {
+	case o.NX:
+		return !exists, previous, nil
+	case o.XX:
+		return exists, previous, nil
+	}
+	return true, previous, nil
+}
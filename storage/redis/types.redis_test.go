@@ -0,0 +1,121 @@
+package redis_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/5112100070/publib/storage/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeoSearchQueryArgs(t *testing.T) {
+	q := GeoSearchQuery{Member: "a", Radius: 1.5, Unit: GeoKilometers}
+	assert.Equal(t, []interface{}{"FROMMEMBER", "a", "BYRADIUS", "1.5", "km"}, q.Args())
+
+	q = GeoSearchQuery{
+		Longitude: 106.8,
+		Latitude:  -6.2,
+		Width:     200,
+		Height:    100,
+		Sort:      "ASC",
+		Count:     3,
+		WithCoord: true,
+		WithDist:  true,
+	}
+	assert.Equal(t, []interface{}{
+		"FROMLONLAT", "106.8", "-6.2", "BYBOX", "200", "100", "m", "ASC", "COUNT", 3, "WITHCOORD", "WITHDIST",
+	}, q.Args())
+}
+
+func TestSetOptionsArgs(t *testing.T) {
+	assert.Nil(t, SetOptions{}.Args())
+
+	opts := SetOptions{NX: true, TTL: 10 * time.Second, Get: true}
+	assert.Equal(t, []interface{}{"NX", "EX", int64(10), "GET"}, opts.Args())
+
+	opts = SetOptions{XX: true, TTL: 1500 * time.Millisecond}
+	assert.Equal(t, []interface{}{"XX", "PX", int64(1500)}, opts.Args())
+
+	opts = SetOptions{TTL: 500 * time.Microsecond}
+	assert.Equal(t, []interface{}{"PX", int64(1)}, opts.Args(), "rounded up as PX 0 is rejected")
+
+	opts = SetOptions{ExpireAt: time.Unix(1700000000, 0)}
+	assert.Equal(t, []interface{}{"EXAT", int64(1700000000)}, opts.Args())
+
+	opts = SetOptions{ExpireAt: time.Unix(1700000000, 250*int64(time.Millisecond)), KeepTTL: true}
+	assert.Equal(t, []interface{}{"PXAT", int64(1700000000250), "KEEPTTL"}, opts.Args())
+}
+
+func TestMilliseconds(t *testing.T) {
+	assert.Equal(t, int64(0), Milliseconds(0))
+	assert.Equal(t, int64(1), Milliseconds(time.Nanosecond))
+	assert.Equal(t, int64(1), Milliseconds(1999*time.Microsecond))
+	assert.Equal(t, int64(1500), Milliseconds(1500*time.Millisecond))
+	assert.Equal(t, int64(-1), Milliseconds(-time.Millisecond))
+}
+
+// point is an argument formatted by RedisArg
+type point struct{ x, y int }
+
+func (p point) RedisArg() interface{} { return fmt.Sprintf("%d,%d", p.x, p.y) }
+
+func TestFormatArg(t *testing.T) {
+	for _, tc := range []struct {
+		arg  interface{}
+		want string
+	}{
+		{"foo", "foo"},
+		{[]byte("bk"), "bk"},
+		{42, "42"},
+		{int64(-7), "-7"},
+		{int32(5), "5"},
+		{uint8(200), "200"},
+		{1.5, "1.5"},
+		{1e21, "1e+21"},
+		{float32(0.25), "0.25"},
+		{true, "1"},
+		{false, "0"},
+		{nil, ""},
+		{point{1, 2}, "1,2"},
+	} {
+		assert.Equal(t, tc.want, string(FormatArg(tc.arg)), "%#v", tc.arg)
+	}
+}
+
+func TestSetOptionsParseReply(t *testing.T) {
+	tests := []struct {
+		opts     SetOptions
+		reply    interface{}
+		written  bool
+		previous string
+	}{
+		{SetOptions{}, "OK", true, ""},
+		{SetOptions{NX: true}, nil, false, ""},
+		{SetOptions{Get: true}, nil, true, ""},
+		{SetOptions{Get: true}, []byte("old"), true, "old"},
+		{SetOptions{NX: true, Get: true}, nil, true, ""},
+		{SetOptions{NX: true, Get: true}, []byte("old"), false, "old"},
+		{SetOptions{XX: true, Get: true}, nil, false, ""},
+		{SetOptions{XX: true, Get: true}, []byte("old"), true, "old"},
+	}
+
+	for _, tt := range tests {
+		written, previous, err := tt.opts.ParseReply(&Result{Value: tt.reply})
+		assert.NoError(t, err)
+		assert.Equal(t, tt.written, written, "%+v %v", tt.opts, tt.reply)
+		assert.Equal(t, tt.previous, previous, "%+v %v", tt.opts, tt.reply)
+	}
+
+	written, _, err := SetOptions{}.ParseReply(&Result{Error: errors.New("test")})
+	assert.False(t, written)
+	assert.EqualError(t, err, "test")
+}
+
+func TestZStoreArgs(t *testing.T) {
+	assert.Equal(t, []interface{}{2, "a", "b"}, ZStore{Keys: []string{"a", "b"}}.Args())
+
+	s := ZStore{Keys: []string{"a", "b"}, Weights: []float64{2, 0.5}, Aggregate: AggregateMin}
+	assert.Equal(t, []interface{}{2, "a", "b", "WEIGHTS", "2", "0.5", "AGGREGATE", "MIN"}, s.Args())
+}
@@ -0,0 +1,117 @@
+// Package unique counts unique members over rolling days with one HyperLogLog per day.
+//
+// Every name, e.g. a product ID, gets a HyperLogLog key per day. Counting over
+// several days merges the daily keys on read, so weekly or monthly uniques do
+// not need their own sets. Counts are estimations with a standard error of 0.81%.
+package unique
+
+import (
+	"time"
+
+	"github.com/5112100070/publib/convert"
+	"github.com/5112100070/publib/storage/redis"
+)
+
+const dayFormat = "20060102"
+
+// Config of a unique counter
+type Config struct {
+	// Prefix of the daily keys
+	Prefix string
+	// Retention is how long a daily key is kept, it should cover the longest
+	// period counted. Default is 31 days
+	Retention time.Duration
+	// Location splits the days, default is WIB (Asia/Jakarta)
+	Location *time.Location
+}
+
+// Counter of unique members per day
+type Counter struct {
+	rds       redis.Redis
+	prefix    string
+	retention int
+	location  *time.Location
+}
+
+// New unique counter
+func New(rds redis.Redis, config Config) *Counter {
+
+	// Set default 31 days retention
+	if config.Retention <= 0 {
+		config.Retention = 31 * 24 * time.Hour
+	}
+
+	if config.Location == nil {
+		config.Location = convert.JakartaLocation
+	}
+
+	return &Counter{
+		rds:       rds,
+		prefix:    config.Prefix,
+		retention: int(config.Retention / time.Second),
+		location:  config.Location,
+	}
+}
+
+// Add records members for name today
+func (c *Counter) Add(name string, members ...interface{}) error {
+	return c.AddAt(name, time.Now(), members...)
+}
+
+// AddAt records members for name on the day of t
+func (c *Counter) AddAt(name string, t time.Time, members ...interface{}) error {
+	key := c.Key(name, t)
+	if err := c.rds.PFAdd(key, members...).Error; err != nil {
+		return err
+	}
+
+	return c.rds.Expire(key, c.retention)
+}
+
+// Daily returns the unique members of name on the day of t
+func (c *Counter) Daily(name string, t time.Time) (int64, error) {
+	return c.Count(name, t, 1)
+}
+
+// Weekly returns the unique members of name over the last 7 days, including today
+func (c *Counter) Weekly(name string) (int64, error) {
+	return c.Count(name, time.Now(), 7)
+}
+
+// Monthly returns the unique members of name over the last 30 days, including today
+func (c *Counter) Monthly(name string) (int64, error) {
+	return c.Count(name, time.Now(), 30)
+}
+
+// Count returns the unique members of name over days days ending on the day of end
+func (c *Counter) Count(name string, end time.Time, days int) (int64, error) {
+	res := c.rds.PFCount(c.keys(name, end, days)...)
+	return res.Int64(), res.Error
+}
+
+// Merge stores the union of days days ending on the day of end into destination,
+// to cache a period read many times
+func (c *Counter) Merge(destination, name string, end time.Time, days int) error {
+	return c.rds.PFMerge(destination, c.keys(name, end, days)...)
+}
+
+// Key returns the HyperLogLog key of name on the day of t.
+// The name is a hash tag so every day of a name lives on the same shard
+func (c *Counter) Key(name string, t time.Time) string {
+	return c.prefix + "{" + name + "}:" + t.In(c.location).Format(dayFormat)
+}
+
+// keys returns the daily keys of the period, most recent first
+func (c *Counter) keys(name string, end time.Time, days int) []string {
+	if days < 1 {
+		days = 1
+	}
+
+	end = end.In(c.location)
+	keys := make([]string, days)
+	for i := range keys {
+		keys[i] = c.Key(name, end.AddDate(0, 0, -i))
+	}
+
+	return keys
+}
@@ -0,0 +1,72 @@
+package unique_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/5112100070/publib/storage/redis/dummyrds"
+	"github.com/5112100070/publib/storage/redis/redigo"
+	"github.com/5112100070/publib/storage/redis/redistest"
+	. "github.com/5112100070/publib/storage/redis/unique"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounter(t *testing.T) {
+	redistest.SkipInProcess(t, "PFCOUNT of several keys")
+	rds := redigo.New(redigo.Config{Endpoint: redistest.StartServer(t)})
+	c := New(rds, Config{Prefix: redistest.Key(t, "visitors")})
+
+	today := time.Now()
+	yesterday := today.AddDate(0, 0, -1)
+	lastMonth := today.AddDate(0, 0, -40)
+
+	require.NoError(t, c.Add("product-1", "alice", "bob"))
+	require.NoError(t, c.AddAt("product-1", yesterday, "bob", "carol"))
+	require.NoError(t, c.AddAt("product-1", lastMonth, "dave"))
+	require.NoError(t, c.Add("product-2", "erin"))
+
+	n, err := c.Daily("product-1", today)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	n, err = c.Weekly("product-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n, "bob is counted once over the week")
+
+	n, err = c.Monthly("product-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+
+	n, err = c.Count("product-1", today, 41)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), n)
+
+	dest := redistest.Key(t, "{product-1}:week")
+	require.NoError(t, c.Merge(dest, "product-1", today, 7))
+	assert.Equal(t, int64(3), rds.PFCount(dest).Int64())
+}
+
+func TestCounter_Keys(t *testing.T) {
+	m := dummyrds.Mocker{}
+	m.AddMock("PFADD visitors:{foo}:20240101 a", 1, false)
+	m.AddMock("EXPIRE visitors:{foo}:20240101 86400", "OK", false)
+	m.AddMock("PFCOUNT visitors:{foo}:20240101 visitors:{foo}:20231231", 2, false)
+	m.AddMock("PFADD visitors:{err}:20240101 a", "failed", true)
+
+	c := New(dummyrds.New(dummyrds.Config{MockingMap: m}), Config{
+		Prefix:    "visitors:",
+		Retention: 24 * time.Hour,
+	})
+
+	// 2023-12-31 23:00 UTC is already January 1st in WIB
+	at := time.Date(2023, 12, 31, 23, 0, 0, 0, time.UTC)
+	assert.Equal(t, "visitors:{foo}:20240101", c.Key("foo", at))
+
+	require.NoError(t, c.AddAt("foo", at, "a"))
+	n, err := c.Count("foo", at, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	assert.EqualError(t, c.AddAt("err", at, "a"), "failed")
+}